@@ -1,21 +1,33 @@
 package mgmt
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"sort"
 
 	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/organizations"
 	outputflag "github.com/openshift/osdctl/cmd/getoutput"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
 
+	"github.com/openshift/osdctl/pkg/k8s"
 	"github.com/openshift/osdctl/pkg/printer"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 // Global variables
@@ -26,12 +38,139 @@ var (
 	OSDStaging1OuID   = "ou-0wd6-z6tzkjek"
 )
 
+// govCloudRegion is the region the GovCloud half of a paired account is tagged from
+const govCloudRegion = "us-gov-west-1"
+
+// defaultEmailPrefix starts the name and email of created accounts, followed by a random suffix
+const defaultEmailPrefix = "osd-creds-mgmt+"
+
+// accountNameSuffixLength is the length of the random suffix of created accounts' names
+const accountNameSuffixLength = 6
+
+// maxAccountNameLength is the AWS limit on account name lengths
+const maxAccountNameLength = 50
+
+// accountEmailDomain ends the email of created accounts
+const accountEmailDomain = "@redhat.com"
+
+// emailPrefixRegex matches the characters allowed in the local part of a created account's email
+var emailPrefixRegex = regexp.MustCompile(`^[a-zA-Z0-9._+-]+$`)
+
+// defaultReservedEmailPatterns match emails created accounts must not get: role addresses the organization reserves,
+// and addresses AWS rejects as invalid, e.g. with consecutive dots or a dot right before the '@'
+var defaultReservedEmailPatterns = []string{
+	`^(admin|administrator|root|postmaster|hostmaster|webmaster|abuse|security|noreply|no-reply)[+@]`,
+	`\.\.`,
+	`^\.`,
+	`\.@`,
+}
+
+// maxEmailAttempts is how many random suffixes are tried before giving up on generating an unreserved email
+const maxEmailAttempts = 10
+
+var ErrNoUnreservedEmail = fmt.Errorf("could not generate an account email not matching a reserved email pattern, check --email-prefix and --reserved-email-pattern")
+
+// The --on-conflict policies, applied when the account being tagged is already owned by someone else
+const (
+	onConflictSkip      = "skip"
+	onConflictFail      = "fail"
+	onConflictOverwrite = "overwrite"
+)
+
+// The --owner-format policies, normalizing the owner tag value of assigned accounts
+const (
+	ownerFormatUsername = "username"
+	ownerFormatEmail    = "email"
+	ownerFormatRaw      = "raw"
+)
+
+// defaultAccessRoleName is the role AWS Organizations creates in member accounts for the management account to assume
+const defaultAccessRoleName = "OrganizationAccountAccessRole"
+
+// roleNameRegex matches valid IAM role names
+var roleNameRegex = regexp.MustCompile(`^[\w+=,.@-]{1,64}$`)
+
 type accountAssignOptions struct {
-	awsClient    awsprovider.Client
-	username     string
-	payerAccount string
-	accountID    string
-	output       string
+	awsClient     awsprovider.Client
+	username      string
+	payerAccount  string
+	accountID     string
+	output        string
+	count         int
+	ttl           time.Duration
+	recursive     bool
+	excludeOUs    []string
+	jsonFromFile  string
+	ownerFile     string
+	noMove        bool
+	noCreate      bool
+	forceRecreate bool
+	verify        bool
+	dryRun        bool
+	tags          []string
+	// ous overrides the OUs of the payer account
+	ous accountOUs
+	// waitForPool is how long to wait for an account to be released when the pool is empty, instead of creating one
+	waitForPool time.Duration
+	// minPool is how many untagged accounts must remain in the pool after picking one, a smaller pool is reported as
+	// a warning, or as an error with strict
+	minPool int
+	strict  bool
+	// tagConcurrency is how many accounts of the pool have their tags read at the same time while looking for an
+	// untagged one, one or less reads them one by one
+	tagConcurrency int
+	// json is the deprecated alias of '-o json' some scripts still use
+	json bool
+	// ownerFormat is how the owner tag value is normalized, one of the ownerFormat policies
+	ownerFormat string
+	// onConflict is what tagging does with an account already owned by someone else, one of the onConflict policies
+	onConflict string
+	// emailPrefix starts the name and email of created accounts, it defaults to defaultEmailPrefix
+	emailPrefix string
+	// reservedEmailPatterns match the emails created accounts must not get, and reservedEmails are their compiled
+	// form. defaultReservedEmailPatterns apply when reservedEmails is nil.
+	reservedEmailPatterns []string
+	reservedEmails        []*regexp.Regexp
+	// roleName is the role in the assigned account the printed assume-role command assumes
+	roleName string
+
+	// extraTags are the tags given with --tag, applied along with the ownership tags
+	extraTags map[string]string
+	// ocmUsername returns the username of the current OCM account, used when no username is given
+	ocmUsername func() (string, error)
+
+	metricsGateway string
+	metrics        *accountMetrics
+	// trackConfigMap is the namespace/name of the ConfigMap assigned accounts are recorded in, written by tracker
+	trackConfigMap string
+	tracker        *assignmentTracker
+
+	// govCloud creates GovCloud paired accounts, the GovCloud half is tagged using govCloudClient
+	govCloud        bool
+	govCloudProfile string
+	govCloudClient  awsprovider.Client
+	// govCloudAccountIDs maps the commercial accounts created to their GovCloud account
+	govCloudAccountIDs map[string]string
+
+	// verbose prints how long each phase of the assignment took, as recorded in timings
+	verbose bool
+	timings phaseTimings
+	// explain prints why each account of the pool was skipped, as recorded in skipped, when none was available
+	explain bool
+	skipped skippedAccounts
+
+	// ctx is cancelled when the command is interrupted or --timeout is exceeded, it may be nil
+	ctx context.Context
+	// timeout bounds the whole command, zero means no deadline
+	timeout time.Duration
+	// phase is the phase of the assignment in progress, it is named in the error when the timeout is exceeded
+	phase string
+
+	// claimedAt is the time recorded in the claimed-at tag, it is set when the first account is tagged
+	claimedAt time.Time
+	// listedTags are the tags of the accounts checked for ownership, newly created accounts have none. They are
+	// kept up to date as tags are applied.
+	listedTags map[string]map[string]string
 
 	flags      *genericclioptions.ConfigFlags
 	printFlags *printer.PrintFlags
@@ -40,12 +179,80 @@ type accountAssignOptions struct {
 }
 
 type assignResponse struct {
-	Username string `json:"username" yaml:"username"`
-	Id       string `json:"id" yaml:"id"`
+	Username   string            `json:"username" yaml:"username"`
+	Id         string            `json:"id" yaml:"id"`
+	GovCloudId string            `json:"govCloudId,omitempty" yaml:"govCloudId,omitempty"`
+	OU         string            `json:"ou,omitempty" yaml:"ou,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// ExpiresAt is when the assignment expires, it is only set with --ttl
+	ExpiresAt string `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`
+	// AssumeRoleCommand is an AWS CLI command giving credentials for the assigned account
+	AssumeRoleCommand string `json:"assumeRoleCommand,omitempty" yaml:"assumeRoleCommand,omitempty"`
 }
 
 func (f assignResponse) String() string {
-	return fmt.Sprintf("  Username: %s\n  Account: %s\n", f.Username, f.Id)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  Username: %s\n  Account: %s\n", f.Username, f.Id))
+	if f.GovCloudId != "" {
+		sb.WriteString(fmt.Sprintf("  GovCloud Account: %s\n", f.GovCloudId))
+	}
+	if f.ExpiresAt != "" {
+		sb.WriteString(fmt.Sprintf("  Expires at: %s\n", f.ExpiresAt))
+	}
+	if f.AssumeRoleCommand != "" {
+		sb.WriteString(fmt.Sprintf("  Assume role with: %s\n", f.AssumeRoleCommand))
+	}
+	return sb.String()
+}
+
+type assignMultipleResponse struct {
+	Username    string            `json:"username" yaml:"username"`
+	Ids         []string          `json:"ids" yaml:"ids"`
+	GovCloudIds map[string]string `json:"govCloudIds,omitempty" yaml:"govCloudIds,omitempty"`
+	ExpiresAt   string            `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`
+}
+
+func (f assignMultipleResponse) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  Username: %s\n", f.Username))
+	if f.ExpiresAt != "" {
+		sb.WriteString(fmt.Sprintf("  Expires at: %s\n", f.ExpiresAt))
+	}
+	for _, id := range f.Ids {
+		if govCloudID, ok := f.GovCloudIds[id]; ok {
+			sb.WriteString(fmt.Sprintf("  Account: %s (GovCloud: %s)\n", id, govCloudID))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  Account: %s\n", id))
+	}
+	return sb.String()
+}
+
+// ownerAssignment is the accounts assigned to one owner of an --owner-file roster
+type ownerAssignment struct {
+	Owner string   `json:"owner" yaml:"owner"`
+	Ids   []string `json:"ids" yaml:"ids"`
+	Error string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type assignRosterResponse struct {
+	Assignments []ownerAssignment `json:"assignments" yaml:"assignments"`
+}
+
+func (f assignRosterResponse) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-30s %s\n", "OWNER", "ACCOUNTS"))
+	for _, a := range f.Assignments {
+		accounts := strings.Join(a.Ids, ", ")
+		if a.Error != "" {
+			if accounts != "" {
+				accounts += " "
+			}
+			accounts += fmt.Sprintf("(failed: %s)", a.Error)
+		}
+		sb.WriteString(fmt.Sprintf("%-30s %s\n", a.Owner, accounts))
+	}
+	return sb.String()
 }
 
 func newAccountAssignOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *accountAssignOptions {
@@ -54,6 +261,7 @@ func newAccountAssignOptions(streams genericclioptions.IOStreams, flags *generic
 		printFlags:    printer.NewPrintFlags(),
 		IOStreams:     streams,
 		GlobalOptions: globalOpts,
+		ocmUsername:   currentOCMUsername,
 	}
 }
 
@@ -65,152 +273,920 @@ func newCmdAccountAssign(streams genericclioptions.IOStreams, flags *genericclio
 		Short:             "Assign account to user",
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			outputflag.CheckErr(globalOpts.Output, ops.complete(cmd, args))
+			// Interrupting the command stops scanning and waiting, rather than killing it mid-way
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			if ops.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, ops.timeout)
+				defer cancel()
+			}
+			ops.ctx = ctx
+			err := ops.run()
+			ops.metrics.push()
+			if ops.verbose {
+				ops.timings.write(os.Stderr, assignPhases)
+			}
+			if ops.explain && err == ErrNoUntaggedAccounts {
+				ops.skipped.write(os.Stderr)
+			}
+			outputflag.CheckErr(globalOpts.Output, err)
 		},
 	}
 	ops.printFlags.AddFlags(accountAssignCmd)
 	accountAssignCmd.Flags().StringVarP(&ops.payerAccount, "payer-account", "p", "", "Payer account type")
-	accountAssignCmd.Flags().StringVarP(&ops.username, "username", "u", "", "LDAP username")
+	accountAssignCmd.Flags().StringVarP(&ops.username, "username", "u", "", "LDAP username, defaults to the current OCM account's username or $USER")
 	accountAssignCmd.Flags().StringVarP(&ops.accountID, "account-id", "i", "", "(optional) Specific AWS account ID to assign")
+	accountAssignCmd.Flags().IntVar(&ops.count, "count", 1, "Number of accounts to assign to the user")
+	accountAssignCmd.Flags().DurationVar(&ops.ttl, "ttl", 0, "(optional) How long the account is assigned for, e.g. 72h. Sets an expires-at tag on the account")
+	accountAssignCmd.Flags().BoolVar(&ops.recursive, "recursive", false, "Also scan the OUs below the root for untagged accounts")
+	accountAssignCmd.Flags().StringSliceVar(&ops.excludeOUs, "exclude-ou", []string{}, "OU ID to skip when scanning recursively, can be repeated")
+	accountAssignCmd.Flags().StringArrayVar(&ops.tags, "tag", []string{}, "Additional key=value tag to set on the account, can be repeated")
+	accountAssignCmd.Flags().BoolVar(&ops.noCreate, "no-create", false, "Fail instead of creating a new account when no untagged accounts are available")
+	accountAssignCmd.Flags().BoolVar(&ops.explain, "explain", false, "When no untagged account is available, print why each account in the pool was skipped")
+	accountAssignCmd.Flags().DurationVar(&ops.waitForPool, "wait-for-pool", 0, "(optional) When no untagged accounts are available, keep checking the pool for this long, e.g. 30m, instead of creating a new account")
+	accountAssignCmd.Flags().IntVar(&ops.minPool, "min-pool", 0, "(optional) Warn when assigning an account from the pool leaves fewer than this many untagged accounts in it")
+	accountAssignCmd.Flags().BoolVar(&ops.strict, "strict", false, "Fail instead of warning when the pool would drop below --min-pool")
+	accountAssignCmd.Flags().IntVar(&ops.tagConcurrency, "tag-concurrency", 1, "Number of accounts of the pool whose tags are read at the same time while looking for an untagged account")
+	accountAssignCmd.Flags().StringVar(&ops.ownerFormat, "owner-format", ownerFormatRaw, "How the owner tag is stored: 'username' strips any @domain, 'email' appends "+accountEmailDomain+" to usernames and 'raw' keeps it as given")
+	accountAssignCmd.Flags().StringVar(&ops.onConflict, "on-conflict", onConflictFail, "What to do when the account is already owned by someone else: 'skip' leaves it as is, 'fail' returns an error and 'overwrite' replaces the owner")
+	accountAssignCmd.Flags().StringVar(&ops.emailPrefix, "email-prefix", defaultEmailPrefix, "Prefix of the name and email of created accounts, followed by a random suffix")
+	accountAssignCmd.Flags().StringArrayVar(&ops.reservedEmailPatterns, "reserved-email-pattern", defaultReservedEmailPatterns, "Regular expression matching emails created accounts must not get, can be repeated. Replaces the default patterns")
+	accountAssignCmd.Flags().StringVar(&ops.roleName, "role-name", defaultAccessRoleName, "Role in the assigned account that the printed assume-role command assumes")
+	accountAssignCmd.Flags().BoolVar(&ops.forceRecreate, "force-recreate", false, "Always create a new account, even when untagged accounts are available")
+	accountAssignCmd.Flags().BoolVar(&ops.verify, "verify", false, "After moving the account, wait until AWS reports it under the developers OU")
+	accountAssignCmd.Flags().BoolVar(&ops.noMove, "no-move", false, "Tag the account in place without moving it to the developers OU, for organizations that don't use OUs")
+	accountAssignCmd.Flags().BoolVar(&ops.dryRun, "dry-run", false, "Print how the tags of the account that would be assigned would change, without assigning it")
+	accountAssignCmd.Flags().BoolVar(&ops.json, "json", false, "Deprecated, use '-o json' instead")
+	accountAssignCmd.Flags().StringVar(&ops.jsonFromFile, "json-from-file", "", "Re-apply an assignment previously printed with '-o json' from the given file")
+	accountAssignCmd.Flags().StringVar(&ops.ownerFile, "owner-file", "", "Assign accounts to each owner listed in the given file, one 'owner' or 'owner=count' per line")
+	accountAssignCmd.Flags().BoolVar(&ops.govCloud, "govcloud", false, "Create a new GovCloud account paired with a commercial account, and assign both")
+	accountAssignCmd.Flags().StringVar(&ops.govCloudProfile, "govcloud-profile", "", "(optional) AWS profile used to tag the GovCloud account, defaults to the payer account profile")
+	accountAssignCmd.Flags().DurationVar(&ops.timeout, "timeout", 0, "(optional) Give up on the whole assignment after this long, e.g. 10m")
+	accountAssignCmd.Flags().BoolVar(&ops.verbose, "verbose", false, "Print how long discovering, creating, tagging and moving the accounts took")
+	addOUFlags(accountAssignCmd, &ops.ous)
+	accountAssignCmd.Flags().StringVar(&ops.metricsGateway, "metrics-push-gateway", "", "(optional) URL of a Prometheus push gateway to push the number of assigned accounts to, in a group of its own per invocation")
+	accountAssignCmd.Flags().StringVar(&ops.trackConfigMap, "track-configmap", "", "(optional) namespace/name of a ConfigMap to record the owner, ID and time of each assigned account in, on the current cluster")
 
 	return accountAssignCmd
 }
 
 func (o *accountAssignOptions) complete(cmd *cobra.Command, _ []string) error {
-	if o.username == "" {
-		return cmdutil.UsageErrorf(cmd, "LDAP username was not provided")
-	}
 	if o.payerAccount == "" {
-		return cmdutil.UsageErrorf(cmd, "Payer account was not provided")
+		return outputflag.UsageErrorf(cmd, "Payer account was not provided")
+	}
+	// The account is left where it was found, so a destination OU would be silently ignored
+	if o.noMove && o.ous.DestinationOU != "" {
+		return outputflag.UsageErrorf(cmd, "--no-move cannot be used with --destination-ou")
+	}
+	o.metrics = newAccountMetrics(o.metricsGateway)
+	if o.trackConfigMap != "" {
+		tracker, err := newAssignmentTracker(o.trackConfigMap, k8s.NewClient(o.flags))
+		if err != nil {
+			return outputflag.UsageErrorf(cmd, "--track-configmap: %v", err)
+		}
+		o.tracker = tracker
+	}
+	if o.timeout < 0 {
+		return outputflag.UsageErrorf(cmd, "--timeout cannot be negative")
+	}
+	switch o.onConflict {
+	case onConflictSkip, onConflictFail, onConflictOverwrite:
+	default:
+		return outputflag.UsageErrorf(cmd, "--on-conflict must be one of %s, %s or %s", onConflictSkip, onConflictFail, onConflictOverwrite)
+	}
+	switch o.ownerFormat {
+	case ownerFormatUsername, ownerFormatEmail, ownerFormatRaw:
+	default:
+		return outputflag.UsageErrorf(cmd, "--owner-format must be one of %s, %s or %s", ownerFormatUsername, ownerFormatEmail, ownerFormatRaw)
+	}
+	if o.jsonFromFile != "" {
+		// The username, account and OU all come from the file
+		if o.username != "" || o.accountID != "" || o.count != 1 || o.ttl != 0 || o.recursive || o.govCloud || o.ownerFile != "" || o.dryRun {
+			return outputflag.UsageErrorf(cmd, "--json-from-file cannot be used with --username, --account-id, --count, --ttl, --recursive, --govcloud, --owner-file or --dry-run")
+		}
+		return o.completeOutput(cmd)
+	}
+	if o.ownerFile != "" {
+		// The owners and how many accounts each gets come from the file
+		if o.username != "" || o.accountID != "" || o.count != 1 {
+			return outputflag.UsageErrorf(cmd, "--owner-file cannot be used with --username, --account-id or --count")
+		}
+	} else {
+		o.username = o.resolveUsername()
+		if o.username == "" {
+			return outputflag.UsageErrorf(cmd, "LDAP username was not provided and could not be derived from OCM or $USER")
+		}
 	}
 
-	o.output = o.GlobalOptions.Output
+	extraTags, err := parseTags(o.tags)
+	if err != nil {
+		return outputflag.UsageErrorf(cmd, err.Error())
+	}
+	o.extraTags = extraTags
+
+	if o.count < 1 {
+		return outputflag.UsageErrorf(cmd, "--count must be at least 1")
+	}
+	if o.noCreate && o.forceRecreate {
+		return outputflag.UsageErrorf(cmd, "Cannot provide both --no-create and --force-recreate")
+	}
+	if o.waitForPool < 0 {
+		return outputflag.UsageErrorf(cmd, "--wait-for-pool cannot be negative")
+	}
+	if o.waitForPool != 0 && (o.accountID != "" || o.forceRecreate || o.govCloud) {
+		return outputflag.UsageErrorf(cmd, "--wait-for-pool cannot be used with --account-id, --force-recreate or --govcloud")
+	}
+	if o.minPool < 0 {
+		return outputflag.UsageErrorf(cmd, "--min-pool cannot be negative")
+	}
+	if o.strict && o.minPool == 0 {
+		return outputflag.UsageErrorf(cmd, "--strict requires --min-pool")
+	}
+	if o.tagConcurrency < 1 {
+		return outputflag.UsageErrorf(cmd, "--tag-concurrency must be at least 1")
+	}
+	if o.accountID != "" && (o.noCreate || o.forceRecreate) {
+		return outputflag.UsageErrorf(cmd, "--no-create and --force-recreate cannot be used with a specific account ID")
+	}
+	if o.govCloud && (o.accountID != "" || o.noCreate) {
+		return outputflag.UsageErrorf(cmd, "--govcloud always creates new accounts, it cannot be used with --account-id or --no-create")
+	}
+	if o.govCloudProfile != "" && !o.govCloud {
+		return outputflag.UsageErrorf(cmd, "--govcloud-profile can only be used with --govcloud")
+	}
+	if o.ttl < 0 {
+		return outputflag.UsageErrorf(cmd, "--ttl cannot be negative")
+	}
+	if err := validateEmailPrefix(o.emailPrefix); err != nil {
+		return outputflag.UsageErrorf(cmd, err.Error())
+	}
+	reservedEmails, err := compileReservedEmailPatterns(o.reservedEmailPatterns)
+	if err != nil {
+		return outputflag.UsageErrorf(cmd, err.Error())
+	}
+	o.reservedEmails = reservedEmails
+	if !roleNameRegex.MatchString(o.roleName) {
+		return outputflag.UsageErrorf(cmd, "--role-name '%s' is not a valid IAM role name", o.roleName)
+	}
+	if o.dryRun && (o.count > 1 || o.ownerFile != "" || o.govCloud) {
+		return outputflag.UsageErrorf(cmd, "--dry-run can only preview assigning a single account, it cannot be used with --count, --owner-file or --govcloud")
+	}
+	if o.count > 1 && o.accountID != "" {
+		return outputflag.UsageErrorf(cmd, "--count cannot be used with a specific account ID")
+	}
+
+	if len(o.excludeOUs) != 0 && !o.recursive {
+		return outputflag.UsageErrorf(cmd, "--exclude-ou can only be used with --recursive")
+	}
+	for _, ou := range o.excludeOUs {
+		if err := validateParentID(ou); err != nil {
+			return outputflag.UsageErrorf(cmd, err.Error())
+		}
+	}
+
+	return o.completeOutput(cmd)
+}
 
+// completeOutput sets the output format from -o, or to json with the deprecated --json
+func (o *accountAssignOptions) completeOutput(cmd *cobra.Command) error {
+	if o.json {
+		o.warnf("Flag --json is deprecated and will be removed, use '-o json' instead\n")
+		if o.GlobalOptions.Output != "" && o.GlobalOptions.Output != "json" {
+			return outputflag.UsageErrorf(cmd, "--json cannot be used with -o %s", o.GlobalOptions.Output)
+		}
+		// Errors are printed as JSON too
+		o.GlobalOptions.Output = "json"
+	}
+	o.output = o.GlobalOptions.Output
 	return nil
 }
 
 func (o *accountAssignOptions) run() error {
 
 	var (
-		accountAssignID string
-		destinationOU   string
-		rootID          string
+		destinationOU string
+		rootID        string
 	)
 
-	if o.payerAccount == "osd-staging-1" {
-		rootID = OSDStaging1RootID
-		destinationOU = OSDStaging1OuID
-	} else if o.payerAccount == "osd-staging-2" {
-		rootID = OSDStaging2RootID
-		destinationOU = OSDStaging2OuID
-	} else {
-		return fmt.Errorf("invalid payer account provided")
+	ous, err := resolveOUs(o.payerAccount, o.ous)
+	if err != nil {
+		return err
 	}
+	// Untagged accounts are picked from, and moved out of, the pool
+	rootID = ous.PoolOU
+	destinationOU = ous.DestinationOU
 
 	//Instantiate aws client
-	awsClient, err := awsprovider.NewAwsClient(o.payerAccount, "us-east-1", "")
+	awsClient, err := newPayerAccountClient(o.payerAccount)
 	if err != nil {
 		return err
 	}
 
 	o.awsClient = awsClient
-	// We support passing in an aws account ID to be assigned, or retrieving one for the user.
-	if o.accountID != "" {
-		accountAssignID = o.accountID
-		// ensure that the account we're assigning is not already owned
-		isOwned, err := isOwned(accountAssignID, &o.awsClient)
+
+	if o.govCloud {
+		govCloudProfile := o.govCloudProfile
+		if govCloudProfile == "" {
+			govCloudProfile = o.payerAccount
+		}
+		o.govCloudClient, err = awsprovider.NewAwsClient(govCloudProfile, govCloudRegion, "")
 		if err != nil {
 			return err
 		}
-		if isOwned {
-			return fmt.Errorf("the account you are attempting to assign is already owned, please use the 'unassign' command to unassign the account, or use 'assign' without a specific aws account id to be assigned one at random")
+	}
+
+	if o.jsonFromFile != "" {
+		assignment, err := readAssignmentFile(o.jsonFromFile)
+		if err != nil {
+			return err
+		}
+		resp, err := o.applyAssignment(assignment)
+		if err != nil {
+			return err
+		}
+		return outputflag.PrintResponse(o.output, outputflag.NewEnvelope("AccountAssignment", resp))
+	}
+
+	// Accounts in the destination OU have already been handed out
+	if o.recursive {
+		o.excludeOUs = append(o.excludeOUs, destinationOU)
+	}
+
+	if o.dryRun {
+		return o.previewAssignment(rootID)
+	}
+
+	if o.ownerFile != "" {
+		claims, err := readOwnerFile(o.ownerFile)
+		if err != nil {
+			return err
+		}
+		resp, assignErr := o.assignOwners(rootID, destinationOU, claims)
+		err = outputflag.PrintResponse(o.output, outputflag.NewEnvelope("AccountAssignmentRoster", resp))
+		if err != nil {
+			fmt.Println("Error while calling PrintResponse(): ", err.Error())
+		}
+		return assignErr
+	}
+
+	if o.count > 1 {
+		accountAssignIDs, assignErr := o.assignAccounts(rootID, destinationOU, o.count)
+		if len(accountAssignIDs) != 0 {
+			resp := assignMultipleResponse{
+				Username:    o.username,
+				Ids:         accountAssignIDs,
+				GovCloudIds: o.govCloudAccountIDs,
+				ExpiresAt:   o.expiresAt(),
+			}
+
+			err = outputflag.PrintResponse(o.output, outputflag.NewEnvelope("AccountAssignmentList", resp))
+			if err != nil {
+				fmt.Println("Error while calling PrintResponse(): ", err.Error())
+			}
 		}
+		return assignErr
+	}
+
+	accountAssignID, err := o.assignAccount(rootID, destinationOU)
+	if err != nil {
+		return err
+	}
 
-		isSuspended, err := isSuspended(accountAssignID, o.awsClient)
+	// Without moving, the account stays wherever it was found
+	ou := destinationOU
+	if o.noMove {
+		ou, err = o.getParentID(accountAssignID)
 		if err != nil {
 			return err
 		}
-		if isSuspended {
-			return fmt.Errorf("the account you are attempting to assign is suspended, please use another account, or use 'assign' without a specific aws account id to be assigned one at random")
+	}
+
+	resp := assignResponse{
+		Username:   o.username,
+		Id:         accountAssignID,
+		GovCloudId: o.govCloudAccountIDs[accountAssignID],
+		OU:         ou,
+		Tags:       o.assignmentTags(),
+		ExpiresAt:  o.expiresAt(),
+
+		AssumeRoleCommand: o.assumeRoleCommand(accountAssignID),
+	}
+
+	err = outputflag.PrintResponse(o.output, outputflag.NewEnvelope("AccountAssignment", resp))
+	if err != nil {
+		fmt.Println("Error while calling PrintResponse(): ", err.Error())
+	}
+
+	return nil
+}
+
+// assignAccounts assigns count accounts to the user, returning the IDs of the accounts assigned.
+// If an assignment fails, the accounts assigned before it are returned along with the error.
+func (o *accountAssignOptions) assignAccounts(rootID string, destinationOU string, count int) ([]string, error) {
+	accountAssignIDs := []string{}
+	for i := 0; i < count; i++ {
+		err := o.interrupted()
+		var accountAssignID string
+		if err == nil {
+			accountAssignID, err = o.assignAccount(rootID, destinationOU)
+		}
+		if err != nil {
+			if len(accountAssignIDs) == 0 {
+				return accountAssignIDs, err
+			}
+			return accountAssignIDs, fmt.Errorf("assigned %d of %d accounts (%s) before failing: %w", len(accountAssignIDs), count, strings.Join(accountAssignIDs, ", "), err)
+		}
+		accountAssignIDs = append(accountAssignIDs, accountAssignID)
+	}
+	return accountAssignIDs, nil
+}
+
+// ownerClaim is a line of an --owner-file roster, the owner and the number of accounts to assign to them
+type ownerClaim struct {
+	owner string
+	count int
+}
+
+var ErrInvalidOwnerFile = fmt.Errorf("the owner file must list one 'owner' or 'owner=count' per line")
+
+// readOwnerFile reads a roster of owners, one per line, each optionally followed by '=count'. Empty lines and lines
+// starting with '#' are ignored.
+func readOwnerFile(path string) ([]ownerClaim, error) {
+	raw, err := os.ReadFile(path) //#nosec G304 -- the path is provided by the user running the command
+	if err != nil {
+		return nil, err
+	}
+
+	claims := []ownerClaim{}
+	seen := map[string]bool{}
+	for i, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		claim := ownerClaim{owner: line, count: 1}
+		if kv := strings.SplitN(line, "=", 2); len(kv) == 2 {
+			claim.owner = strings.TrimSpace(kv[0])
+			claim.count, err = strconv.Atoi(strings.TrimSpace(kv[1]))
+			if err != nil || claim.count < 1 {
+				return nil, fmt.Errorf("%w: line %d: count must be a number of at least 1", ErrInvalidOwnerFile, i+1)
+			}
+		}
+		if claim.owner == "" || strings.ContainsAny(claim.owner, " \t") {
+			return nil, fmt.Errorf("%w: line %d: invalid owner '%s'", ErrInvalidOwnerFile, i+1, claim.owner)
+		}
+		if seen[claim.owner] {
+			return nil, fmt.Errorf("%w: line %d: owner '%s' is listed more than once", ErrInvalidOwnerFile, i+1, claim.owner)
 		}
+		seen[claim.owner] = true
+		claims = append(claims, claim)
+	}
+	if len(claims) == 0 {
+		return nil, fmt.Errorf("%w: no owners were listed", ErrInvalidOwnerFile)
+	}
+	return claims, nil
+}
+
+// assignOwners assigns accounts to each owner of a roster in turn. A failure only affects the owner it happened
+// for, the remaining owners are still assigned accounts and the failures are returned together.
+func (o *accountAssignOptions) assignOwners(rootID string, destinationOU string, claims []ownerClaim) (assignRosterResponse, error) {
+	defer func(username string) { o.username = username }(o.username)
+
+	resp := assignRosterResponse{Assignments: []ownerAssignment{}}
+	failures := []string{}
+	for _, claim := range claims {
+		o.username = claim.owner
+		ids, err := o.assignAccounts(rootID, destinationOU, claim.count)
+		assignment := ownerAssignment{Owner: claim.owner, Ids: ids}
+		if err != nil {
+			assignment.Error = err.Error()
+			failures = append(failures, fmt.Sprintf("%s: %v", claim.owner, err))
+		}
+		resp.Assignments = append(resp.Assignments, assignment)
+	}
+	if len(failures) > 0 {
+		return resp, fmt.Errorf("failed to assign accounts to %d of %d owners:\n%s", len(failures), len(claims), strings.Join(failures, "\n"))
+	}
+	return resp, nil
+}
 
+// resolveUsername returns the username given with --username. Otherwise it defaults to the username of the
+// current OCM account, falling back to $USER if OCM can't be reached.
+func (o *accountAssignOptions) resolveUsername() string {
+	if o.username != "" {
+		return o.username
+	}
+	if o.ocmUsername != nil {
+		username, err := o.ocmUsername()
+		if err == nil && username != "" {
+			return username
+		}
+	}
+	return os.Getenv("USER")
+}
+
+// currentOCMUsername returns the username of the OCM account osdctl is logged in as
+func currentOCMUsername() (string, error) {
+	connection, err := utils.NewOCMConnection()
+	if err != nil {
+		return "", err
+	}
+	defer connection.Close()
+	return utils.GetCurrentAccountUsername(connection)
+}
+
+var ErrInvalidAssignmentFile = fmt.Errorf("the file is not an assignment printed by 'account mgmt assign -o json'")
+
+// assignmentFile is the structured output of the assign command, as read back by --json-from-file
+type assignmentFile struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Data       assignResponse `json:"data"`
+}
+
+// readAssignmentFile reads and validates an assignment previously printed with '-o json'
+func readAssignmentFile(path string) (assignResponse, error) {
+	raw, err := os.ReadFile(path) //#nosec G304 -- the path is provided by the user running the command
+	if err != nil {
+		return assignResponse{}, err
+	}
+
+	var f assignmentFile
+	err = json.Unmarshal(raw, &f)
+	if err != nil {
+		return assignResponse{}, fmt.Errorf("%w: %v", ErrInvalidAssignmentFile, err)
+	}
+	if f.APIVersion != outputflag.OutputAPIVersion || f.Kind != "AccountAssignment" {
+		return assignResponse{}, fmt.Errorf("%w: unsupported apiVersion '%s' or kind '%s'", ErrInvalidAssignmentFile, f.APIVersion, f.Kind)
+	}
+	if f.Data.Username == "" || f.Data.Id == "" {
+		return assignResponse{}, fmt.Errorf("%w: username and id are required", ErrInvalidAssignmentFile)
+	}
+	if err := validateParentID(f.Data.OU); err != nil {
+		return assignResponse{}, fmt.Errorf("%w: %v", ErrInvalidAssignmentFile, err)
+	}
+
+	return f.Data, nil
+}
+
+// applyAssignment re-applies a saved assignment. The account must still exist and must not be owned by someone
+// else. Tags are set again and the account is only moved if it is not already in the assignment's OU, so
+// applying the same assignment twice has no further effect.
+func (o *accountAssignOptions) applyAssignment(assignment assignResponse) (assignResponse, error) {
+	suspended, err := isSuspended(assignment.Id, o.awsClient)
+	if err != nil {
+		return assignResponse{}, err
+	}
+	if suspended {
+		return assignResponse{}, ErrAccountSuspended
+	}
+
+	tags, err := o.accountTags(assignment.Id)
+	if err != nil {
+		return assignResponse{}, err
+	}
+	if owner, ok := tags["owner"]; ok && owner != assignment.Username {
+		return assignResponse{}, ErrAccountAlreadyOwned
+	}
+
+	if assignment.Tags == nil {
+		assignment.Tags = map[string]string{}
+	}
+	assignment.Tags["owner"] = assignment.Username
+	assignment.Tags["claimed"] = "true"
+	err = o.applyTags(assignment.Id, assignment.Tags)
+	if err != nil {
+		return assignResponse{}, err
+	}
+
+	if o.noMove {
+		return assignment, nil
+	}
+
+	moved, err := o.moveAccount(assignment.Id, assignment.OU)
+	if err != nil {
+		return assignResponse{}, err
+	}
+	if moved && o.verify {
+		err = o.verifyMove(assignment.Id, assignment.OU)
+		if err != nil {
+			return assignResponse{}, err
+		}
+	}
+
+	return assignment, nil
+}
+
+var ErrNoUntaggedAccounts = fmt.Errorf("no untagged accounts available")
+var ErrAccountAlreadyOwned = fmt.Errorf("the account you are attempting to assign is already owned, please use the 'unassign' command to unassign the account, or use 'assign' without a specific aws account id to be assigned one at random")
+var ErrAccountSuspended = fmt.Errorf("the account you are attempting to assign is suspended, please use another account, or use 'assign' without a specific aws account id to be assigned one at random")
+
+// assignAccount tags and moves an account for the user, returning the assigned account ID.
+// If an account ID was provided it is verified to be unowned and active, otherwise an untagged
+// account is picked from the root OU, falling back to creating a new account.
+func (o *accountAssignOptions) assignAccount(rootID string, destinationOU string) (string, error) {
+	var (
+		accountAssignID string
+		err             error
+		// created is set when the account was created rather than picked from the pool
+		created bool
+	)
+
+	o.phase = "discovery"
+	start := time.Now()
+	// We support passing in an aws account ID to be assigned, or retrieving one for the user.
+	if o.accountID != "" {
+		accountAssignID = o.accountID
+		err = o.checkAssignable(accountAssignID)
+		if err != nil {
+			return "", err
+		}
+	} else if o.forceRecreate || o.govCloud {
+		// Skip the pool entirely, a fresh account is always created. Pooled accounts have no GovCloud pair.
+		err = ErrNoUntaggedAccounts
+	} else if o.waitForPool != 0 {
+		accountAssignID, err = o.waitForUntaggedAccount(rootID)
 	} else {
 		accountAssignID, err = o.findUntaggedAccount(rootID)
 	}
+	o.timings.record("discovery", start)
+
+	if err == nil && o.accountID == "" && o.minPool > 0 {
+		err = o.checkMinPool(rootID)
+		if err != nil {
+			return "", err
+		}
+	}
 
 	if err != nil {
 		// If the error returned is not because of a lack of accounts, return the error
 		if err != ErrNoUntaggedAccounts {
-			return err
+			return "", err
+		}
+		// If creating accounts is disabled, the empty pool is an error
+		if o.noCreate {
+			return "", err
 		}
 		// otherwise, create a new account
-		seed := time.Now().UnixNano()
+		o.phase = "create"
+		start = time.Now()
+		seed := start.UnixNano()
 		accountAssignID, err = o.buildAccount(seed)
+		o.timings.record("create", start)
+
+		if err != nil {
+			return "", err
+		}
+		created = true
+	}
+
+	o.phase = "tag"
+	start = time.Now()
+	err = o.retryCreatedAccount(created, func() error { return o.tagAccount(accountAssignID) })
+	if err != nil {
+		return "", err
+	}
+
+	if govCloudID, ok := o.govCloudAccountIDs[accountAssignID]; ok {
+		err = o.retryCreatedAccount(created, func() error { return o.tagGovCloudAccount(accountAssignID, govCloudID) })
+		if err != nil {
+			return "", err
+		}
+	}
+	o.timings.record("tag", start)
 
+	if o.noMove {
+		o.recordAssigned(accountAssignID)
+		return accountAssignID, nil
+	}
+
+	o.phase = "move"
+	start = time.Now()
+	defer o.timings.record("move", start)
+	moved, err := o.moveAccount(accountAssignID, destinationOU)
+	if err != nil {
+		return "", err
+	}
+
+	if moved && o.verify {
+		err = o.verifyMove(accountAssignID, destinationOU)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	o.recordAssigned(accountAssignID)
+	return accountAssignID, nil
+}
+
+// recordAssigned counts the assigned account and records it in the tracking ConfigMap. Failing to record it only
+// prints a warning, as the account has already been assigned by then.
+func (o *accountAssignOptions) recordAssigned(accountID string) {
+	o.metrics.accountAssigned()
+
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+	// The claim time is only set when the account was tagged, skipping an account owned by someone else doesn't tag it
+	assignedAt := o.claimedAt
+	if assignedAt.IsZero() {
+		assignedAt = time.Now().UTC()
+	}
+	err := o.tracker.accountAssigned(ctx, assignmentEntry{
+		Owner:      o.ownerTag(),
+		AccountID:  accountID,
+		AssignedAt: assignedAt,
+	})
+	if err != nil {
+		o.warnf("Failed to record account %s in ConfigMap %s: %v\n", accountID, o.trackConfigMap, err)
+	}
+}
+
+// checkAssignable returns an error if the given account is already owned or is suspended
+func (o *accountAssignOptions) checkAssignable(accountID string) error {
+	// ensure that the account we're assigning is not already owned, unless --on-conflict handles it when tagging
+	isOwned, err := o.isOwned(accountID)
+	if err != nil {
+		return err
+	}
+	if isOwned && o.conflictPolicy() == onConflictFail {
+		return ErrAccountAlreadyOwned
+	}
+
+	isSuspended, err := isSuspended(accountID, o.awsClient)
+	if err != nil {
+		return err
+	}
+	if isSuspended {
+		return ErrAccountSuspended
+	}
+	return nil
+}
+
+// previewAssignment prints how the tags of the account that would be assigned would change, without assigning it.
+// When no account would be picked from the pool, the tags are those a newly created account would get.
+func (o *accountAssignOptions) previewAssignment(rootID string) error {
+	var (
+		accountAssignID string
+		err             error
+	)
+	if o.accountID != "" {
+		accountAssignID = o.accountID
+		err = o.checkAssignable(accountAssignID)
+	} else if !o.forceRecreate {
+		accountAssignID, err = o.findUntaggedAccount(rootID)
+		if err == ErrNoUntaggedAccounts && !o.noCreate {
+			err = nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	o.claimedAt = time.Now().UTC()
+	if accountAssignID == "" {
+		fmt.Println("Dry run: a new account would be created with the tags:")
+	} else {
+		fmt.Printf("Dry run: account %s would be assigned to %s with the tag changes:\n", accountAssignID, o.username)
+	}
+	// The tags of the accounts checked for ownership were listed with them
+	fmt.Print(diffTags(o.listedTags[accountAssignID], o.accountAssignmentTags(accountAssignID), nil))
+	return nil
+}
+
+// The organizations API is eventually consistent, so a moved account may briefly still show under its old parent
+var (
+	moveVerifyAttempts = 5
+	moveVerifyInterval = 2 * time.Second
+)
+
+var ErrPoolBelowMinimum = fmt.Errorf("assigning the account would leave fewer untagged accounts in the pool than --min-pool")
+
+// checkMinPool counts the untagged accounts left in the pool once the picked account is assigned, and warns, or with
+// --strict fails, when fewer than --min-pool would remain. The picked account is not tagged yet so it is still counted.
+func (o *accountAssignOptions) checkMinPool(rootID string) error {
+	status, err := (&accountPoolStatusOptions{awsClient: o.awsClient}).poolStatus([]string{rootID})
+	if err != nil {
+		return err
+	}
+	remaining := status.UnclaimedActive - 1
+	if remaining < 0 {
+		remaining = 0
+	}
+	if remaining >= o.minPool {
+		return nil
+	}
+	if o.strict {
+		return fmt.Errorf("%w: %d would remain, %d required", ErrPoolBelowMinimum, remaining, o.minPool)
+	}
+	o.warnf("Warning: only %d untagged accounts will remain in the pool, below the minimum of %d\n", remaining, o.minPool)
+	return nil
+}
+
+// warnf prints a warning to ErrOut, or to stderr when no streams are set
+func (o *accountAssignOptions) warnf(format string, a ...interface{}) {
+	errOut := o.ErrOut
+	if errOut == nil {
+		errOut = os.Stderr
+	}
+	fmt.Fprintf(errOut, format, a...)
+}
+
+var ErrMoveNotVerified = fmt.Errorf("the account was moved but AWS does not report it under the destination yet")
+
+// verifyMove polls the account's parent until it is the destination, giving up after a bounded number of attempts
+func (o *accountAssignOptions) verifyMove(accountID string, destinationOU string) error {
+	for attempt := 0; attempt < moveVerifyAttempts; attempt++ {
+		if attempt != 0 {
+			if err := o.sleep(moveVerifyInterval); err != nil {
+				return err
+			}
+		}
+		parentID, err := o.getParentID(accountID)
 		if err != nil {
 			return err
 		}
+		if parentID == destinationOU {
+			return nil
+		}
+	}
+	return ErrMoveNotVerified
+}
+
+// The pool is scanned again at this interval while waiting for an account to be released
+var poolPollInterval = 30 * time.Second
+
+var ErrPoolWaitTimedOut = fmt.Errorf("timed out waiting for an untagged account to be released to the pool")
+
+// waitForUntaggedAccount scans the pool until an untagged account is found, giving up once --wait-for-pool has
+// elapsed. Accounts are never created while waiting.
+func (o *accountAssignOptions) waitForUntaggedAccount(rootOu string) (string, error) {
+	deadline := time.Now().Add(o.waitForPool)
+	for {
+		accountAssignID, err := o.findUntaggedAccount(rootOu)
+		if err != ErrNoUntaggedAccounts {
+			return accountAssignID, err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return "", ErrPoolWaitTimedOut
+		}
+		wait := poolPollInterval
+		if wait > remaining {
+			wait = remaining
+		}
+		o.infof("No untagged accounts available, checking the pool again in %s\n", wait)
+		if err := o.sleep(wait); err != nil {
+			return "", err
+		}
 	}
+}
 
-	err = o.tagAccount(accountAssignID)
-	if err != nil {
-		return err
+func (o *accountAssignOptions) findUntaggedAccount(rootOu string) (string, error) {
+	o.skipped = skippedAccounts{}
+	accountAssignID, err := o.findUntaggedAccountInParent(rootOu)
+	if err != ErrNoUntaggedAccounts || !o.recursive {
+		return accountAssignID, err
 	}
 
-	err = o.moveAccount(accountAssignID, destinationOU, rootID)
+	return o.findUntaggedAccountInChildOUs(rootOu)
+}
+
+// findUntaggedAccountInChildOUs walks the OUs below the given parent depth-first in OU ID order, skipping excluded
+// OUs entirely, and returns the first untagged account found
+func (o *accountAssignOptions) findUntaggedAccountInChildOUs(parentID string) (string, error) {
+	ous, err := o.awsClient.ListOrganizationalUnitsForParent(&organizations.ListOrganizationalUnitsForParentInput{
+		ParentId: &parentID,
+	})
 	if err != nil {
-		return err
+		return "", awsprovider.WithRequestID(err)
 	}
+	sort.Slice(ous.OrganizationalUnits, func(i, j int) bool {
+		return *ous.OrganizationalUnits[i].Id < *ous.OrganizationalUnits[j].Id
+	})
 
-	resp := assignResponse{
-		Username: o.username,
-		Id:       accountAssignID,
-	}
+	for _, ou := range ous.OrganizationalUnits {
+		if o.isExcludedOU(*ou.Id) {
+			continue
+		}
 
-	err = outputflag.PrintResponse(o.output, resp)
-	if err != nil {
-		fmt.Println("Error while calling PrintResponse(): ", err.Error())
+		accountAssignID, err := o.findUntaggedAccountInParent(*ou.Id)
+		if err != ErrNoUntaggedAccounts {
+			return accountAssignID, err
+		}
+
+		accountAssignID, err = o.findUntaggedAccountInChildOUs(*ou.Id)
+		if err != ErrNoUntaggedAccounts {
+			return accountAssignID, err
+		}
 	}
 
-	return nil
+	return "", ErrNoUntaggedAccounts
 }
 
-var ErrNoUntaggedAccounts = fmt.Errorf("no untagged accounts available")
+func (o *accountAssignOptions) isExcludedOU(ouID string) bool {
+	for _, excluded := range o.excludeOUs {
+		if excluded == ouID {
+			return true
+		}
+	}
+	return false
+}
 
-func (o *accountAssignOptions) findUntaggedAccount(rootOu string) (string, error) {
+// findUntaggedAccountInParent returns the untagged, active account with the lowest account ID directly under the
+// given parent
+func (o *accountAssignOptions) findUntaggedAccountInParent(parentID string) (string, error) {
 
 	var accountAssignID string
 
-	//List accounts that are not in any OU
+	//List accounts that are directly under the parent
 	input := &organizations.ListAccountsForParentInput{
-		ParentId: &rootOu,
+		ParentId: &parentID,
 	}
-	accounts, err := o.awsClient.ListAccountsForParent(input)
-	if err != nil {
-		return "", err
+	candidates := []*organizations.Account{}
+	for {
+		if err := o.interrupted(); err != nil {
+			return "", err
+		}
+		accounts, err := o.awsClient.ListAccountsForParent(input)
+		if err != nil {
+			return "", awsprovider.WithOrganizationsHint(awsprovider.WithRequestID(err))
+		}
+		candidates = append(candidates, accounts.Accounts...)
+		if accounts.NextToken == nil {
+			break
+		}
+		input.NextToken = accounts.NextToken
 	}
 
-	if len(accounts.Accounts) == 0 {
+	// A successful but empty listing means the pool is exhausted, not that AWS failed
+	if len(candidates) == 0 {
 		return "", ErrNoUntaggedAccounts
 	}
 
+	// AWS lists accounts in no particular order, checking them by ascending account ID means the same pool always
+	// hands out the same account first
+	sort.Slice(candidates, func(i, j int) bool {
+		return *candidates[i].Id < *candidates[j].Id
+	})
+
 	// Loop through accounts and check that it's untagged and assign ID to user
-	for _, a := range accounts.Accounts {
-		isOwned, err := isOwned(*a.Id, &o.awsClient)
+	var owned map[string]bool
+	for i, a := range candidates {
+		if err := o.interrupted(); err != nil {
+			return "", err
+		}
+		var isOwned bool
+		var err error
+		if o.tagConcurrency > 1 {
+			// The tags of the next accounts are read together, which still hands out the lowest untagged account ID
+			if i%o.tagConcurrency == 0 {
+				end := i + o.tagConcurrency
+				if end > len(candidates) {
+					end = len(candidates)
+				}
+				owned, err = o.readOwnership(candidates[i:end])
+			}
+			isOwned = owned[*a.Id]
+		} else {
+			isOwned, err = o.isOwned(*a.Id)
+		}
 		if err != nil {
 			return "", err
 		}
 
-		if !isOwned {
+		if isOwned {
+			o.skipped.record(*a.Id, ownedReason(o.listedTags[*a.Id]))
+		} else {
 			isSuspended, err := isSuspended(*a.Id, o.awsClient)
+			if err != nil {
+				// An account closed since it was listed can't be assigned, but the rest of the pool can
+				if isAccountNotFound(err) {
+					o.skipped.record(*a.Id, "closed since it was listed")
+					continue
+				}
+				return "", err
+			}
+			if isSuspended {
+				o.skipped.record(*a.Id, "suspended")
+				continue
+			}
+			// Someone else assigning concurrently may have claimed the account since its tags were read, so they're
+			// read again right before it's handed back to be tagged
+			isOwned, err = o.isOwned(*a.Id)
 			if err != nil {
 				return "", err
 			}
-			if !isSuspended {
-				accountAssignID = *a.Id
-				break
+			if isOwned {
+				o.infof("Account %s was claimed by someone else, trying the next account\n", *a.Id)
+				o.skipped.record(*a.Id, "claimed by someone else during the scan")
+				continue
 			}
+			accountAssignID = *a.Id
+			break
 		}
 	}
 
@@ -220,22 +1196,130 @@ func (o *accountAssignOptions) findUntaggedAccount(rootOu string) (string, error
 	return accountAssignID, nil
 }
 
-func isOwned(accountID string, awsClient *awsprovider.Client) (bool, error) {
-	inputListTags := &organizations.ListTagsForResourceInput{
-		ResourceId: aws.String(accountID),
+// getParentID returns the ID of the OU or root the account currently belongs to
+func (o *accountAssignOptions) getParentID(accountID string) (string, error) {
+	parents, err := o.awsClient.ListParents(&organizations.ListParentsInput{
+		ChildId: aws.String(accountID),
+	})
+	if err != nil {
+		return "", awsprovider.WithRequestID(err)
 	}
-	tags, err := (*awsClient).ListTagsForResource(inputListTags)
+	if len(parents.Parents) == 0 {
+		return "", fmt.Errorf("no parent found for account %s", accountID)
+	}
+	return *parents.Parents[0].Id, nil
+}
+
+// isOwned checks whether the account is owned, remembering its tags so its claim history can be appended to once
+// assigned
+func (o *accountAssignOptions) isOwned(accountID string) (bool, error) {
+	tags, err := o.accountTags(accountID)
 	if err != nil {
 		return false, err
 	}
+	return tagsOwned(tags), nil
+}
+
+// readOwnership reads the tags of the given accounts concurrently, up to tagConcurrency at the same time, and returns
+// whether each of them is owned. The tags are remembered in listedTags, which is only written under a lock until every
+// read is done.
+func (o *accountAssignOptions) readOwnership(accounts []*organizations.Account) (map[string]bool, error) {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		owned = make(map[string]bool, len(accounts))
+		errs  = make([]error, len(accounts))
+		slots = make(chan struct{}, o.tagConcurrency)
+	)
+	for i, a := range accounts {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, accountID string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			tags, err := listAccountTags(accountID, o.awsClient)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if o.listedTags == nil {
+				o.listedTags = map[string]map[string]string{}
+			}
+			o.listedTags[accountID] = tags
+			owned[accountID] = tagsOwned(tags)
+		}(i, *a.Id)
+	}
+	wg.Wait()
 
-	for _, t := range tags.Tags {
-		if *t.Key == "owner" || *t.Key == "claimed" {
-			return true, nil
+	// The first failure in account ID order is returned, so the error doesn't depend on how the reads were scheduled
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
 		}
 	}
+	return owned, nil
+}
 
-	return false, nil
+// accountTags lists the account's tags and remembers them in listedTags
+func (o *accountAssignOptions) accountTags(accountID string) (map[string]string, error) {
+	tags, err := listAccountTags(accountID, o.awsClient)
+	if err != nil {
+		return nil, err
+	}
+	if o.listedTags == nil {
+		o.listedTags = map[string]map[string]string{}
+	}
+	o.listedTags[accountID] = tags
+	return tags, nil
+}
+
+func isOwned(accountID string, awsClient *awsprovider.Client) (bool, error) {
+	tags, err := listAccountTags(accountID, *awsClient)
+	if err != nil {
+		return false, err
+	}
+
+	return tagsOwned(tags), nil
+}
+
+// tagsOwned returns true if the given account tags mark it as owned
+func tagsOwned(tags map[string]string) bool {
+	_, hasOwner := tags["owner"]
+	_, hasClaimed := tags["claimed"]
+
+	return hasOwner || hasClaimed
+}
+
+// listAccountTags returns the tags set on the given account as a key/value map, following
+// NextToken so tags on later pages are not missed
+func listAccountTags(accountID string, awsClient awsprovider.Client) (map[string]string, error) {
+	m := map[string]string{}
+
+	inputListTags := &organizations.ListTagsForResourceInput{
+		ResourceId: aws.String(accountID),
+	}
+	for {
+		tags, err := awsClient.ListTagsForResource(inputListTags)
+		if err != nil {
+			return nil, awsprovider.WithRequestID(err)
+		}
+
+		for _, t := range tags.Tags {
+			m[*t.Key] = *t.Value
+		}
+
+		if tags.NextToken == nil || *tags.NextToken == "" {
+			break
+		}
+		inputListTags = &organizations.ListTagsForResourceInput{
+			ResourceId: aws.String(accountID),
+			NextToken:  tags.NextToken,
+		}
+	}
+
+	return m, nil
 }
 
 func isSuspended(accountIdInput string, awsClient awsprovider.Client) (bool, error) {
@@ -246,7 +1330,7 @@ func isSuspended(accountIdInput string, awsClient awsprovider.Client) (bool, err
 	)
 
 	if err != nil {
-		return false, err
+		return false, awsprovider.WithRequestID(err)
 	}
 
 	if *accountInfo.Account.Status == organizations.AccountStatusSuspended {
@@ -256,32 +1340,237 @@ func isSuspended(accountIdInput string, awsClient awsprovider.Client) (bool, err
 	return false, nil
 }
 
+// isAccountNotFound returns true if AWS reported that the account does not exist, as happens once it is closed
+func isAccountNotFound(err error) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == organizations.ErrCodeAccountNotFoundException
+}
+
+// A newly created account can briefly be unknown to the organizations API, so operations on it are retried while AWS
+// reports it as not found
+var (
+	createdAccountAttempts = 5
+	createdAccountInterval = 2 * time.Second
+)
+
+// retryCreatedAccount runs the operation, retrying it a bounded number of times while AWS does not find the account
+// if it was just created. Operations on other accounts are run once.
+func (o *accountAssignOptions) retryCreatedAccount(created bool, operation func() error) error {
+	err := operation()
+	for attempt := 1; created && attempt < createdAccountAttempts && isAccountNotFound(err); attempt++ {
+		if err := o.sleep(createdAccountInterval); err != nil {
+			return err
+		}
+		err = operation()
+	}
+	return err
+}
+
+// tagAccount tags the account as owned by the user. The time of the assignment is recorded in the
+// claimed-at tag, and when a ttl is set, the time the assignment expires is recorded in the expires-at tag.
 func (o *accountAssignOptions) tagAccount(accountId string) error {
+	current, ok := o.listedTags[accountId]
+	if !ok {
+		var err error
+		current, err = o.accountTags(accountId)
+		if err != nil {
+			return err
+		}
+	}
+	if tagsOwned(current) && current["owner"] != o.ownerTag() {
+		switch o.conflictPolicy() {
+		case onConflictSkip:
+			o.warnf("Account %s is %s, leaving its tags unchanged\n", accountId, ownedReason(current))
+			return nil
+		case onConflictFail:
+			return fmt.Errorf("%w: %s is %s", ErrAccountAlreadyOwned, accountId, ownedReason(current))
+		}
+	}
+
+	if o.claimedAt.IsZero() {
+		o.claimedAt = time.Now().UTC()
+	}
+	return o.applyTags(accountId, o.accountAssignmentTags(accountId))
+}
+
+// ownerTag returns the value of the owner tag of the user's accounts, the username normalized by --owner-format
+func (o *accountAssignOptions) ownerTag() string {
+	return normalizeOwner(o.username, o.ownerFormat)
+}
+
+// normalizeOwner converts the owner to the given --owner-format. Owners are kept as is by the raw format, and when
+// no format is set.
+func normalizeOwner(owner string, format string) string {
+	switch format {
+	case ownerFormatUsername:
+		if i := strings.Index(owner, "@"); i >= 0 {
+			return owner[:i]
+		}
+	case ownerFormatEmail:
+		if !strings.Contains(owner, "@") {
+			return owner + accountEmailDomain
+		}
+	}
+	return owner
+}
+
+// conflictPolicy returns the --on-conflict policy, failing when none was set
+func (o *accountAssignOptions) conflictPolicy() string {
+	if o.onConflict == "" {
+		return onConflictFail
+	}
+	return o.onConflict
+}
+
+// accountAssignmentTags returns the tags set on the given account when assigning it to the user, which also record
+// the assignment in the account's claim history
+func (o *accountAssignOptions) accountAssignmentTags(accountId string) map[string]string {
+	tags := o.assignmentTags()
+	tags[claimHistoryTagKey] = appendClaimHistory(o.listedTags[accountId][claimHistoryTagKey], claimHistoryEntry{
+		Action: claimHistoryAssign,
+		Owner:  o.ownerTag(),
+		Time:   o.claimedAt,
+	})
+	return tags
+}
+
+// tagGovCloudAccount tags the GovCloud account paired with the given commercial account like the commercial
+// account, and links the two accounts to each other with tags
+func (o *accountAssignOptions) tagGovCloudAccount(accountId string, govCloudAccountId string) error {
+	err := o.applyTags(accountId, map[string]string{"govcloud-account-id": govCloudAccountId})
+	if err != nil {
+		return err
+	}
+	tags := o.assignmentTags()
+	tags["commercial-account-id"] = accountId
+	return tagResource(o.govCloudClient, govCloudAccountId, tags)
+}
+
+// assignmentTags returns the tags set on an account assigned to the user
+func (o *accountAssignOptions) assignmentTags() map[string]string {
+	tags := map[string]string{
+		"owner":      o.ownerTag(),
+		"claimed":    "true",
+		"claimed-at": o.claimedAt.Format(time.RFC3339),
+	}
+	if expiresAt := o.expiresAt(); expiresAt != "" {
+		tags["expires-at"] = expiresAt
+	}
+	for k, v := range o.extraTags {
+		tags[k] = v
+	}
+	return tags
+}
+
+// expiresAt returns the RFC 3339 time the assignment expires when a ttl is set, and an empty string otherwise
+func (o *accountAssignOptions) expiresAt() string {
+	if o.ttl <= 0 {
+		return ""
+	}
+	return o.claimedAt.Add(o.ttl).Format(time.RFC3339)
+}
+
+// reservedTagKeys are set by the assign command itself and can't be given with --tag
+var reservedTagKeys = []string{"owner", "claimed", "claimed-at", "expires-at", "reassigned-at", claimHistoryTagKey, "govcloud-account-id", "commercial-account-id"}
+
+// parseTags parses key=value tags, enforcing the AWS limits on tag key and value lengths
+func parseTags(tags []string) (map[string]string, error) {
+	m, err := parseKeyValues(tags)
+	if err != nil {
+		return nil, err
+	}
+	for _, reserved := range reservedTagKeys {
+		if _, ok := m[reserved]; ok {
+			return nil, fmt.Errorf("tag key '%s' is set by the assign command and can't be given with --tag", reserved)
+		}
+	}
+	return m, nil
+}
+
+// parseKeyValues parses key=value pairs, enforcing the AWS limits on tag key and value lengths
+func parseKeyValues(pairs []string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("tag '%s' must be in the form key=value", pair)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if key == "" || len(key) > 128 {
+			return nil, fmt.Errorf("tag key in '%s' must be between 1 and 128 characters", pair)
+		}
+		if len(value) > 256 {
+			return nil, fmt.Errorf("tag value in '%s' must be at most 256 characters", pair)
+		}
+		if _, ok := m[key]; ok {
+			return nil, fmt.Errorf("tag key '%s' was given more than once", key)
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+// applyTags merges the given tags into the account's existing tags. Only the tags which are missing or have a
+// different value are set, in key order, so re-running an assignment leaves the tags already set, including those
+// set by other processes, untouched.
+func (o *accountAssignOptions) applyTags(accountId string, tags map[string]string) error {
+	current, ok := o.listedTags[accountId]
+	if !ok {
+		var err error
+		current, err = o.accountTags(accountId)
+		if err != nil {
+			return err
+		}
+	}
+
+	diff := diffTags(current, tags, nil)
+	delta := map[string]string{}
+	for _, c := range append(diff.Added, diff.Changed...) {
+		delta[c.Key] = c.NewValue
+	}
+	if len(delta) == 0 {
+		return nil
+	}
+	err := tagResource(o.awsClient, accountId, delta)
+	if err != nil {
+		return err
+	}
+	for k, v := range delta {
+		current[k] = v
+	}
+	return nil
+}
+
+// tagResource sets the given tags on the account using the given client, sorted by key
+func tagResource(awsClient awsprovider.Client, accountId string, tags map[string]string) error {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	awsTags := []*organizations.Tag{}
+	for _, k := range keys {
+		awsTags = append(awsTags, &organizations.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(tags[k]),
+		})
+	}
 
 	inputTag := &organizations.TagResourceInput{
 		ResourceId: aws.String(accountId),
-		Tags: []*organizations.Tag{
-			{
-				Key:   aws.String("owner"),
-				Value: aws.String(o.username),
-			},
-			{
-				Key:   aws.String("claimed"),
-				Value: aws.String("true"),
-			},
-		},
+		Tags:       awsTags,
 	}
-	_, err := o.awsClient.TagResource(inputTag)
+	_, err := awsClient.TagResource(inputTag)
 	if err != nil {
-		return err
+		return awsprovider.WithRequestID(err)
 	}
 	return nil
 }
 
 func (o *accountAssignOptions) buildAccount(seedVal int64) (string, error) {
 
-	fmt.Println("Creating account")
-	var newAccountId string
+	o.infof("Creating account\n")
 
 	orgOutput, orgErr := o.createAccount(seedVal)
 	if orgErr != nil {
@@ -291,49 +1580,219 @@ func (o *accountAssignOptions) buildAccount(seedVal int64) (string, error) {
 			seedVal = time.Now().UnixNano()
 			orgOutput, orgErr = o.createAccount(seedVal)
 			if orgErr == nil {
-				newAccountId = *orgOutput.CreateAccountStatus.AccountId
-				return newAccountId, nil
+				return o.createdAccountID(orgOutput)
 			}
 		}
 		return "", orgErr
 	}
 
-	newAccountId = *orgOutput.CreateAccountStatus.AccountId
+	return o.createdAccountID(orgOutput)
+}
+
+var ErrNoCreatedAccountID = fmt.Errorf("the account was created but AWS did not return its ID")
+
+// createdAccountID returns the ID of the account created according to the given status, so it can be
+// tagged and moved without having to look it up again
+func (o *accountAssignOptions) createdAccountID(status *organizations.DescribeCreateAccountStatusOutput) (string, error) {
+	if status.CreateAccountStatus == nil || status.CreateAccountStatus.AccountId == nil || *status.CreateAccountStatus.AccountId == "" {
+		return "", ErrNoCreatedAccountID
+	}
+
+	newAccountId := *status.CreateAccountStatus.AccountId
+	if o.listedTags == nil {
+		o.listedTags = map[string]map[string]string{}
+	}
+	o.listedTags[newAccountId] = map[string]string{}
+	if status.CreateAccountStatus.GovCloudAccountId != nil && *status.CreateAccountStatus.GovCloudAccountId != "" {
+		govCloudAccountId := *status.CreateAccountStatus.GovCloudAccountId
+		o.infof("Created account %s with GovCloud account %s\n", newAccountId, govCloudAccountId)
+		if o.govCloudAccountIDs == nil {
+			o.govCloudAccountIDs = map[string]string{}
+		}
+		o.govCloudAccountIDs[newAccountId] = govCloudAccountId
+	} else {
+		o.infof("Created account %s\n", newAccountId)
+	}
 	return newAccountId, nil
 }
 
+// ErrInterrupted is returned when the command is interrupted before the accounts were fully assigned
+var ErrInterrupted = fmt.Errorf("interrupted, some operations may be incomplete")
+
+// ErrTimeout is returned when the accounts could not be assigned within --timeout
+var ErrTimeout = fmt.Errorf("timed out, some operations may be incomplete")
+
+// interrupted returns ErrInterrupted once the command has been interrupted, or ErrTimeout naming the phase in
+// progress once --timeout has been exceeded
+func (o *accountAssignOptions) interrupted() error {
+	if o.ctx == nil || o.ctx.Err() == nil {
+		return nil
+	}
+	if o.ctx.Err() == context.DeadlineExceeded {
+		if o.phase == "" {
+			return fmt.Errorf("%w: exceeded --timeout of %s", ErrTimeout, o.timeout)
+		}
+		return fmt.Errorf("%w: exceeded --timeout of %s during the %s phase", ErrTimeout, o.timeout, o.phase)
+	}
+	return ErrInterrupted
+}
+
+// sleep waits for the given duration, returning early with the error from interrupted as soon as the command is
+// interrupted or times out
+func (o *accountAssignOptions) sleep(d time.Duration) error {
+	var interrupted <-chan struct{}
+	if o.ctx != nil {
+		interrupted = o.ctx.Done()
+	}
+	select {
+	case <-interrupted:
+		return o.interrupted()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// infof prints informational output, unless --quiet is set
+func (o *accountAssignOptions) infof(format string, a ...interface{}) {
+	if o.GlobalOptions != nil && o.GlobalOptions.Quiet {
+		return
+	}
+	fmt.Printf(format, a...)
+}
+
 var ErrAwsAccountLimitExceeded error = fmt.Errorf("ErrAwsAccountLimitExceeded")
 var ErrEmailAlreadyExist error = fmt.Errorf("ErrEmailAlreadyExist")
 var ErrAwsInternalFailure error = fmt.Errorf("ErrAwsInternalFailure")
 var ErrAwsTooManyRequests error = fmt.Errorf("ErrAwsTooManyRequests")
 var ErrAwsFailedCreateAccount error = fmt.Errorf("ErrAwsFailedCreateAccount")
 
-func (o *accountAssignOptions) createAccount(seedVal int64) (*organizations.DescribeCreateAccountStatusOutput, error) {
+// assumeRoleCommand returns the AWS CLI command assuming the role in the given account. It runs with the payer
+// account's profile, as the role trusts the organization's management account.
+func (o *accountAssignOptions) assumeRoleCommand(accountID string) string {
+	profile, err := payerAccountProfile(o.payerAccount)
+	if err != nil {
+		profile = o.payerAccount
+	}
+	roleName := o.roleName
+	if roleName == "" {
+		roleName = defaultAccessRoleName
+	}
+	return fmt.Sprintf("aws sts assume-role --profile %s --role-arn arn:aws:iam::%s:role/%s --role-session-name %s", profile, accountID, roleName, o.username)
+}
 
-	rand.Seed(seedVal)
-	randStr := RandomString(6)
-	accountName := "osd-creds-mgmt+" + randStr
-	email := accountName + "@redhat.com"
+// accountEmailPrefix returns the prefix of the name and email of created accounts
+func (o *accountAssignOptions) accountEmailPrefix() string {
+	if o.emailPrefix == "" {
+		return defaultEmailPrefix
+	}
+	return o.emailPrefix
+}
+
+// validateEmailPrefix checks that the prefix makes legal names and emails for created accounts
+func validateEmailPrefix(prefix string) error {
+	if !emailPrefixRegex.MatchString(prefix) {
+		return fmt.Errorf("--email-prefix '%s' must only contain letters, digits and '.', '_', '+' or '-'", prefix)
+	}
+	if len(prefix)+accountNameSuffixLength > maxAccountNameLength {
+		return fmt.Errorf("--email-prefix '%s' must be at most %d characters, so the account names it generates fit in %d", prefix, maxAccountNameLength-accountNameSuffixLength, maxAccountNameLength)
+	}
+	return nil
+}
 
-	createInput := &organizations.CreateAccountInput{
-		AccountName: aws.String(accountName),
-		Email:       aws.String(email),
+// compileReservedEmailPatterns compiles the --reserved-email-pattern regular expressions
+func compileReservedEmailPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --reserved-email-pattern '%s': %v", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// generateAccountName returns the name of an account to create, with a random suffix. The suffix is regenerated while
+// the account's email matches a reserved email pattern, so AWS doesn't reject the account after it was requested.
+func (o *accountAssignOptions) generateAccountName() (string, error) {
+	reserved := o.reservedEmails
+	if reserved == nil {
+		var err error
+		reserved, err = compileReservedEmailPatterns(defaultReservedEmailPatterns)
+		if err != nil {
+			return "", err
+		}
 	}
 
-	createOutput, err := o.awsClient.CreateAccount(createInput)
+	for attempt := 0; attempt < maxEmailAttempts; attempt++ {
+		accountName := o.accountEmailPrefix() + RandomString(accountNameSuffixLength)
+		if !matchesAny(reserved, accountName+accountEmailDomain) {
+			return accountName, nil
+		}
+	}
+	return "", ErrNoUnreservedEmail
+}
+
+// matchesAny returns true if any of the regular expressions match s
+func matchesAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// The status of an account being created is checked again at this interval until it is no longer in progress
+var createAccountPollInterval = 5 * time.Second
+
+func (o *accountAssignOptions) createAccount(seedVal int64) (*organizations.DescribeCreateAccountStatusOutput, error) {
+
+	rand.Seed(seedVal)
+	accountName, err := o.generateAccountName()
 	if err != nil {
 		return &organizations.DescribeCreateAccountStatusOutput{}, err
 	}
+	email := accountName + accountEmailDomain
+
+	var createStatus *organizations.CreateAccountStatus
+	if o.govCloud {
+		createOutput, err := o.awsClient.CreateGovCloudAccount(&organizations.CreateGovCloudAccountInput{
+			AccountName: aws.String(accountName),
+			Email:       aws.String(email),
+		})
+		if err != nil {
+			return &organizations.DescribeCreateAccountStatusOutput{}, awsprovider.WithRequestID(err)
+		}
+		createStatus = createOutput.CreateAccountStatus
+	} else {
+		createOutput, err := o.awsClient.CreateAccount(&organizations.CreateAccountInput{
+			AccountName: aws.String(accountName),
+			Email:       aws.String(email),
+		})
+		if err != nil {
+			return &organizations.DescribeCreateAccountStatusOutput{}, awsprovider.WithRequestID(err)
+		}
+		createStatus = createOutput.CreateAccountStatus
+	}
 
 	describeStatusInput := &organizations.DescribeCreateAccountStatusInput{
-		CreateAccountRequestId: createOutput.CreateAccountStatus.Id,
+		CreateAccountRequestId: createStatus.Id,
 	}
 
 	var accountStatus *organizations.DescribeCreateAccountStatusOutput
-	for {
+	for attempt := 0; ; attempt++ {
+		if attempt != 0 {
+			if err := o.sleep(createAccountPollInterval); err != nil {
+				return &organizations.DescribeCreateAccountStatusOutput{}, err
+			}
+		}
+		if err := o.interrupted(); err != nil {
+			return &organizations.DescribeCreateAccountStatusOutput{}, err
+		}
 		status, err := o.awsClient.DescribeCreateAccountStatus(describeStatusInput)
 		if err != nil {
-			return &organizations.DescribeCreateAccountStatusOutput{}, err
+			return &organizations.DescribeCreateAccountStatusOutput{}, awsprovider.WithRequestID(err)
 		}
 
 		accountStatus = status
@@ -373,17 +1832,47 @@ func RandomString(n int) string {
 	return string(s)
 }
 
-func (o *accountAssignOptions) moveAccount(accountIdInput string, destOuInput string, rootIdInput string) error {
+var (
+	ouIDRE   = regexp.MustCompile(`^ou-[0-9a-z]{4,32}-[0-9a-z]{8,32}$`)
+	rootIDRE = regexp.MustCompile(`^r-[0-9a-z]{4,32}$`)
+)
+
+// validateParentID returns an error if the given ID is neither a valid OU ID nor a valid root ID
+func validateParentID(parentID string) error {
+	if ouIDRE.MatchString(parentID) || rootIDRE.MatchString(parentID) {
+		return nil
+	}
+	return fmt.Errorf("'%s' is not a valid OU ('ou-...') or root ('r-...') ID", parentID)
+}
+
+// moveAccount moves the account from its current parent, which may be the root or any OU below it, to the destination
+// OU. It returns false without moving the account if it is already in the destination OU.
+func (o *accountAssignOptions) moveAccount(accountIdInput string, destOuInput string) (bool, error) {
+
+	// Catch typos before AWS returns an opaque error for them
+	if err := validateParentID(destOuInput); err != nil {
+		return false, err
+	}
+
+	// AWS fails moving an account to the parent it's already in with a confusing error, there is nothing to do then
+	parentID, err := o.getParentID(accountIdInput)
+	if err != nil {
+		return false, err
+	}
+	if parentID == destOuInput {
+		o.infof("Account %s is already in the destination OU %s, not moving it\n", accountIdInput, destOuInput)
+		return false, nil
+	}
 
 	inputMove := &organizations.MoveAccountInput{
 		AccountId:           aws.String(accountIdInput),
 		DestinationParentId: aws.String(destOuInput),
-		SourceParentId:      aws.String(rootIdInput),
+		SourceParentId:      aws.String(parentID),
 	}
 
-	_, err := o.awsClient.MoveAccount(inputMove)
+	_, err = o.awsClient.MoveAccount(inputMove)
 	if err != nil {
-		return err
+		return false, awsprovider.WithRequestID(err)
 	}
-	return nil
+	return true, nil
 }
@@ -1,15 +1,28 @@
 package mgmt
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/golang/mock/gomock"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"github.com/spf13/cobra"
 
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -87,6 +100,50 @@ func TestIsOwned(t *testing.T) {
 	}
 }
 
+func TestIsOwnedPaginatedTags(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+	accountID := "11111"
+
+	gomock.InOrder(
+		mockAWSClient.EXPECT().ListTagsForResource(
+			&organizations.ListTagsForResourceInput{
+				ResourceId: aws.String(accountID),
+			},
+		).Return(&organizations.ListTagsForResourceOutput{
+			Tags: []*organizations.Tag{
+				{
+					Key:   aws.String("team"),
+					Value: aws.String("srep"),
+				},
+			},
+			NextToken: aws.String("page-2"),
+		}, nil),
+		mockAWSClient.EXPECT().ListTagsForResource(
+			&organizations.ListTagsForResourceInput{
+				ResourceId: aws.String(accountID),
+				NextToken:  aws.String("page-2"),
+			},
+		).Return(&organizations.ListTagsForResourceOutput{
+			Tags: []*organizations.Tag{
+				{
+					Key:   aws.String("claimed"),
+					Value: aws.String("true"),
+				},
+			},
+		}, nil),
+	)
+
+	var awsC awsprovider.Client = mockAWSClient
+	isOwned, err := isOwned(accountID, &awsC)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !isOwned {
+		t.Errorf("expected account with claimed tag on the second page to be owned")
+	}
+}
+
 func TestFindUntaggedAccount(t *testing.T) {
 	var genericAWSError error = fmt.Errorf("Generic AWS error")
 
@@ -110,6 +167,16 @@ func TestFindUntaggedAccount(t *testing.T) {
 			expectErr:         nil,
 			expectedAWSError:  nil,
 		},
+		{
+			name:              "test for the lowest account ID picked from several untagged accounts",
+			accountsList:      []string{"333333333333", "111111111111", "222222222222"},
+			expectedAccountId: "111111111111",
+			tags:              map[string]string{},
+			suspendCheck:      true,
+			accountStatus:     organizations.AccountStatusActive,
+			expectErr:         nil,
+			expectedAWSError:  nil,
+		},
 		{
 			name:              "test for only partially tagged accounts present",
 			accountsList:      []string{"111111111111"},
@@ -142,6 +209,15 @@ func TestFindUntaggedAccount(t *testing.T) {
 			expectErr:         genericAWSError,
 			expectedAWSError:  genericAWSError,
 		},
+		{
+			name:              "test for empty account list without AWS error",
+			accountsList:      []string{},
+			expectedAccountId: "",
+			tags:              nil,
+			suspendCheck:      false,
+			expectErr:         ErrNoUntaggedAccounts,
+			expectedAWSError:  nil,
+		},
 		{
 			name:              "test for suspended account error",
 			accountsList:      []string{"111111111111"},
@@ -166,6 +242,14 @@ func TestFindUntaggedAccount(t *testing.T) {
 
 			awsOutputAccounts := &organizations.ListAccountsForParentOutput{}
 
+			// Candidates are checked in account ID order, so only the lowest is checked when it's free
+			firstCandidate := ""
+			for _, a := range test.accountsList {
+				if firstCandidate == "" || a < firstCandidate {
+					firstCandidate = a
+				}
+			}
+
 			if test.accountsList != nil {
 				accountsList := []*organizations.Account{}
 				for _, a := range test.accountsList {
@@ -189,24 +273,29 @@ func TestFindUntaggedAccount(t *testing.T) {
 				}
 				awsOutputTags.Tags = tags
 
+				// A free account's tags are read again right before it's handed back
+				tagReads := 1
+				if test.suspendCheck && test.accountStatus != organizations.AccountStatusSuspended {
+					tagReads = 2
+				}
 				mockAWSClient.EXPECT().ListTagsForResource(
 					&organizations.ListTagsForResourceInput{
-						ResourceId: &test.accountsList[0],
+						ResourceId: aws.String(firstCandidate),
 					}).Return(
 					awsOutputTags,
 					test.expectedAWSError,
-				)
+				).Times(tagReads)
 			}
 
 			if test.suspendCheck {
 				mockAWSClient.EXPECT().DescribeAccount(
 					&organizations.DescribeAccountInput{
-						AccountId: &test.accountsList[0],
+						AccountId: aws.String(firstCandidate),
 					},
 				).Return(
 					&organizations.DescribeAccountOutput{
 						Account: &organizations.Account{
-							Id:     aws.String(test.accountsList[0]),
+							Id:     aws.String(firstCandidate),
 							Status: aws.String(test.accountStatus),
 						},
 					}, nil,
@@ -229,91 +318,2138 @@ func TestFindUntaggedAccount(t *testing.T) {
 	}
 }
 
-func TestCreateAccount(t *testing.T) {
+func TestFindUntaggedAccountRecursive(t *testing.T) {
 	mocks := setupDefaultMocks(t, []runtime.Object{})
-
 	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
 
-	seed := int64(1)
-	rand.Seed(seed)
-	randStr := RandomString(6)
-	accountName := "osd-creds-mgmt+" + randStr
-	email := accountName + "@redhat.com"
+	rootOu := "r-abcd"
+	excludedOu := "ou-abcd-reserved"
+	freeOu := "ou-abcd-freepool"
 
-	createId := "car-random1234"
+	// The root only contains an account that is already owned
+	mockAWSClient.EXPECT().ListAccountsForParent(&organizations.ListAccountsForParentInput{
+		ParentId: aws.String(rootOu),
+	}).Return(&organizations.ListAccountsForParentOutput{
+		Accounts: []*organizations.Account{{Id: aws.String("111111111111")}},
+	}, nil)
+	mockAWSClient.EXPECT().ListTagsForResource(&organizations.ListTagsForResourceInput{
+		ResourceId: aws.String("111111111111"),
+	}).Return(&organizations.ListTagsForResourceOutput{
+		Tags: []*organizations.Tag{{Key: aws.String("owner"), Value: aws.String("randuser")}},
+	}, nil)
 
-	mockAWSClient.EXPECT().CreateAccount(&organizations.CreateAccountInput{
-		AccountName: &accountName,
-		Email:       &email,
-	}).Return(&organizations.CreateAccountOutput{
-		CreateAccountStatus: &organizations.CreateAccountStatus{Id: &createId},
+	mockAWSClient.EXPECT().ListOrganizationalUnitsForParent(&organizations.ListOrganizationalUnitsForParentInput{
+		ParentId: aws.String(rootOu),
+	}).Return(&organizations.ListOrganizationalUnitsForParentOutput{
+		OrganizationalUnits: []*organizations.OrganizationalUnit{
+			{Id: aws.String(excludedOu)},
+			{Id: aws.String(freeOu)},
+		},
 	}, nil)
 
-	expectedOutput := "SUCCEEDED"
+	// The excluded OU must never be listed, so only the free OU's accounts are expected to be read
+	mockAWSClient.EXPECT().ListAccountsForParent(&organizations.ListAccountsForParentInput{
+		ParentId: aws.String(freeOu),
+	}).Return(&organizations.ListAccountsForParentOutput{
+		Accounts: []*organizations.Account{{Id: aws.String("333333333333")}},
+	}, nil)
+	mockAWSClient.EXPECT().ListTagsForResource(&organizations.ListTagsForResourceInput{
+		ResourceId: aws.String("333333333333"),
+	}).Return(&organizations.ListTagsForResourceOutput{}, nil).Times(2)
+	mockAWSClient.EXPECT().DescribeAccount(&organizations.DescribeAccountInput{
+		AccountId: aws.String("333333333333"),
+	}).Return(&organizations.DescribeAccountOutput{
+		Account: &organizations.Account{
+			Id:     aws.String("333333333333"),
+			Status: aws.String(organizations.AccountStatusActive),
+		},
+	}, nil)
 
-	awsDescribeOutput := &organizations.DescribeCreateAccountStatusOutput{
-		CreateAccountStatus: &organizations.CreateAccountStatus{
-			State: &expectedOutput,
-		}}
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.recursive = true
+	o.excludeOUs = []string{excludedOu}
+
+	returnValue, err := o.findUntaggedAccount(rootOu)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if returnValue != "333333333333" {
+		t.Errorf("expected 333333333333, got %s", returnValue)
+	}
+}
+
+func TestFindUntaggedAccountSkipsClosedAccount(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	closedID := "111111111111"
+	activeID := "222222222222"
 
-	mockAWSClient.EXPECT().DescribeCreateAccountStatus(&organizations.DescribeCreateAccountStatusInput{
-		CreateAccountRequestId: &createId,
-	}).Return(awsDescribeOutput, nil)
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+		Accounts: []*organizations.Account{{Id: aws.String(closedID)}, {Id: aws.String(activeID)}},
+	}, nil)
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil).Times(3)
+	// The first account was closed after it was listed
+	mockAWSClient.EXPECT().DescribeAccount(&organizations.DescribeAccountInput{
+		AccountId: aws.String(closedID),
+	}).Return(nil, awserr.NewRequestFailure(
+		awserr.New(organizations.ErrCodeAccountNotFoundException, "account not found", nil), 400, "ab12cd34-0000-1111-2222-333344445555"))
+	mockAWSClient.EXPECT().DescribeAccount(&organizations.DescribeAccountInput{
+		AccountId: aws.String(activeID),
+	}).Return(&organizations.DescribeAccountOutput{
+		Account: &organizations.Account{
+			Id:     aws.String(activeID),
+			Status: aws.String(organizations.AccountStatusActive),
+		},
+	}, nil)
 
 	o := &accountAssignOptions{}
 	o.awsClient = mockAWSClient
-	returnVal, err := o.createAccount(seed)
+
+	returnValue, err := o.findUntaggedAccount("r-abcd")
 	if err != nil {
-		t.Error("failed to create account")
+		t.Errorf("unexpected error: %v", err)
 	}
-	if returnVal.CreateAccountStatus.State != &expectedOutput {
-		t.Error("failed to create account")
+	if returnValue != activeID {
+		t.Errorf("expected %s, got %s", activeID, returnValue)
 	}
 }
 
-func TestTagAccount(t *testing.T) {
+func TestFindUntaggedAccountClaimedConcurrently(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+	claimedID, freeID := "111111111111", "222222222222"
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+		Accounts: []*organizations.Account{{Id: aws.String(freeID)}, {Id: aws.String(claimedID)}},
+	}, nil)
+	for _, id := range []string{claimedID, freeID} {
+		mockAWSClient.EXPECT().DescribeAccount(&organizations.DescribeAccountInput{
+			AccountId: aws.String(id),
+		}).Return(&organizations.DescribeAccountOutput{
+			Account: &organizations.Account{
+				Id:     aws.String(id),
+				Status: aws.String(organizations.AccountStatusActive),
+			},
+		}, nil)
+	}
+	// The first account is untagged when it's selected, but someone else has tagged it by the time it's tagged
+	gomock.InOrder(
+		mockAWSClient.EXPECT().ListTagsForResource(&organizations.ListTagsForResourceInput{
+			ResourceId: aws.String(claimedID),
+		}).Return(&organizations.ListTagsForResourceOutput{}, nil),
+		mockAWSClient.EXPECT().ListTagsForResource(&organizations.ListTagsForResourceInput{
+			ResourceId: aws.String(claimedID),
+		}).Return(&organizations.ListTagsForResourceOutput{
+			Tags: []*organizations.Tag{
+				{Key: aws.String("owner"), Value: aws.String("otheruser")},
+				{Key: aws.String("claimed"), Value: aws.String("true")},
+			},
+		}, nil),
+	)
+	mockAWSClient.EXPECT().ListTagsForResource(&organizations.ListTagsForResourceInput{
+		ResourceId: aws.String(freeID),
+	}).Return(&organizations.ListTagsForResourceOutput{}, nil).Times(2)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+
+	accountID, err := o.findUntaggedAccount("r-abcd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if accountID != freeID {
+		t.Errorf("expected the next free account %s, got %s", freeID, accountID)
+	}
+}
 
+func TestFindUntaggedAccountConcurrentTagReads(t *testing.T) {
 	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	// Every account is owned except one, so the tags of all the accounts up to the end of its batch are read
+	const numAccounts, concurrency, freeIndex = 50, 8, 37
+	accounts := []*organizations.Account{}
+	for i := 0; i < numAccounts; i++ {
+		accounts = append(accounts, &organizations.Account{Id: aws.String(fmt.Sprintf("1000000000%02d", i))})
+	}
+	freeID := *accounts[freeIndex].Id
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+		Accounts: accounts,
+	}, nil)
+	for i := 0; i < 40; i++ {
+		id := *accounts[i].Id
+		if id == freeID {
+			// The free account's tags are read again right before it's handed back
+			mockAWSClient.EXPECT().ListTagsForResource(&organizations.ListTagsForResourceInput{
+				ResourceId: aws.String(id),
+			}).Return(&organizations.ListTagsForResourceOutput{}, nil).Times(2)
+			continue
+		}
+		mockAWSClient.EXPECT().ListTagsForResource(&organizations.ListTagsForResourceInput{
+			ResourceId: aws.String(id),
+		}).Return(&organizations.ListTagsForResourceOutput{
+			Tags: []*organizations.Tag{
+				{Key: aws.String("owner"), Value: aws.String("randuser")},
+				{Key: aws.String("claimed"), Value: aws.String("true")},
+			},
+		}, nil)
+	}
+	mockAWSClient.EXPECT().DescribeAccount(&organizations.DescribeAccountInput{
+		AccountId: aws.String(freeID),
+	}).Return(&organizations.DescribeAccountOutput{
+		Account: &organizations.Account{
+			Id:     aws.String(freeID),
+			Status: aws.String(organizations.AccountStatusActive),
+		},
+	}, nil)
 
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.tagConcurrency = concurrency
+
+	accountID, err := o.findUntaggedAccount("r-abcd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if accountID != freeID {
+		t.Errorf("expected the only free account %s, got %s", freeID, accountID)
+	}
+	if len(o.listedTags) != 40 {
+		t.Errorf("expected the tags of 40 accounts to be remembered, got %d", len(o.listedTags))
+	}
+	if len(o.skipped.accounts) != freeIndex {
+		t.Errorf("expected %d owned accounts to be skipped, got %d", freeIndex, len(o.skipped.accounts))
+	}
+	for i, skipped := range o.skipped.accounts {
+		if skipped.ID != *accounts[i].Id {
+			t.Errorf("expected skipped accounts in account ID order, got %s at %d", skipped.ID, i)
+		}
+	}
+}
+
+func TestWaitForUntaggedAccount(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
 	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
-	accountID := "111111111111"
 
-	awsOutputTag := &organizations.TagResourceOutput{}
+	defer func(interval time.Duration) { poolPollInterval = interval }(poolPollInterval)
+	poolPollInterval = time.Millisecond
 
-	mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(
-		awsOutputTag,
-		nil,
+	freedID := "111111111111"
+
+	// The pool is empty on the first scan, then another engineer releases their account
+	gomock.InOrder(
+		mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{},
+		}, nil),
+		mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{{Id: aws.String(freedID)}},
+		}, nil),
 	)
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil).Times(2)
+	mockAWSClient.EXPECT().DescribeAccount(gomock.Any()).Return(&organizations.DescribeAccountOutput{
+		Account: &organizations.Account{
+			Id:     aws.String(freedID),
+			Status: aws.String(organizations.AccountStatusActive),
+		},
+	}, nil)
 
 	o := &accountAssignOptions{}
 	o.awsClient = mockAWSClient
-	err := o.tagAccount(accountID)
+	o.waitForPool = time.Minute
+
+	returnValue, err := o.waitForUntaggedAccount("r-abcd")
 	if err != nil {
-		t.Errorf("failed to tag account")
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if returnValue != freedID {
+		t.Errorf("expected %s, got %s", freedID, returnValue)
 	}
 }
 
-func TestMoveAccount(t *testing.T) {
+func TestWaitForUntaggedAccountTimeout(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	defer func(interval time.Duration) { poolPollInterval = interval }(poolPollInterval)
+	poolPollInterval = time.Millisecond
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+		Accounts: []*organizations.Account{},
+	}, nil).MinTimes(1)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.waitForPool = 5 * time.Millisecond
+
+	_, err := o.waitForUntaggedAccount("r-abcd")
+	if err != ErrPoolWaitTimedOut {
+		t.Errorf("expected %v, got %v", ErrPoolWaitTimedOut, err)
+	}
+}
+
+func TestWaitForUntaggedAccountInterrupted(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	defer func(interval time.Duration) { poolPollInterval = interval }(poolPollInterval)
+	poolPollInterval = time.Minute
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+		Accounts: []*organizations.Account{},
+	}, nil).MinTimes(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.waitForPool = time.Hour
+	o.ctx = ctx
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
 
+	start := time.Now()
+	_, err := o.waitForUntaggedAccount("r-abcd")
+	if err != ErrInterrupted {
+		t.Errorf("expected %v, got %v", ErrInterrupted, err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the wait to stop when interrupted, it took %s", elapsed)
+	}
+}
+
+func TestAssignAccountTimeout(t *testing.T) {
 	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	// Listing the pool outlasts the deadline, no account is tagged, created or moved
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).DoAndReturn(
+		func(input *organizations.ListAccountsForParentInput) (*organizations.ListAccountsForParentOutput, error) {
+			time.Sleep(50 * time.Millisecond)
+			return &organizations.ListAccountsForParentOutput{
+				Accounts: []*organizations.Account{{Id: aws.String("111111111111")}},
+			}, nil
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.username = "tuser"
+	o.timeout = 10 * time.Millisecond
+	o.ctx = ctx
+
+	_, err := o.assignAccount("r-abcd", "ou-abcd-vnjfdshs")
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected %v, got %v", ErrTimeout, err)
+	}
+	if !strings.Contains(err.Error(), "discovery phase") {
+		t.Errorf("expected the discovery phase to be named in the error, got %v", err)
+	}
+}
 
+func TestFindUntaggedAccountOrganizationsNotInUse(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
 	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
 
-	accountId := "111111111111"
-	destOu := "abc-vnjfdshs"
-	rootOu := "abc"
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+		nil,
+		awserr.New(organizations.ErrCodeAWSOrganizationsNotInUseException, "Your account is not a member of an organization.", nil),
+	)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+
+	_, err := o.findUntaggedAccount("r-abcd")
+	var orgErr *awsprovider.OrganizationsNotAvailableError
+	if !errors.As(err, &orgErr) {
+		t.Fatalf("expected an OrganizationsNotAvailableError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "management account") {
+		t.Errorf("expected the error to point at the management account, got %s", err)
+	}
+}
 
-	awsOutputMove := &organizations.MoveAccountOutput{}
+func TestFindUntaggedAccountRequestID(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
 
-	mockAWSClient.EXPECT().MoveAccount(gomock.Any()).Return(
-		awsOutputMove,
+	requestID := "ab12cd34-0000-1111-2222-333344445555"
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
 		nil,
+		awserr.NewRequestFailure(awserr.New("TooManyRequestsException", "rate exceeded", nil), 400, requestID),
+	)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+
+	_, err := o.findUntaggedAccount("r-abcd")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), requestID) {
+		t.Errorf("expected error to contain request ID %s, got %s", requestID, err)
+	}
+}
+
+func TestCreateAccount(t *testing.T) {
+	defer func(interval time.Duration) { createAccountPollInterval = interval }(createAccountPollInterval)
+	createAccountPollInterval = 0
+
+	tests := []struct {
+		name string
+		// states are the states DescribeCreateAccountStatus returns, one per call
+		states        []string
+		failureReason string
+		expectedState string
+		expectedErr   error
+	}{
+		{
+			name:          "succeeded",
+			states:        []string{"SUCCEEDED"},
+			expectedState: "SUCCEEDED",
+		},
+		{
+			name:          "in progress then succeeded",
+			states:        []string{"IN_PROGRESS", "IN_PROGRESS", "SUCCEEDED"},
+			expectedState: "SUCCEEDED",
+		},
+		{
+			name:          "in progress then failed",
+			states:        []string{"IN_PROGRESS", "FAILED"},
+			failureReason: "EMAIL_ALREADY_EXISTS",
+			expectedErr:   ErrEmailAlreadyExist,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+			seed := int64(1)
+			rand.Seed(seed)
+			randStr := RandomString(6)
+			accountName := "osd-creds-mgmt+" + randStr
+			email := accountName + "@redhat.com"
+
+			createId := "car-random1234"
+
+			mockAWSClient.EXPECT().CreateAccount(&organizations.CreateAccountInput{
+				AccountName: &accountName,
+				Email:       &email,
+			}).Return(&organizations.CreateAccountOutput{
+				CreateAccountStatus: &organizations.CreateAccountStatus{Id: &createId},
+			}, nil)
+			expectCreateAccountStatuses(mockAWSClient, createId, test.failureReason, test.states...)
+
+			o := &accountAssignOptions{}
+			o.awsClient = mockAWSClient
+			returnVal, err := o.createAccount(seed)
+			if test.expectedErr != nil {
+				if !errors.Is(err, test.expectedErr) {
+					t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to create account: %v", err)
+			}
+			if *returnVal.CreateAccountStatus.State != test.expectedState {
+				t.Errorf("expected state %s, got %s", test.expectedState, *returnVal.CreateAccountStatus.State)
+			}
+		})
+	}
+}
+
+// expectCreateAccountStatuses scripts DescribeCreateAccountStatus to return the given states in order, one per call.
+// A FAILED state is returned with the failure reason.
+func expectCreateAccountStatuses(mockAWSClient *mock.MockClient, createId string, failureReason string, states ...string) {
+	calls := make([]*gomock.Call, 0, len(states))
+	for _, state := range states {
+		status := &organizations.CreateAccountStatus{State: aws.String(state)}
+		if state == "FAILED" {
+			status.FailureReason = aws.String(failureReason)
+		}
+		calls = append(calls, mockAWSClient.EXPECT().DescribeCreateAccountStatus(&organizations.DescribeCreateAccountStatusInput{
+			CreateAccountRequestId: &createId,
+		}).Return(&organizations.DescribeCreateAccountStatusOutput{CreateAccountStatus: status}, nil))
+	}
+	gomock.InOrder(calls...)
+}
+
+func TestAssignCreatedAccount(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	rootOu := "r-abcd"
+	destOu := "ou-abcd-vnjfdshs"
+	createdID := "333333333333"
+
+	// No untagged accounts are available, so a new one is created
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+		&organizations.ListAccountsForParentOutput{Accounts: []*organizations.Account{}}, nil)
+	mockAWSClient.EXPECT().CreateAccount(gomock.Any()).Return(&organizations.CreateAccountOutput{
+		CreateAccountStatus: &organizations.CreateAccountStatus{Id: aws.String("car-random1234")},
+	}, nil)
+	mockAWSClient.EXPECT().DescribeCreateAccountStatus(gomock.Any()).Return(&organizations.DescribeCreateAccountStatusOutput{
+		CreateAccountStatus: &organizations.CreateAccountStatus{
+			State:     aws.String("SUCCEEDED"),
+			AccountId: aws.String(createdID),
+		},
+	}, nil)
+	mockAWSClient.EXPECT().TagResource(gomock.Any()).DoAndReturn(
+		func(input *organizations.TagResourceInput) (*organizations.TagResourceOutput, error) {
+			if *input.ResourceId != createdID {
+				t.Errorf("expected created account %s to be tagged, got %s", createdID, *input.ResourceId)
+			}
+			return &organizations.TagResourceOutput{}, nil
+		},
+	)
+	mockAWSClient.EXPECT().ListParents(gomock.Any()).Return(&organizations.ListParentsOutput{
+		Parents: []*organizations.Parent{{Id: aws.String(rootOu)}},
+	}, nil)
+	mockAWSClient.EXPECT().MoveAccount(&organizations.MoveAccountInput{
+		AccountId:           aws.String(createdID),
+		DestinationParentId: aws.String(destOu),
+		SourceParentId:      aws.String(rootOu),
+	}).Return(&organizations.MoveAccountOutput{}, nil)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.username = "tuser"
+
+	returnValue, err := o.assignAccount(rootOu, destOu)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if returnValue != createdID {
+		t.Errorf("expected %s is %s", createdID, returnValue)
+	}
+}
+
+func TestAssignCreatedAccountNotFoundRetry(t *testing.T) {
+	defer func(interval time.Duration) { createdAccountInterval = interval }(createdAccountInterval)
+	createdAccountInterval = time.Millisecond
+
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	rootOu := "r-abcd"
+	destOu := "ou-abcd-vnjfdshs"
+	createdID := "333333333333"
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+		&organizations.ListAccountsForParentOutput{Accounts: []*organizations.Account{}}, nil)
+	mockAWSClient.EXPECT().CreateAccount(gomock.Any()).Return(&organizations.CreateAccountOutput{
+		CreateAccountStatus: &organizations.CreateAccountStatus{Id: aws.String("car-random1234")},
+	}, nil)
+	mockAWSClient.EXPECT().DescribeCreateAccountStatus(gomock.Any()).Return(&organizations.DescribeCreateAccountStatusOutput{
+		CreateAccountStatus: &organizations.CreateAccountStatus{
+			State:     aws.String("SUCCEEDED"),
+			AccountId: aws.String(createdID),
+		},
+	}, nil)
+	// The created account hasn't propagated yet when it is first tagged
+	gomock.InOrder(
+		mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(nil, awserr.NewRequestFailure(
+			awserr.New(organizations.ErrCodeAccountNotFoundException, "account not found", nil), 400, "ab12cd34-0000-1111-2222-333344445555")),
+		mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(&organizations.TagResourceOutput{}, nil),
 	)
 
 	o := &accountAssignOptions{}
 	o.awsClient = mockAWSClient
-	err := o.moveAccount(accountId, destOu, rootOu)
+	o.username = "tuser"
+	o.noMove = true
+
+	returnValue, err := o.assignAccount(rootOu, destOu)
 	if err != nil {
-		t.Errorf("failed to move account")
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if returnValue != createdID {
+		t.Errorf("expected %s is %s", createdID, returnValue)
+	}
+}
+
+func TestAssignPooledAccountNotFoundNotRetried(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	accountID := "111111111111"
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+		&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{{Id: aws.String(accountID)}},
+		}, nil)
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil).Times(2)
+	mockAWSClient.EXPECT().DescribeAccount(gomock.Any()).Return(&organizations.DescribeAccountOutput{
+		Account: &organizations.Account{Id: aws.String(accountID), Status: aws.String(organizations.AccountStatusActive)},
+	}, nil)
+	// An account picked from the pool which isn't found was closed, retrying wouldn't help
+	mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(nil,
+		awserr.New(organizations.ErrCodeAccountNotFoundException, "account not found", nil)).Times(1)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.username = "tuser"
+	o.noMove = true
+
+	_, err := o.assignAccount("r-abcd", "ou-abcd-vnjfdshs")
+	if !isAccountNotFound(err) {
+		t.Errorf("expected the account not found error, got %v", err)
+	}
+}
+
+func TestAssignGovCloudAccount(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+	mockGovCloudClient := mock.NewMockClient(mocks.mockCtrl)
+
+	rootOu := "r-abcd"
+	destOu := "ou-abcd-vnjfdshs"
+	createdID := "333333333333"
+	govCloudID := "444444444444"
+
+	// The pool is skipped, GovCloud accounts are always created
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Times(0)
+	mockAWSClient.EXPECT().CreateAccount(gomock.Any()).Times(0)
+	mockAWSClient.EXPECT().CreateGovCloudAccount(gomock.Any()).Return(&organizations.CreateGovCloudAccountOutput{
+		CreateAccountStatus: &organizations.CreateAccountStatus{Id: aws.String("car-random1234")},
+	}, nil)
+	mockAWSClient.EXPECT().DescribeCreateAccountStatus(gomock.Any()).Return(&organizations.DescribeCreateAccountStatusOutput{
+		CreateAccountStatus: &organizations.CreateAccountStatus{
+			State:             aws.String("SUCCEEDED"),
+			AccountId:         aws.String(createdID),
+			GovCloudAccountId: aws.String(govCloudID),
+		},
+	}, nil)
+
+	commercialTags := map[string]string{}
+	mockAWSClient.EXPECT().TagResource(gomock.Any()).DoAndReturn(
+		func(input *organizations.TagResourceInput) (*organizations.TagResourceOutput, error) {
+			if *input.ResourceId != createdID {
+				t.Errorf("expected commercial account %s to be tagged, got %s", createdID, *input.ResourceId)
+			}
+			for _, tag := range input.Tags {
+				commercialTags[*tag.Key] = *tag.Value
+			}
+			return &organizations.TagResourceOutput{}, nil
+		},
+	).Times(2)
+	govCloudTags := map[string]string{}
+	mockGovCloudClient.EXPECT().TagResource(gomock.Any()).DoAndReturn(
+		func(input *organizations.TagResourceInput) (*organizations.TagResourceOutput, error) {
+			if *input.ResourceId != govCloudID {
+				t.Errorf("expected GovCloud account %s to be tagged, got %s", govCloudID, *input.ResourceId)
+			}
+			for _, tag := range input.Tags {
+				govCloudTags[*tag.Key] = *tag.Value
+			}
+			return &organizations.TagResourceOutput{}, nil
+		},
+	)
+	mockAWSClient.EXPECT().ListParents(gomock.Any()).Return(&organizations.ListParentsOutput{
+		Parents: []*organizations.Parent{{Id: aws.String(rootOu)}},
+	}, nil)
+	mockAWSClient.EXPECT().MoveAccount(gomock.Any()).Return(&organizations.MoveAccountOutput{}, nil)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.govCloudClient = mockGovCloudClient
+	o.govCloud = true
+	o.username = "tuser"
+
+	returnValue, err := o.assignAccount(rootOu, destOu)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if returnValue != createdID {
+		t.Errorf("expected %s is %s", createdID, returnValue)
+	}
+	if o.govCloudAccountIDs[createdID] != govCloudID {
+		t.Errorf("expected GovCloud account %s to be paired with %s, got %v", govCloudID, createdID, o.govCloudAccountIDs)
+	}
+	if commercialTags["owner"] != "tuser" || commercialTags["govcloud-account-id"] != govCloudID {
+		t.Errorf("unexpected commercial account tags: %v", commercialTags)
+	}
+	if govCloudTags["owner"] != "tuser" || govCloudTags["commercial-account-id"] != createdID {
+		t.Errorf("unexpected GovCloud account tags: %v", govCloudTags)
+	}
+}
+
+func TestCreatedAccountIDMissing(t *testing.T) {
+	o := &accountAssignOptions{}
+	_, err := o.createdAccountID(&organizations.DescribeCreateAccountStatusOutput{
+		CreateAccountStatus: &organizations.CreateAccountStatus{State: aws.String("SUCCEEDED")},
+	})
+	if err != ErrNoCreatedAccountID {
+		t.Errorf("expected error %v and got %v", ErrNoCreatedAccountID, err)
+	}
+}
+
+func TestAssignAccountNoMove(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	accountID := "111111111111"
+	rootOu := "r-abcd"
+	destOu := "ou-abcd-vnjfdshs"
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+		&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{{Id: aws.String(accountID)}},
+		}, nil)
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil).Times(2)
+	mockAWSClient.EXPECT().DescribeAccount(gomock.Any()).Return(&organizations.DescribeAccountOutput{
+		Account: &organizations.Account{
+			Id:     aws.String(accountID),
+			Status: aws.String(organizations.AccountStatusActive),
+		},
+	}, nil)
+	mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(&organizations.TagResourceOutput{}, nil)
+	mockAWSClient.EXPECT().MoveAccount(gomock.Any()).Times(0)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.username = "tuser"
+	o.noMove = true
+
+	returnValue, err := o.assignAccount(rootOu, destOu)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if returnValue != accountID {
+		t.Errorf("expected %s is %s", accountID, returnValue)
+	}
+}
+
+func TestCompleteNoMoveWithDestinationOU(t *testing.T) {
+	o := &accountAssignOptions{GlobalOptions: &globalflags.GlobalOptions{}}
+	o.payerAccount = "osd-staging-2"
+	o.noMove = true
+	o.ous.DestinationOU = "ou-abcd-vnjfdshs"
+
+	err := o.complete(&cobra.Command{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "--no-move cannot be used with --destination-ou") {
+		t.Errorf("expected --no-move with --destination-ou to be rejected, got %v", err)
+	}
+}
+
+func TestAssignAccountMinPool(t *testing.T) {
+	testData := []struct {
+		name        string
+		strict      bool
+		expectedErr error
+	}{
+		{name: "warns when the pool drops below the minimum"},
+		{name: "fails when the pool drops below the minimum with strict", strict: true, expectedErr: ErrPoolBelowMinimum},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+			rootOu := "r-abcd"
+			destOu := "ou-abcd-vnjfdshs"
+
+			// Two untagged accounts, one is picked and only one would remain
+			mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+				&organizations.ListAccountsForParentOutput{
+					Accounts: []*organizations.Account{{Id: aws.String("111111111111")}, {Id: aws.String("222222222222")}},
+				}, nil).AnyTimes()
+			mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil).AnyTimes()
+			mockAWSClient.EXPECT().DescribeAccount(gomock.Any()).Return(&organizations.DescribeAccountOutput{
+				Account: &organizations.Account{Status: aws.String(organizations.AccountStatusActive)},
+			}, nil).AnyTimes()
+			if test.expectedErr == nil {
+				mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(&organizations.TagResourceOutput{}, nil)
+			} else {
+				mockAWSClient.EXPECT().TagResource(gomock.Any()).Times(0)
+			}
+
+			errOut := &strings.Builder{}
+			o := &accountAssignOptions{}
+			o.awsClient = mockAWSClient
+			o.username = "tuser"
+			o.noMove = true
+			o.minPool = 2
+			o.strict = test.strict
+			o.ErrOut = errOut
+
+			_, err := o.assignAccount(rootOu, destOu)
+			if test.expectedErr != nil {
+				if !errors.Is(err, test.expectedErr) {
+					t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(errOut.String(), "only 1 untagged accounts will remain") {
+				t.Errorf("expected a warning about the pool, got %q", errOut.String())
+			}
+		})
+	}
+}
+
+func TestAssignAccountJSONError(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+		&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{{Id: aws.String("111111111111")}},
+		}, nil).AnyTimes()
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil).AnyTimes()
+	mockAWSClient.EXPECT().DescribeAccount(gomock.Any()).Return(&organizations.DescribeAccountOutput{
+		Account: &organizations.Account{Status: aws.String(organizations.AccountStatusActive)},
+	}, nil).AnyTimes()
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.username = "tuser"
+	o.noMove = true
+	o.minPool = 1
+	o.strict = true
+
+	_, err := o.assignAccount("r-abcd", "ou-abcd-vnjfdshs")
+	if err == nil {
+		t.Fatal("expected the assignment to fail")
+	}
+
+	var out strings.Builder
+	err = outputflag.PrintError(&out, err)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got outputflag.ErrorResponse
+	err = json.Unmarshal([]byte(out.String()), &got)
+	if err != nil {
+		t.Fatalf("expected a JSON error object, got %q: %v", out.String(), err)
+	}
+	if got.Code != "PoolBelowMinimum" || !strings.Contains(got.Message, "0 would remain, 1 required") {
+		t.Errorf("expected a PoolBelowMinimum error, got %v", got)
+	}
+}
+
+func TestCompleteOutputJSONAlias(t *testing.T) {
+	testData := []struct {
+		name           string
+		json           bool
+		output         string
+		expectedOutput string
+		expectErr      bool
+	}{
+		{name: "--json sets json output", json: true, expectedOutput: "json"},
+		{name: "--json with -o json", json: true, output: "json", expectedOutput: "json"},
+		{name: "--json with another output", json: true, output: "yaml", expectErr: true},
+		{name: "-o is kept without --json", output: "yaml", expectedOutput: "yaml"},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			errOut := &strings.Builder{}
+			o := &accountAssignOptions{GlobalOptions: &globalflags.GlobalOptions{Output: test.output}}
+			o.json = test.json
+			o.ErrOut = errOut
+
+			err := o.completeOutput(&cobra.Command{})
+			if test.expectErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if o.output != test.expectedOutput {
+				t.Errorf("expected output %s, got %s", test.expectedOutput, o.output)
+			}
+			if o.GlobalOptions.Output != test.expectedOutput {
+				t.Errorf("expected errors to be printed as %s, got %s", test.expectedOutput, o.GlobalOptions.Output)
+			}
+			deprecated := strings.Contains(errOut.String(), "Flag --json is deprecated and will be removed, use '-o json' instead")
+			if deprecated != test.json {
+				t.Errorf("expected the deprecation note to be printed: %v, got %q", test.json, errOut.String())
+			}
+		})
+	}
+}
+
+func TestResolveUsername(t *testing.T) {
+	testData := []struct {
+		name             string
+		username         string
+		ocmUsername      string
+		ocmErr           error
+		user             string
+		expectedUsername string
+	}{
+		{
+			name:             "test for explicit username winning over the derived default",
+			username:         "explicituser",
+			ocmUsername:      "ocmuser",
+			user:             "localuser",
+			expectedUsername: "explicituser",
+		},
+		{
+			name:             "test for username derived from OCM",
+			ocmUsername:      "ocmuser",
+			user:             "localuser",
+			expectedUsername: "ocmuser",
+		},
+		{
+			name:             "test for username falling back to $USER",
+			ocmErr:           fmt.Errorf("not logged in"),
+			user:             "localuser",
+			expectedUsername: "localuser",
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("USER", test.user)
+
+			o := &accountAssignOptions{}
+			o.username = test.username
+			o.ocmUsername = func() (string, error) {
+				if test.username != "" {
+					t.Errorf("expected OCM not to be queried when a username is given")
+				}
+				return test.ocmUsername, test.ocmErr
+			}
+
+			username := o.resolveUsername()
+			if username != test.expectedUsername {
+				t.Errorf("expected %s is %s", test.expectedUsername, username)
+			}
+		})
+	}
+}
+
+func TestAssignAccountCreationFlags(t *testing.T) {
+	testData := []struct {
+		name              string
+		noCreate          bool
+		forceRecreate     bool
+		poolAccounts      []string
+		expectCreate      bool
+		expectedAccountId string
+		expectErr         error
+	}{
+		{
+			name:         "test for no-create with an empty pool",
+			noCreate:     true,
+			poolAccounts: []string{},
+			expectErr:    ErrNoUntaggedAccounts,
+		},
+		{
+			name:              "test for no-create with a non-empty pool",
+			noCreate:          true,
+			poolAccounts:      []string{"111111111111"},
+			expectedAccountId: "111111111111",
+		},
+		{
+			name:              "test for force-recreate with an empty pool",
+			forceRecreate:     true,
+			poolAccounts:      []string{},
+			expectCreate:      true,
+			expectedAccountId: "333333333333",
+		},
+		{
+			name:              "test for force-recreate with a non-empty pool",
+			forceRecreate:     true,
+			poolAccounts:      []string{"111111111111"},
+			expectCreate:      true,
+			expectedAccountId: "333333333333",
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+			rootOu := "r-abcd"
+			destOu := "ou-abcd-vnjfdshs"
+
+			if test.forceRecreate {
+				// The pool isn't looked at when always creating
+				mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Times(0)
+			} else {
+				accounts := []*organizations.Account{}
+				for _, id := range test.poolAccounts {
+					accounts = append(accounts, &organizations.Account{Id: aws.String(id)})
+					mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil).Times(2)
+					mockAWSClient.EXPECT().DescribeAccount(gomock.Any()).Return(&organizations.DescribeAccountOutput{
+						Account: &organizations.Account{
+							Id:     aws.String(id),
+							Status: aws.String(organizations.AccountStatusActive),
+						},
+					}, nil)
+				}
+				mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+					&organizations.ListAccountsForParentOutput{Accounts: accounts}, nil)
+			}
+
+			if test.expectCreate {
+				mockAWSClient.EXPECT().CreateAccount(gomock.Any()).Return(&organizations.CreateAccountOutput{
+					CreateAccountStatus: &organizations.CreateAccountStatus{Id: aws.String("car-random1234")},
+				}, nil)
+				mockAWSClient.EXPECT().DescribeCreateAccountStatus(gomock.Any()).Return(&organizations.DescribeCreateAccountStatusOutput{
+					CreateAccountStatus: &organizations.CreateAccountStatus{
+						State:     aws.String("SUCCEEDED"),
+						AccountId: aws.String(test.expectedAccountId),
+					},
+				}, nil)
+			} else {
+				mockAWSClient.EXPECT().CreateAccount(gomock.Any()).Times(0)
+			}
+
+			if test.expectErr == nil {
+				mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(&organizations.TagResourceOutput{}, nil)
+				mockAWSClient.EXPECT().ListParents(gomock.Any()).Return(&organizations.ListParentsOutput{
+					Parents: []*organizations.Parent{{Id: aws.String(rootOu)}},
+				}, nil)
+				mockAWSClient.EXPECT().MoveAccount(&organizations.MoveAccountInput{
+					AccountId:           aws.String(test.expectedAccountId),
+					DestinationParentId: aws.String(destOu),
+					SourceParentId:      aws.String(rootOu),
+				}).Return(&organizations.MoveAccountOutput{}, nil)
+			}
+
+			o := &accountAssignOptions{}
+			o.awsClient = mockAWSClient
+			o.username = "tuser"
+			o.noCreate = test.noCreate
+			o.forceRecreate = test.forceRecreate
+
+			returnValue, err := o.assignAccount(rootOu, destOu)
+			if err != test.expectErr {
+				t.Errorf("expected error %v and got %v", test.expectErr, err)
+			}
+			if returnValue != test.expectedAccountId {
+				t.Errorf("expected %s is %s", test.expectedAccountId, returnValue)
+			}
+		})
+	}
+}
+
+func TestVerifyMove(t *testing.T) {
+	interval := moveVerifyInterval
+	moveVerifyInterval = 0
+	defer func() { moveVerifyInterval = interval }()
+
+	testData := []struct {
+		name      string
+		parents   []string
+		expectErr error
+	}{
+		{
+			name:      "test for move visible on the second check",
+			parents:   []string{"r-abcd", "ou-abcd-vnjfdshs"},
+			expectErr: nil,
+		},
+		{
+			name:      "test for move never visible",
+			parents:   []string{"r-abcd", "r-abcd", "r-abcd", "r-abcd", "r-abcd"},
+			expectErr: ErrMoveNotVerified,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+			accountID := "111111111111"
+			destOu := "ou-abcd-vnjfdshs"
+
+			calls := []*gomock.Call{}
+			for _, parent := range test.parents {
+				calls = append(calls, mockAWSClient.EXPECT().ListParents(&organizations.ListParentsInput{
+					ChildId: aws.String(accountID),
+				}).Return(&organizations.ListParentsOutput{
+					Parents: []*organizations.Parent{{Id: aws.String(parent)}},
+				}, nil))
+			}
+			gomock.InOrder(calls...)
+
+			o := &accountAssignOptions{}
+			o.awsClient = mockAWSClient
+
+			err := o.verifyMove(accountID, destOu)
+			if err != test.expectErr {
+				t.Errorf("expected error %v and got %v", test.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestTagAccountMergesExistingTags(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+	accountID := "111111111111"
+
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{
+		Tags: []*organizations.Tag{
+			{Key: aws.String("owner"), Value: aws.String("tuser")},
+			{Key: aws.String("claimed"), Value: aws.String("true")},
+			{Key: aws.String("team"), Value: aws.String("srep")},
+			{Key: aws.String("backup-policy"), Value: aws.String("daily")},
+		},
+	}, nil)
+	var tagged map[string]string
+	mockAWSClient.EXPECT().TagResource(gomock.Any()).DoAndReturn(
+		func(input *organizations.TagResourceInput) (*organizations.TagResourceOutput, error) {
+			tagged = map[string]string{}
+			for _, t := range input.Tags {
+				tagged[*t.Key] = *t.Value
+			}
+			return &organizations.TagResourceOutput{}, nil
+		},
+	)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.username = "tuser"
+	o.extraTags = map[string]string{"team": "srep", "cost-center": "1234"}
+	err := o.tagAccount(accountID)
+	if err != nil {
+		t.Fatalf("failed to tag account: %v", err)
+	}
+
+	if tagged["cost-center"] != "1234" || tagged["claimed-at"] == "" || tagged["claim-history"] == "" {
+		t.Errorf("expected the new tags to be set, got %v", tagged)
+	}
+	for _, unchanged := range []string{"owner", "claimed", "team", "backup-policy"} {
+		if _, ok := tagged[unchanged]; ok {
+			t.Errorf("expected tag %s with an unchanged value not to be set again, got %v", unchanged, tagged)
+		}
+	}
+	if o.listedTags[accountID]["backup-policy"] != "daily" || o.listedTags[accountID]["cost-center"] != "1234" {
+		t.Errorf("expected the remembered tags to be merged, got %v", o.listedTags[accountID])
+	}
+
+	// Applying the same tags again has nothing left to set
+	err = o.applyTags(accountID, map[string]string{"team": "srep", "cost-center": "1234"})
+	if err != nil {
+		t.Fatalf("failed to re-tag account: %v", err)
+	}
+}
+
+func TestTagAccount(t *testing.T) {
+	testData := []struct {
+		name           string
+		ttl            time.Duration
+		extraTags      map[string]string
+		expectExpiring bool
+	}{
+		{
+			name:           "test for account tagged without ttl",
+			ttl:            0,
+			expectExpiring: false,
+		},
+		{
+			name:           "test for account tagged with ttl",
+			ttl:            72 * time.Hour,
+			expectExpiring: true,
+		},
+		{
+			name:           "test for account tagged with extra tags",
+			ttl:            0,
+			extraTags:      map[string]string{"cost-center": "1234", "team": "srep"},
+			expectExpiring: false,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+			accountID := "111111111111"
+
+			awsOutputTag := &organizations.TagResourceOutput{}
+
+			mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil)
+			var tags map[string]string
+			mockAWSClient.EXPECT().TagResource(gomock.Any()).DoAndReturn(
+				func(input *organizations.TagResourceInput) (*organizations.TagResourceOutput, error) {
+					tags = map[string]string{}
+					for _, t := range input.Tags {
+						tags[*t.Key] = *t.Value
+					}
+					return awsOutputTag, nil
+				},
+			)
+
+			o := &accountAssignOptions{}
+			o.awsClient = mockAWSClient
+			o.username = "tuser"
+			o.ttl = test.ttl
+			o.extraTags = test.extraTags
+			before := time.Now().UTC().Truncate(time.Second)
+			err := o.tagAccount(accountID)
+			if err != nil {
+				t.Fatalf("failed to tag account")
+			}
+
+			if tags["owner"] != "tuser" || tags["claimed"] != "true" {
+				t.Errorf("expected owner and claimed tags, got %v", tags)
+			}
+			if !strings.HasPrefix(tags["claim-history"], "assign:tuser:") {
+				t.Errorf("expected the assignment to be recorded in the claim history, got %v", tags)
+			}
+			for k, v := range test.extraTags {
+				if tags[k] != v {
+					t.Errorf("expected tag %s=%s, got %v", k, v, tags)
+				}
+			}
+
+			claimedAt, err := time.Parse(time.RFC3339, tags["claimed-at"])
+			if err != nil {
+				t.Fatalf("expected claimed-at tag to be RFC3339, got %q", tags["claimed-at"])
+			}
+			if claimedAt.Before(before) || claimedAt.After(time.Now().UTC()) {
+				t.Errorf("expected claimed-at tag to be the current time, got %v", claimedAt)
+			}
+
+			expiresAtValue, hasExpiresAt := tags["expires-at"]
+			if hasExpiresAt != test.expectExpiring {
+				t.Fatalf("expected expires-at tag presence to be %t, got %v", test.expectExpiring, tags)
+			}
+			if test.expectExpiring {
+				expiresAt, err := time.Parse(time.RFC3339, expiresAtValue)
+				if err != nil {
+					t.Fatalf("expected expires-at tag to be RFC3339, got %q", expiresAtValue)
+				}
+				if !expiresAt.Equal(claimedAt.Add(test.ttl)) {
+					t.Errorf("expected expires-at to be %v after claimed-at, got %v", test.ttl, expiresAt.Sub(claimedAt))
+				}
+			}
+		})
+	}
+}
+
+func TestTagAccountOnConflict(t *testing.T) {
+	testData := []struct {
+		name        string
+		onConflict  string
+		expectTag   bool
+		expectedErr error
+	}{
+		{name: "skip leaves the account as is", onConflict: onConflictSkip},
+		{name: "fail returns an error", onConflict: onConflictFail, expectedErr: ErrAccountAlreadyOwned},
+		{name: "no policy fails", expectedErr: ErrAccountAlreadyOwned},
+		{name: "overwrite replaces the owner", onConflict: onConflictOverwrite, expectTag: true},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+			accountID := "111111111111"
+
+			mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{
+				Tags: []*organizations.Tag{
+					{Key: aws.String("owner"), Value: aws.String("otheruser")},
+					{Key: aws.String("claimed"), Value: aws.String("true")},
+				},
+			}, nil)
+			var tagged map[string]string
+			if test.expectTag {
+				mockAWSClient.EXPECT().TagResource(gomock.Any()).DoAndReturn(
+					func(input *organizations.TagResourceInput) (*organizations.TagResourceOutput, error) {
+						tagged = map[string]string{}
+						for _, t := range input.Tags {
+							tagged[*t.Key] = *t.Value
+						}
+						return &organizations.TagResourceOutput{}, nil
+					},
+				)
+			} else {
+				mockAWSClient.EXPECT().TagResource(gomock.Any()).Times(0)
+			}
+
+			errOut := &strings.Builder{}
+			o := &accountAssignOptions{}
+			o.awsClient = mockAWSClient
+			o.username = "tuser"
+			o.onConflict = test.onConflict
+			o.ErrOut = errOut
+
+			err := o.tagAccount(accountID)
+			if test.expectedErr != nil {
+				if !errors.Is(err, test.expectedErr) {
+					t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.expectTag && tagged["owner"] != "tuser" {
+				t.Errorf("expected the owner to be replaced, got %v", tagged)
+			}
+			if !test.expectTag && !strings.Contains(errOut.String(), "owned by otheruser") {
+				t.Errorf("expected a warning that the account was skipped, got %q", errOut.String())
+			}
+		})
+	}
+}
+
+func TestNormalizeOwner(t *testing.T) {
+	testData := []struct {
+		name     string
+		owner    string
+		format   string
+		expected string
+	}{
+		{name: "username strips the domain", owner: "tuser@example.com", format: ownerFormatUsername, expected: "tuser"},
+		{name: "username keeps a username", owner: "tuser", format: ownerFormatUsername, expected: "tuser"},
+		{name: "email appends the domain", owner: "tuser", format: ownerFormatEmail, expected: "tuser" + accountEmailDomain},
+		{name: "email keeps an email", owner: "tuser@example.com", format: ownerFormatEmail, expected: "tuser@example.com"},
+		{name: "raw keeps an email", owner: "tuser@example.com", format: ownerFormatRaw, expected: "tuser@example.com"},
+		{name: "raw keeps a username", owner: "tuser", format: ownerFormatRaw, expected: "tuser"},
+		{name: "no format keeps the owner", owner: "tuser@example.com", expected: "tuser@example.com"},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			got := normalizeOwner(test.owner, test.format)
+			if got != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestTagAccountOwnerFormat(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+	accountID := "111111111111"
+
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil)
+	var tagged map[string]string
+	mockAWSClient.EXPECT().TagResource(gomock.Any()).DoAndReturn(
+		func(input *organizations.TagResourceInput) (*organizations.TagResourceOutput, error) {
+			tagged = map[string]string{}
+			for _, t := range input.Tags {
+				tagged[*t.Key] = *t.Value
+			}
+			return &organizations.TagResourceOutput{}, nil
+		},
+	)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.username = "tuser"
+	o.ownerFormat = ownerFormatEmail
+	err := o.tagAccount(accountID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tagged["owner"] != "tuser"+accountEmailDomain {
+		t.Errorf("expected the owner tag to be an email, got %v", tagged)
+	}
+	if !strings.HasPrefix(tagged["claim-history"], "assign:tuser"+accountEmailDomain+":") {
+		t.Errorf("expected the claim history to record the normalized owner, got %v", tagged)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	testData := []struct {
+		name      string
+		tags      []string
+		expected  map[string]string
+		expectErr bool
+	}{
+		{
+			name:     "test for valid tags",
+			tags:     []string{"cost-center=1234", "note=a=b"},
+			expected: map[string]string{"cost-center": "1234", "note": "a=b"},
+		},
+		{
+			name:      "test for tag without value",
+			tags:      []string{"cost-center"},
+			expectErr: true,
+		},
+		{
+			name:      "test for tag without key",
+			tags:      []string{"=1234"},
+			expectErr: true,
+		},
+		{
+			name:      "test for reserved tag",
+			tags:      []string{"owner=someoneelse"},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			tags, err := parseTags(test.tags)
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("expected an error parsing %v", test.tags)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tags, test.expected) {
+				t.Errorf("expected %v is %v", test.expected, tags)
+			}
+		})
+	}
+}
+
+func TestMoveAccount(t *testing.T) {
+	testData := []struct {
+		name       string
+		destOu     string
+		parentId   string
+		expectMove bool
+		expectErr  bool
+	}{
+		{
+			name:       "test for account in the root",
+			destOu:     "ou-abcd-vnjfdshs",
+			parentId:   "r-abcd",
+			expectMove: true,
+			expectErr:  false,
+		},
+		{
+			name:       "test for account in a child OU",
+			destOu:     "ou-abcd-vnjfdshs",
+			parentId:   "ou-abcd-childchi",
+			expectMove: true,
+			expectErr:  false,
+		},
+		{
+			name:       "test for account already in the destination OU",
+			destOu:     "ou-abcd-vnjfdshs",
+			parentId:   "ou-abcd-vnjfdshs",
+			expectMove: false,
+			expectErr:  false,
+		},
+		{
+			name:       "test for malformed destination OU",
+			destOu:     "abc-vnjfdshs",
+			expectMove: false,
+			expectErr:  true,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+			accountId := "111111111111"
+
+			if test.parentId != "" {
+				mockAWSClient.EXPECT().ListParents(&organizations.ListParentsInput{
+					ChildId: aws.String(accountId),
+				}).Return(&organizations.ListParentsOutput{
+					Parents: []*organizations.Parent{{Id: aws.String(test.parentId)}},
+				}, nil)
+			}
+			if test.expectMove {
+				mockAWSClient.EXPECT().MoveAccount(&organizations.MoveAccountInput{
+					AccountId:           aws.String(accountId),
+					DestinationParentId: aws.String(test.destOu),
+					SourceParentId:      aws.String(test.parentId),
+				}).Return(
+					&organizations.MoveAccountOutput{},
+					nil,
+				)
+			}
+
+			o := &accountAssignOptions{}
+			o.awsClient = mockAWSClient
+			moved, err := o.moveAccount(accountId, test.destOu)
+			if test.expectErr && err == nil {
+				t.Errorf("expected an error moving account")
+			}
+			if !test.expectErr && err != nil {
+				t.Errorf("failed to move account: %v", err)
+			}
+			if moved != test.expectMove {
+				t.Errorf("expected moved to be %t, got %t", test.expectMove, moved)
+			}
+		})
+	}
+}
+
+func TestAssignSpecificAccount(t *testing.T) {
+	testData := []struct {
+		name              string
+		tags              []*organizations.Tag
+		expectedAccountId string
+		expectErr         error
+	}{
+		{
+			name:              "test for unowned account is assigned",
+			tags:              []*organizations.Tag{},
+			expectedAccountId: "111111111111",
+			expectErr:         nil,
+		},
+		{
+			name: "test for owned account is rejected",
+			tags: []*organizations.Tag{
+				{
+					Key:   aws.String("owner"),
+					Value: aws.String("randuser"),
+				},
+				{
+					Key:   aws.String("claimed"),
+					Value: aws.String("true"),
+				},
+			},
+			expectedAccountId: "",
+			expectErr:         ErrAccountAlreadyOwned,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+			accountID := "111111111111"
+			rootOu := "r-abcd"
+			destOu := "ou-abcd-vnjfdshs"
+
+			mockAWSClient.EXPECT().ListTagsForResource(
+				&organizations.ListTagsForResourceInput{
+					ResourceId: aws.String(accountID),
+				},
+			).Return(&organizations.ListTagsForResourceOutput{Tags: test.tags}, nil)
+
+			if test.expectErr == nil {
+				mockAWSClient.EXPECT().DescribeAccount(
+					&organizations.DescribeAccountInput{
+						AccountId: aws.String(accountID),
+					},
+				).Return(&organizations.DescribeAccountOutput{
+					Account: &organizations.Account{
+						Id:     aws.String(accountID),
+						Status: aws.String(organizations.AccountStatusActive),
+					},
+				}, nil)
+				mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(&organizations.TagResourceOutput{}, nil)
+				mockAWSClient.EXPECT().ListParents(gomock.Any()).Return(&organizations.ListParentsOutput{
+					Parents: []*organizations.Parent{{Id: aws.String(rootOu)}},
+				}, nil)
+				mockAWSClient.EXPECT().MoveAccount(&organizations.MoveAccountInput{
+					AccountId:           aws.String(accountID),
+					DestinationParentId: aws.String(destOu),
+					SourceParentId:      aws.String(rootOu),
+				}).Return(&organizations.MoveAccountOutput{}, nil)
+			}
+
+			o := &accountAssignOptions{}
+			o.awsClient = mockAWSClient
+			o.accountID = accountID
+			o.username = "tuser"
+
+			returnValue, err := o.assignAccount(rootOu, destOu)
+			if err != test.expectErr {
+				t.Errorf("expected error %v and got %v", test.expectErr, err)
+			}
+			if returnValue != test.expectedAccountId {
+				t.Errorf("expected %s is %s", test.expectedAccountId, returnValue)
+			}
+		})
+	}
+}
+
+func TestAssignAccounts(t *testing.T) {
+	var genericAWSError error = fmt.Errorf("Generic AWS error")
+
+	testData := []struct {
+		name               string
+		secondListErr      error
+		expectedAccountIds []string
+		expectErr          error
+	}{
+		{
+			name:               "test for both accounts assigned",
+			secondListErr:      nil,
+			expectedAccountIds: []string{"111111111111", "222222222222"},
+			expectErr:          nil,
+		},
+		{
+			name:               "test for second assignment failing",
+			secondListErr:      genericAWSError,
+			expectedAccountIds: []string{"111111111111"},
+			expectErr:          genericAWSError,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+			rootOu := "r-abcd"
+			destOu := "ou-abcd-vnjfdshs"
+
+			expectAssignment := func(accountID string) {
+				mockAWSClient.EXPECT().ListTagsForResource(
+					&organizations.ListTagsForResourceInput{
+						ResourceId: aws.String(accountID),
+					},
+				).Return(&organizations.ListTagsForResourceOutput{Tags: []*organizations.Tag{}}, nil).Times(2)
+				mockAWSClient.EXPECT().DescribeAccount(
+					&organizations.DescribeAccountInput{
+						AccountId: aws.String(accountID),
+					},
+				).Return(&organizations.DescribeAccountOutput{
+					Account: &organizations.Account{
+						Id:     aws.String(accountID),
+						Status: aws.String(organizations.AccountStatusActive),
+					},
+				}, nil)
+				mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(&organizations.TagResourceOutput{}, nil)
+				mockAWSClient.EXPECT().ListParents(gomock.Any()).Return(&organizations.ListParentsOutput{
+					Parents: []*organizations.Parent{{Id: aws.String(rootOu)}},
+				}, nil)
+				mockAWSClient.EXPECT().MoveAccount(&organizations.MoveAccountInput{
+					AccountId:           aws.String(accountID),
+					DestinationParentId: aws.String(destOu),
+					SourceParentId:      aws.String(rootOu),
+				}).Return(&organizations.MoveAccountOutput{}, nil)
+			}
+
+			// Each assignment moves the account out of the root, so every listing returns the next account
+			mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+				&organizations.ListAccountsForParentOutput{
+					Accounts: []*organizations.Account{{Id: aws.String("111111111111")}},
+				}, nil)
+			expectAssignment("111111111111")
+
+			if test.secondListErr != nil {
+				mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(nil, test.secondListErr)
+			} else {
+				mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+					&organizations.ListAccountsForParentOutput{
+						Accounts: []*organizations.Account{{Id: aws.String("222222222222")}},
+					}, nil)
+				expectAssignment("222222222222")
+			}
+
+			o := &accountAssignOptions{}
+			o.awsClient = mockAWSClient
+			o.username = "tuser"
+
+			returnValue, err := o.assignAccounts(rootOu, destOu, 2)
+			if test.expectErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if test.expectErr != nil {
+				if !errors.Is(err, test.expectErr) {
+					t.Errorf("expected error %v and got %v", test.expectErr, err)
+				}
+				if !strings.Contains(err.Error(), "111111111111") {
+					t.Errorf("expected error to report the assigned account, got %v", err)
+				}
+			}
+			if strings.Join(returnValue, ",") != strings.Join(test.expectedAccountIds, ",") {
+				t.Errorf("expected %v is %v", test.expectedAccountIds, returnValue)
+			}
+		})
+	}
+}
+
+func TestAssignmentFileRoundTrip(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	accountID := "111111111111"
+	rootOu := "r-abcd"
+	destOu := "ou-abcd-vnjfdshs"
+
+	saved := assignResponse{
+		Username: "tuser",
+		Id:       accountID,
+		OU:       destOu,
+		Tags: map[string]string{
+			"owner":      "tuser",
+			"claimed":    "true",
+			"claimed-at": "2022-06-01T10:00:00Z",
+		},
+	}
+
+	raw, err := json.Marshal(outputflag.NewEnvelope("AccountAssignment", saved))
+	if err != nil {
+		t.Fatalf("failed to marshal assignment: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "assignment.json")
+	err = os.WriteFile(path, raw, 0600)
+	if err != nil {
+		t.Fatalf("failed to write assignment file: %v", err)
+	}
+
+	assignment, err := readAssignmentFile(path)
+	if err != nil {
+		t.Fatalf("failed to read assignment file: %v", err)
+	}
+	if !reflect.DeepEqual(assignment, saved) {
+		t.Fatalf("expected %v is %v", saved, assignment)
+	}
+
+	mockAWSClient.EXPECT().DescribeAccount(
+		&organizations.DescribeAccountInput{
+			AccountId: aws.String(accountID),
+		},
+	).Return(&organizations.DescribeAccountOutput{
+		Account: &organizations.Account{
+			Id:     aws.String(accountID),
+			Status: aws.String(organizations.AccountStatusActive),
+		},
+	}, nil)
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil)
+	mockAWSClient.EXPECT().TagResource(&organizations.TagResourceInput{
+		ResourceId: aws.String(accountID),
+		Tags: []*organizations.Tag{
+			{Key: aws.String("claimed"), Value: aws.String("true")},
+			{Key: aws.String("claimed-at"), Value: aws.String("2022-06-01T10:00:00Z")},
+			{Key: aws.String("owner"), Value: aws.String("tuser")},
+		},
+	}).Return(&organizations.TagResourceOutput{}, nil)
+	mockAWSClient.EXPECT().ListParents(gomock.Any()).Return(&organizations.ListParentsOutput{
+		Parents: []*organizations.Parent{{Id: aws.String(rootOu)}},
+	}, nil)
+	mockAWSClient.EXPECT().MoveAccount(&organizations.MoveAccountInput{
+		AccountId:           aws.String(accountID),
+		DestinationParentId: aws.String(destOu),
+		SourceParentId:      aws.String(rootOu),
+	}).Return(&organizations.MoveAccountOutput{}, nil)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+
+	applied, err := o.applyAssignment(assignment)
+	if err != nil {
+		t.Fatalf("failed to apply assignment: %v", err)
+	}
+	if !reflect.DeepEqual(applied, saved) {
+		t.Errorf("expected %v is %v", saved, applied)
+	}
+}
+
+func TestReadAssignmentFileInvalid(t *testing.T) {
+	testData := []struct {
+		name     string
+		contents string
+	}{
+		{
+			name:     "test for malformed json",
+			contents: "{",
+		},
+		{
+			name:     "test for wrong kind",
+			contents: `{"apiVersion": "` + outputflag.OutputAPIVersion + `", "kind": "AccountList", "data": {"username": "tuser", "id": "111111111111", "ou": "ou-abcd-vnjfdshs"}}`,
+		},
+		{
+			name:     "test for malformed ou",
+			contents: `{"apiVersion": "` + outputflag.OutputAPIVersion + `", "kind": "AccountAssignment", "data": {"username": "tuser", "id": "111111111111", "ou": "abcd"}}`,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "assignment.json")
+			err := os.WriteFile(path, []byte(test.contents), 0600)
+			if err != nil {
+				t.Fatalf("failed to write assignment file: %v", err)
+			}
+
+			_, err = readAssignmentFile(path)
+			if !errors.Is(err, ErrInvalidAssignmentFile) {
+				t.Errorf("expected error %v and got %v", ErrInvalidAssignmentFile, err)
+			}
+		})
+	}
+}
+
+func TestAssignOwners(t *testing.T) {
+	var genericAWSError error = fmt.Errorf("Generic AWS error")
+
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	rootOu := "r-abcd"
+	destOu := "ou-abcd-vnjfdshs"
+
+	expectAssignment := func(accountID string, owner string) {
+		mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+			&organizations.ListAccountsForParentOutput{
+				Accounts: []*organizations.Account{{Id: aws.String(accountID)}},
+			}, nil)
+		mockAWSClient.EXPECT().ListTagsForResource(
+			&organizations.ListTagsForResourceInput{
+				ResourceId: aws.String(accountID),
+			},
+		).Return(&organizations.ListTagsForResourceOutput{Tags: []*organizations.Tag{}}, nil).Times(2)
+		mockAWSClient.EXPECT().DescribeAccount(
+			&organizations.DescribeAccountInput{
+				AccountId: aws.String(accountID),
+			},
+		).Return(&organizations.DescribeAccountOutput{
+			Account: &organizations.Account{
+				Id:     aws.String(accountID),
+				Status: aws.String(organizations.AccountStatusActive),
+			},
+		}, nil)
+		mockAWSClient.EXPECT().TagResource(gomock.Any()).DoAndReturn(
+			func(input *organizations.TagResourceInput) (*organizations.TagResourceOutput, error) {
+				for _, tag := range input.Tags {
+					if *tag.Key == "owner" && *tag.Value != owner {
+						t.Errorf("expected account %s to be tagged for %s, got %s", accountID, owner, *tag.Value)
+					}
+				}
+				return &organizations.TagResourceOutput{}, nil
+			})
+		mockAWSClient.EXPECT().ListParents(gomock.Any()).Return(&organizations.ListParentsOutput{
+			Parents: []*organizations.Parent{{Id: aws.String(rootOu)}},
+		}, nil)
+		mockAWSClient.EXPECT().MoveAccount(&organizations.MoveAccountInput{
+			AccountId:           aws.String(accountID),
+			DestinationParentId: aws.String(destOu),
+			SourceParentId:      aws.String(rootOu),
+		}).Return(&organizations.MoveAccountOutput{}, nil)
+	}
+
+	// The second owner's assignment fails, the owners either side of it are still assigned accounts
+	expectAssignment("111111111111", "alice")
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(nil, genericAWSError)
+	expectAssignment("333333333333", "carol")
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.username = "tuser"
+
+	claims := []ownerClaim{{owner: "alice", count: 1}, {owner: "bob", count: 1}, {owner: "carol", count: 1}}
+	resp, err := o.assignOwners(rootOu, destOu, claims)
+	if err == nil {
+		t.Fatalf("expected an error for the failed owner")
+	}
+	for _, want := range []string{"1 of 3 owners", "bob: Generic AWS error"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+
+	expected := []ownerAssignment{
+		{Owner: "alice", Ids: []string{"111111111111"}},
+		{Owner: "bob", Ids: []string{}, Error: genericAWSError.Error()},
+		{Owner: "carol", Ids: []string{"333333333333"}},
+	}
+	if !reflect.DeepEqual(resp.Assignments, expected) {
+		t.Errorf("expected %v is %v", expected, resp.Assignments)
+	}
+	if o.username != "tuser" {
+		t.Errorf("expected the username to be restored, got %s", o.username)
+	}
+	if !strings.Contains(resp.String(), "(failed: Generic AWS error)") {
+		t.Errorf("expected the table to report the failure, got:\n%s", resp.String())
+	}
+}
+
+func TestReadOwnerFile(t *testing.T) {
+	testData := []struct {
+		name           string
+		contents       string
+		expectedClaims []ownerClaim
+		expectErr      error
+	}{
+		{
+			name:           "test for a list of owners",
+			contents:       "alice\nbob\n",
+			expectedClaims: []ownerClaim{{owner: "alice", count: 1}, {owner: "bob", count: 1}},
+		},
+		{
+			name:           "test for owners with counts and comments",
+			contents:       "# onboarding\nalice=2\n\nbob = 1\n",
+			expectedClaims: []ownerClaim{{owner: "alice", count: 2}, {owner: "bob", count: 1}},
+		},
+		{
+			name:      "test for an invalid count",
+			contents:  "alice=0\n",
+			expectErr: ErrInvalidOwnerFile,
+		},
+		{
+			name:      "test for a duplicate owner",
+			contents:  "alice\nalice=2\n",
+			expectErr: ErrInvalidOwnerFile,
+		},
+		{
+			name:      "test for no owners",
+			contents:  "# nobody\n",
+			expectErr: ErrInvalidOwnerFile,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "owners")
+			err := os.WriteFile(path, []byte(test.contents), 0600)
+			if err != nil {
+				t.Fatalf("failed to write owner file: %v", err)
+			}
+
+			claims, err := readOwnerFile(path)
+			if !errors.Is(err, test.expectErr) {
+				t.Fatalf("expected error %v and got %v", test.expectErr, err)
+			}
+			if test.expectErr == nil && !reflect.DeepEqual(claims, test.expectedClaims) {
+				t.Errorf("expected %v is %v", test.expectedClaims, claims)
+			}
+		})
+	}
+}
+
+func TestCreateAccountEmailPrefix(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	seed := int64(1)
+	rand.Seed(seed)
+	accountName := "osd-pool-b+" + RandomString(6)
+	email := accountName + "@redhat.com"
+	createId := "car-random1234"
+
+	mockAWSClient.EXPECT().CreateAccount(&organizations.CreateAccountInput{
+		AccountName: &accountName,
+		Email:       &email,
+	}).Return(&organizations.CreateAccountOutput{
+		CreateAccountStatus: &organizations.CreateAccountStatus{Id: &createId},
+	}, nil)
+	mockAWSClient.EXPECT().DescribeCreateAccountStatus(gomock.Any()).Return(&organizations.DescribeCreateAccountStatusOutput{
+		CreateAccountStatus: &organizations.CreateAccountStatus{State: aws.String("SUCCEEDED")},
+	}, nil)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.emailPrefix = "osd-pool-b+"
+	_, err := o.createAccount(seed)
+	if err != nil {
+		t.Errorf("failed to create account: %v", err)
+	}
+}
+
+func TestCreateAccountReservedEmail(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	seed := int64(1)
+	rand.Seed(seed)
+	reservedName := defaultEmailPrefix + RandomString(6)
+	accountName := defaultEmailPrefix + RandomString(6)
+	email := accountName + "@redhat.com"
+	createId := "car-random1234"
+
+	// The first generated email is reserved, so the account is created with the next one
+	mockAWSClient.EXPECT().CreateAccount(&organizations.CreateAccountInput{
+		AccountName: &accountName,
+		Email:       &email,
+	}).Return(&organizations.CreateAccountOutput{
+		CreateAccountStatus: &organizations.CreateAccountStatus{Id: &createId},
+	}, nil)
+	mockAWSClient.EXPECT().DescribeCreateAccountStatus(gomock.Any()).Return(&organizations.DescribeCreateAccountStatusOutput{
+		CreateAccountStatus: &organizations.CreateAccountStatus{State: aws.String("SUCCEEDED")},
+	}, nil)
+
+	reservedEmails, err := compileReservedEmailPatterns([]string{"^" + regexp.QuoteMeta(reservedName+"@redhat.com") + "$"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.reservedEmails = reservedEmails
+	_, err = o.createAccount(seed)
+	if err != nil {
+		t.Errorf("failed to create account: %v", err)
+	}
+}
+
+func TestCreateAccountNoUnreservedEmail(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	// Every generated email is reserved, so no account is requested
+	reservedEmails, err := compileReservedEmailPatterns([]string{"^osd-creds-mgmt\\+"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.reservedEmails = reservedEmails
+	_, err = o.createAccount(1)
+	if !errors.Is(err, ErrNoUnreservedEmail) {
+		t.Errorf("expected %v, got %v", ErrNoUnreservedEmail, err)
+	}
+}
+
+func TestDefaultReservedEmailPatterns(t *testing.T) {
+	reservedEmails, err := compileReservedEmailPatterns(defaultReservedEmailPatterns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testData := []struct {
+		email    string
+		reserved bool
+	}{
+		{email: "osd-creds-mgmt+abc123@redhat.com", reserved: false},
+		{email: "admin+abc123@redhat.com", reserved: true},
+		{email: "osd..pool+abc123@redhat.com", reserved: true},
+		{email: "osd-pool.@redhat.com", reserved: true},
+	}
+	for _, test := range testData {
+		if matchesAny(reservedEmails, test.email) != test.reserved {
+			t.Errorf("expected %s to be reserved: %t", test.email, test.reserved)
+		}
+	}
+}
+
+func TestAssumeRoleCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		roleName     string
+		expectedRole string
+	}{
+		{
+			name:         "default role",
+			roleName:     "",
+			expectedRole: defaultAccessRoleName,
+		},
+		{
+			name:         "custom role",
+			roleName:     "OSDDeveloperAccess",
+			expectedRole: "OSDDeveloperAccess",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := &accountAssignOptions{}
+			o.payerAccount = "osd-staging-2"
+			o.username = "tuser"
+			o.roleName = test.roleName
+
+			resp := assignResponse{
+				Username:          o.username,
+				Id:                "111111111111",
+				AssumeRoleCommand: o.assumeRoleCommand("111111111111"),
+			}
+			expectedArn := fmt.Sprintf("arn:aws:iam::111111111111:role/%s", test.expectedRole)
+			if !strings.Contains(resp.AssumeRoleCommand, expectedArn) {
+				t.Errorf("expected the command to assume %s, got %s", expectedArn, resp.AssumeRoleCommand)
+			}
+			if !strings.Contains(resp.AssumeRoleCommand, "--profile osd-staging-2") {
+				t.Errorf("expected the command to use the payer account's profile, got %s", resp.AssumeRoleCommand)
+			}
+			if !strings.Contains(resp.String(), resp.AssumeRoleCommand) {
+				t.Errorf("expected the command to be printed, got %s", resp.String())
+			}
+		})
+	}
+}
+
+func TestAssignResponseExpiresAt(t *testing.T) {
+	claimedAt := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		expiresAt string
+	}{
+		{
+			name:      "without ttl",
+			ttl:       0,
+			expiresAt: "",
+		},
+		{
+			name:      "with ttl",
+			ttl:       72 * time.Hour,
+			expiresAt: "2023-01-05T03:04:05Z",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := &accountAssignOptions{}
+			o.username = "tuser"
+			o.ttl = test.ttl
+			o.claimedAt = claimedAt
+
+			resp := assignResponse{
+				Username:  o.username,
+				Id:        "111111111111",
+				Tags:      o.assignmentTags(),
+				ExpiresAt: o.expiresAt(),
+			}
+			raw, err := json.Marshal(outputflag.NewEnvelope("AccountAssignment", resp))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var out struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			err = json.Unmarshal(raw, &out)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expiresAt, ok := out.Data["expiresAt"]
+			if test.expiresAt == "" {
+				if ok {
+					t.Errorf("expected no expiresAt without --ttl, got %v", expiresAt)
+				}
+				return
+			}
+			if expiresAt != test.expiresAt {
+				t.Errorf("expected expiresAt %s, got %v", test.expiresAt, expiresAt)
+			}
+			if resp.Tags["expires-at"] != test.expiresAt {
+				t.Errorf("expected expiresAt to match the expires-at tag %s, got %s", resp.Tags["expires-at"], test.expiresAt)
+			}
+		})
+	}
+}
+
+func TestValidateEmailPrefix(t *testing.T) {
+	testData := []struct {
+		name      string
+		prefix    string
+		expectErr bool
+	}{
+		{name: "test for default prefix", prefix: defaultEmailPrefix, expectErr: false},
+		{name: "test for longest prefix", prefix: strings.Repeat("a", 44), expectErr: false},
+		{name: "test for too long prefix", prefix: strings.Repeat("a", 45), expectErr: true},
+		{name: "test for empty prefix", prefix: "", expectErr: true},
+		{name: "test for prefix with illegal characters", prefix: "osd creds@", expectErr: true},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateEmailPrefix(test.prefix)
+			if test.expectErr != (err != nil) {
+				t.Errorf("expected error to be %t, got %v", test.expectErr, err)
+			}
+		})
 	}
 }
@@ -2,6 +2,11 @@ package mgmt
 
 import (
 	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/organizations"
@@ -13,7 +18,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 type accountListOptions struct {
@@ -23,6 +27,18 @@ type accountListOptions struct {
 	payerAccount string
 	accountID    string
 	output       string
+	template     string
+	// ous overrides the OUs of the payer account
+	ous  accountOUs
+	sort accountSort
+	// claimedAt holds the claimed-at tag of the listed accounts, to sort and filter them by
+	claimedAt map[string]string
+	// since only keeps the accounts claimed at least this long ago, zero keeps every account. Accounts without a valid
+	// claimed-at tag have an unknown age, and are only kept with includeUnknownAge.
+	since             time.Duration
+	includeUnknownAge bool
+
+	accountTemplate *template.Template
 
 	flags      *genericclioptions.ConfigFlags
 	printFlags *printer.PrintFlags
@@ -41,6 +57,56 @@ func (f listResponse) String() string {
 
 }
 
+// claimedAccount is a single owned account, as streamed by '-o ndjson'
+type claimedAccount struct {
+	Username string `json:"username" yaml:"username"`
+	Id       string `json:"id" yaml:"id"`
+}
+
+func (f claimedAccount) String() string {
+	return fmt.Sprintf("  Username: %s\n  Account: %s\n", f.Username, f.Id)
+}
+
+// claimedAccountList is every listed account, written one per row with '-o csv'
+type claimedAccountList []claimedAccount
+
+func (f claimedAccountList) String() string {
+	var sb strings.Builder
+	for _, a := range f {
+		sb.WriteString(a.String())
+	}
+	return sb.String()
+}
+
+func (f claimedAccountList) CSVHeader() []string {
+	return []string{"username", "id"}
+}
+
+func (f claimedAccountList) CSVRows() [][]string {
+	rows := make([][]string, 0, len(f))
+	for _, a := range f {
+		rows = append(rows, []string{a.Username, a.Id})
+	}
+	return rows
+}
+
+// csvAccounts flattens the accounts of each user, in the order of listResponses
+func csvAccounts(responses []listResponse) claimedAccountList {
+	accounts := claimedAccountList{}
+	for _, resp := range responses {
+		for _, id := range resp.Accounts {
+			accounts = append(accounts, claimedAccount{Username: resp.Username, Id: id})
+		}
+	}
+	return accounts
+}
+
+// listedAccount is the data the --go-template flag is executed against for each account
+type listedAccount struct {
+	Username string
+	Id       string
+}
+
 func newAccountListOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *accountListOptions {
 	return &accountListOptions{
 		flags:         flags,
@@ -55,56 +121,91 @@ func newCmdAccountList(streams genericclioptions.IOStreams, flags *genericcliopt
 	accountListCmd := &cobra.Command{
 		Use:               "list",
 		Short:             "List out accounts for username",
+		Long:              "List the accounts owned by a user, the owner of an account, or every claimed account of the payer account.\nBesides the global output formats, '-o csv' prints a username,id row per account, and '-o ndjson' streams every\nclaimed account as a line of JSON while the OU is still being listed.",
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			outputflag.CheckErr(globalOpts.Output, ops.complete(cmd, args))
+			outputflag.CheckErr(globalOpts.Output, ops.run())
 		},
 	}
 	ops.printFlags.AddFlags(accountListCmd)
 	accountListCmd.Flags().StringVarP(&ops.username, "user", "u", "", "LDAP username")
 	accountListCmd.Flags().StringVarP(&ops.payerAccount, "payer-account", "p", "", "Payer account type")
 	accountListCmd.Flags().StringVarP(&ops.accountID, "account-id", "i", "", "Account ID")
+	addOUFlags(accountListCmd, &ops.ous)
+	addSortFlags(accountListCmd, &ops.sort)
+	accountListCmd.Flags().DurationVar(&ops.since, "since", 0, "(optional) Only list accounts claimed at least this long ago according to their claimed-at tag, e.g. 720h")
+	accountListCmd.Flags().BoolVar(&ops.includeUnknownAge, "include-unknown-age", false, "With --since, also list accounts without a valid claimed-at tag")
+	accountListCmd.Flags().StringVar(&ops.template, "go-template", "", "Go template executed for each account, e.g. '{{.Id}} {{.Username}}'. Fields are .Username and .Id")
 
 	return accountListCmd
 }
 
 func (o *accountListOptions) complete(cmd *cobra.Command, _ []string) error {
 	if o.payerAccount == "" {
-		return cmdutil.UsageErrorf(cmd, "Payer account was not provided")
+		return outputflag.UsageErrorf(cmd, "Payer account was not provided")
 	}
 	if o.username != "" && o.accountID != "" {
-		return cmdutil.UsageErrorf(cmd, "Cannot provide both username and account ID")
+		return outputflag.UsageErrorf(cmd, "Cannot provide both username and account ID")
 	}
 
 	o.output = o.GlobalOptions.Output
 
+	if o.output == outputflag.NDJSONOutput && (o.username != "" || o.accountID != "") {
+		return outputflag.UsageErrorf(cmd, "'-o ndjson' can only be used when listing all accounts, without a username or account ID")
+	}
+
+	if o.since < 0 {
+		return outputflag.UsageErrorf(cmd, "--since cannot be negative")
+	}
+	if o.includeUnknownAge && o.since == 0 {
+		return outputflag.UsageErrorf(cmd, "--include-unknown-age requires --since")
+	}
+	if o.since != 0 && o.accountID != "" {
+		return outputflag.UsageErrorf(cmd, "--since cannot be used with an account ID")
+	}
+
+	if err := o.sort.validate(); err != nil {
+		return outputflag.UsageErrorf(cmd, err.Error())
+	}
+	if o.sort.Key != "" && (o.accountID != "" || o.output == outputflag.NDJSONOutput) {
+		return outputflag.UsageErrorf(cmd, "--sort cannot be used with an account ID or '-o ndjson'")
+	}
+
+	if o.template != "" {
+		if o.output != "" {
+			return outputflag.UsageErrorf(cmd, "Cannot provide both --go-template and --output")
+		}
+		// Parse the template up front so a bad template fails before any AWS calls are made
+		tmpl, err := template.New("account").Parse(o.template)
+		if err != nil {
+			return outputflag.UsageErrorf(cmd, "Invalid template: %v", err)
+		}
+		o.accountTemplate = tmpl
+	}
+
 	return nil
 }
 
 func (o *accountListOptions) run() error {
-
-	var (
-		OuID string
-	)
 	// Instantiate Aws client
-	awsClient, err := awsprovider.NewAwsClient(o.payerAccount, "us-east-1", "")
+	awsClient, err := newPayerAccountClient(o.payerAccount)
 	if err != nil {
 		return err
 	}
 
-	if o.payerAccount == "osd-staging-2" {
-		OuID = "ou-rs3h-ry0hn2l9"
-	} else if o.payerAccount == "osd-staging-1" {
-		OuID = "ou-0wd6-z6tzkjek"
-	}
-
 	o.awsClient = awsClient
 	if o.accountID != "" {
 		owner, err := o.listUserName(o.accountID)
 		if err != nil {
 			return err
 		}
+		if o.accountTemplate != nil {
+			return o.renderAccounts(o.Out, map[string][]string{owner: {o.accountID}})
+		}
+		if o.output == outputflag.CSVOutput {
+			return outputflag.PrintCSV(o.Out, claimedAccountList{{Username: owner, Id: o.accountID}})
+		}
 		fmt.Println(owner)
 		return nil
 	}
@@ -123,20 +224,34 @@ func (o *accountListOptions) run() error {
 	}
 
 	if o.username == "" && o.accountID == "" {
+		// Only listing every account needs the developers OU
+		ous, err := resolveOUs(o.payerAccount, o.ous)
+		if err != nil {
+			return err
+		}
 
-		o.m, err = o.listAllAccounts(OuID)
+		if o.output == outputflag.NDJSONOutput {
+			return o.streamAllAccounts(o.Out, ous.DestinationOU)
+		}
+
+		o.m, err = o.listAllAccounts(ous.DestinationOU)
 		if err != nil {
 			return err
 		}
 	}
 
-	for key, value := range o.m {
-		resp := listResponse{
-			Username: key,
-			Accounts: value,
-		}
+	o.m = o.filterBySince(o.m, time.Now())
 
-		err := outputflag.PrintResponse(o.output, resp)
+	if o.accountTemplate != nil {
+		return o.renderAccounts(o.Out, o.m)
+	}
+	// A single header row is written for every user
+	if o.output == outputflag.CSVOutput {
+		return outputflag.PrintCSV(o.Out, csvAccounts(o.listResponses(o.m)))
+	}
+
+	for _, resp := range o.listResponses(o.m) {
+		err := outputflag.PrintResponse(o.output, outputflag.NewEnvelope("AccountList", resp))
 		if err != nil {
 			fmt.Println("Error while printing response: ", err.Error())
 			return err
@@ -147,6 +262,101 @@ func (o *accountListOptions) run() error {
 	return nil
 }
 
+// renderAccounts executes the account template once per account, in the order of listResponses, writing each result
+// on its own line
+func (o *accountListOptions) renderAccounts(w io.Writer, m map[string][]string) error {
+	for _, resp := range o.listResponses(m) {
+		for _, id := range resp.Accounts {
+			err := o.accountTemplate.Execute(w, listedAccount{Username: resp.Username, Id: id})
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(w)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// listResponses returns the accounts of each user, sorted by username. With --sort the accounts are sorted instead,
+// and each user is listed in the position of their first account.
+func (o *accountListOptions) listResponses(m map[string][]string) []listResponse {
+	users := make([]string, 0, len(m))
+	for user := range m {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	responses := []listResponse{}
+	if o.sort.Key == "" {
+		for _, user := range users {
+			responses = append(responses, listResponse{Username: user, Accounts: m[user]})
+		}
+		return responses
+	}
+
+	keys := []sortKey{}
+	for _, user := range users {
+		for _, id := range m[user] {
+			keys = append(keys, sortKey{ID: id, Owner: user, ClaimedAt: o.claimedAt[id]})
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return o.sort.less(keys[i], keys[j])
+	})
+
+	positions := map[string]int{}
+	for _, key := range keys {
+		i, ok := positions[key.Owner]
+		if !ok {
+			i = len(responses)
+			positions[key.Owner] = i
+			responses = append(responses, listResponse{Username: key.Owner})
+		}
+		responses[i].Accounts = append(responses[i].Accounts, key.ID)
+	}
+	return responses
+}
+
+// claimedBefore returns true if the account with the given claimed-at tag is kept by --since at the given time
+func (o *accountListOptions) claimedBefore(claimedAt string, now time.Time) bool {
+	if o.since == 0 {
+		return true
+	}
+	t, err := time.Parse(time.RFC3339, claimedAt)
+	if err != nil {
+		return o.includeUnknownAge
+	}
+	return !t.After(now.Add(-o.since))
+}
+
+// filterBySince drops the accounts claimed less than --since before the given time, along with the users left
+// without accounts
+func (o *accountListOptions) filterBySince(m map[string][]string, now time.Time) map[string][]string {
+	if o.since == 0 {
+		return m
+	}
+	filtered := map[string][]string{}
+	for user, ids := range m {
+		for _, id := range ids {
+			if o.claimedBefore(o.claimedAt[id], now) {
+				filtered[user] = append(filtered[user], id)
+			}
+		}
+	}
+	return filtered
+}
+
+// recordClaimedAt keeps the claimed-at tag of the account to sort and filter it by
+func (o *accountListOptions) recordClaimedAt(accountID string, claimedAt string) {
+	if o.claimedAt == nil {
+		o.claimedAt = map[string]string{}
+	}
+	o.claimedAt[accountID] = claimedAt
+}
+
 var ErrNoOwnerTag error = fmt.Errorf("No owner tag on aws account")
 var ErrNoTagsOnAccount error = fmt.Errorf("No tags on aws account")
 
@@ -198,7 +408,13 @@ func (o *accountListOptions) listAccountsByUser(user string) ([]string, error) {
 	// Get last 12 digits of ResourceARN and append it to account list
 	tempAccountIDs := []string{}
 	for _, a := range accounts.ResourceTagMappingList {
-		tempAccountIDs = append(tempAccountIDs, (*a.ResourceARN)[len(*a.ResourceARN)-12:])
+		accountID := (*a.ResourceARN)[len(*a.ResourceARN)-12:]
+		tempAccountIDs = append(tempAccountIDs, accountID)
+		for _, t := range a.Tags {
+			if *t.Key == "claimed-at" {
+				o.recordClaimedAt(accountID, *t.Value)
+			}
+		}
 	}
 
 	return tempAccountIDs, nil
@@ -216,7 +432,7 @@ func (o *accountListOptions) listAllAccounts(OuIdInput string) (map[string][]str
 	}
 	accounts, err := o.awsClient.ListAccountsForParent(input)
 	if err != nil {
-		return m, err
+		return m, awsprovider.WithOrganizationsHint(err)
 	}
 
 	if len(accounts.Accounts) == 0 {
@@ -239,7 +455,9 @@ func (o *accountListOptions) listAllAccounts(OuIdInput string) (map[string][]str
 		for _, t := range tagVal.Tags {
 			if *t.Key == "owner" {
 				user = *t.Value
-				break
+			}
+			if *t.Key == "claimed-at" {
+				o.recordClaimedAt(*a.Id, *t.Value)
 			}
 		}
 		// If account has no owner, don't print
@@ -261,3 +479,43 @@ func (o *accountListOptions) listAllAccounts(OuIdInput string) (map[string][]str
 	}
 	return m, nil
 }
+
+// streamAllAccounts writes each owned account in the OU as a line of JSON as soon as its tags are read, following
+// every page of accounts instead of collecting them all before printing
+func (o *accountListOptions) streamAllAccounts(w io.Writer, ouID string) error {
+	// An empty parent would iterate over the whole organization
+	if ouID == "" {
+		return fmt.Errorf("invalid payer account provided")
+	}
+
+	listed, owned := 0, 0
+	now := time.Now()
+	err := awsprovider.ForEachAccount(o.awsClient, ouID, func(a *awsprovider.OrgAccount) error {
+		listed++
+		tags, err := a.Tags()
+		if err != nil {
+			return err
+		}
+		// If account has no owner, don't print
+		owner := tags["owner"]
+		if owner == "" {
+			return nil
+		}
+		owned++
+		if !o.claimedBefore(tags["claimed-at"], now) {
+			return nil
+		}
+		return outputflag.PrintNDJSON(w, outputflag.NewEnvelope("ClaimedAccount", claimedAccount{Username: owner, Id: *a.Id}))
+	})
+	if err != nil {
+		return err
+	}
+
+	if listed == 0 {
+		return ErrNoAccountsForParent
+	}
+	if owned == 0 {
+		return ErrAccountsWithNoOwner
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package mgmt
+
+import (
+	"fmt"
+	"strconv"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+type accountPoolStatusOptions struct {
+	awsClient    awsprovider.Client
+	payerAccount string
+	output       string
+	// ous overrides the OUs of the payer account
+	ous accountOUs
+
+	flags      *genericclioptions.ConfigFlags
+	printFlags *printer.PrintFlags
+	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// poolStatusResponse counts the accounts in the pool. Suspended accounts are only counted as suspended,
+// so claimed, unclaimed and suspended add up to the total.
+type poolStatusResponse struct {
+	Total           int `json:"total" yaml:"total"`
+	Claimed         int `json:"claimed" yaml:"claimed"`
+	UnclaimedActive int `json:"unclaimedActive" yaml:"unclaimedActive"`
+	Suspended       int `json:"suspended" yaml:"suspended"`
+}
+
+func (f poolStatusResponse) String() string {
+	return fmt.Sprintf("  Total: %d\n  Claimed: %d\n  Unclaimed (active): %d\n  Suspended: %d\n", f.Total, f.Claimed, f.UnclaimedActive, f.Suspended)
+}
+
+func (f poolStatusResponse) CSVHeader() []string {
+	return []string{"total", "claimed", "unclaimedActive", "suspended"}
+}
+
+// CSVRows returns the counts as a single row, as the pool status doesn't list the accounts themselves
+func (f poolStatusResponse) CSVRows() [][]string {
+	return [][]string{{strconv.Itoa(f.Total), strconv.Itoa(f.Claimed), strconv.Itoa(f.UnclaimedActive), strconv.Itoa(f.Suspended)}}
+}
+
+func newAccountPoolStatusOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *accountPoolStatusOptions {
+	return &accountPoolStatusOptions{
+		flags:         flags,
+		printFlags:    printer.NewPrintFlags(),
+		IOStreams:     streams,
+		GlobalOptions: globalOpts,
+	}
+}
+
+// newCmdAccountPoolStatus reports how many accounts in the pool are free and how many are claimed
+func newCmdAccountPoolStatus(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := newAccountPoolStatusOptions(streams, flags, globalOpts)
+	accountPoolStatusCmd := &cobra.Command{
+		Use:               "pool-status",
+		Short:             "Report how many accounts are free and how many are claimed",
+		Long:              "Report how many accounts are free and how many are claimed. Besides the global output formats, '-o csv' prints\nthe counts as a single row.",
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			outputflag.CheckErr(globalOpts.Output, ops.complete(cmd, args))
+			outputflag.CheckErr(globalOpts.Output, ops.run())
+		},
+	}
+	ops.printFlags.AddFlags(accountPoolStatusCmd)
+	accountPoolStatusCmd.Flags().StringVarP(&ops.payerAccount, "payer-account", "p", "", "Payer account type")
+	addOUFlags(accountPoolStatusCmd, &ops.ous)
+
+	return accountPoolStatusCmd
+}
+
+func (o *accountPoolStatusOptions) complete(cmd *cobra.Command, _ []string) error {
+	if o.payerAccount == "" {
+		return outputflag.UsageErrorf(cmd, "Payer account was not provided")
+	}
+
+	o.output = o.GlobalOptions.Output
+
+	return nil
+}
+
+func (o *accountPoolStatusOptions) run() error {
+	ous, err := resolveOUs(o.payerAccount, o.ous)
+	if err != nil {
+		return err
+	}
+
+	awsClient, err := newPayerAccountClient(o.payerAccount)
+	if err != nil {
+		return err
+	}
+	o.awsClient = awsClient
+
+	// Free accounts wait in the pool, and assigned accounts are moved to the developers OU
+	resp, err := o.poolStatus([]string{ous.PoolOU, ous.DestinationOU})
+	if err != nil {
+		return err
+	}
+
+	return outputflag.PrintResponse(o.output, outputflag.NewEnvelope("AccountPoolStatus", resp))
+}
+
+// poolStatus counts the accounts directly under the given parents
+func (o *accountPoolStatusOptions) poolStatus(parentIDs []string) (poolStatusResponse, error) {
+	status := poolStatusResponse{}
+
+	for _, parentID := range parentIDs {
+		err := awsprovider.ForEachAccount(o.awsClient, parentID, func(a *awsprovider.OrgAccount) error {
+			status.Total++
+
+			suspended, err := isSuspended(*a.Id, o.awsClient)
+			if err != nil {
+				return err
+			}
+			if suspended {
+				status.Suspended++
+				return nil
+			}
+
+			owned, err := isOwned(*a.Id, &o.awsClient)
+			if err != nil {
+				return err
+			}
+			if owned {
+				status.Claimed++
+			} else {
+				status.UnclaimedActive++
+			}
+			return nil
+		})
+		if err != nil {
+			return poolStatusResponse{}, err
+		}
+	}
+
+	return status, nil
+}
@@ -0,0 +1,109 @@
+package mgmt
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestPoolStatus(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	rootID := "r-abcd"
+	ouID := "ou-abcd-vnjfdshs"
+
+	accounts := []struct {
+		id       string
+		parentID string
+		status   string
+		tags     []*organizations.Tag
+	}{
+		{
+			id:       "111111111111",
+			parentID: rootID,
+			status:   organizations.AccountStatusActive,
+			tags:     []*organizations.Tag{},
+		},
+		{
+			id:       "222222222222",
+			parentID: rootID,
+			status:   organizations.AccountStatusActive,
+			tags:     []*organizations.Tag{},
+		},
+		{
+			id:       "333333333333",
+			parentID: rootID,
+			status:   organizations.AccountStatusSuspended,
+		},
+		{
+			id:       "444444444444",
+			parentID: ouID,
+			status:   organizations.AccountStatusActive,
+			tags: []*organizations.Tag{
+				{
+					Key:   aws.String("owner"),
+					Value: aws.String("tuser"),
+				},
+				{
+					Key:   aws.String("claimed"),
+					Value: aws.String("true"),
+				},
+			},
+		},
+	}
+
+	accountsByParent := map[string][]*organizations.Account{}
+	for _, a := range accounts {
+		accountsByParent[a.parentID] = append(accountsByParent[a.parentID], &organizations.Account{Id: aws.String(a.id)})
+
+		mockAWSClient.EXPECT().DescribeAccount(
+			&organizations.DescribeAccountInput{
+				AccountId: aws.String(a.id),
+			},
+		).Return(&organizations.DescribeAccountOutput{
+			Account: &organizations.Account{
+				Id:     aws.String(a.id),
+				Status: aws.String(a.status),
+			},
+		}, nil)
+
+		// Tags are only read for active accounts
+		if a.status == organizations.AccountStatusActive {
+			mockAWSClient.EXPECT().ListTagsForResource(
+				&organizations.ListTagsForResourceInput{
+					ResourceId: aws.String(a.id),
+				},
+			).Return(&organizations.ListTagsForResourceOutput{Tags: a.tags}, nil)
+		}
+	}
+	for parentID, parentAccounts := range accountsByParent {
+		mockAWSClient.EXPECT().ListAccountsForParent(
+			&organizations.ListAccountsForParentInput{
+				ParentId: aws.String(parentID),
+			},
+		).Return(&organizations.ListAccountsForParentOutput{Accounts: parentAccounts}, nil)
+	}
+
+	o := &accountPoolStatusOptions{}
+	o.awsClient = mockAWSClient
+
+	status, err := o.poolStatus([]string{rootID, ouID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := poolStatusResponse{
+		Total:           4,
+		Claimed:         1,
+		UnclaimedActive: 2,
+		Suspended:       1,
+	}
+	if status != expected {
+		t.Errorf("expected %+v is %+v", expected, status)
+	}
+}
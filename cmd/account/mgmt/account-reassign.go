@@ -0,0 +1,163 @@
+package mgmt
+
+import (
+	"fmt"
+	"time"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+type accountReassignOptions struct {
+	awsClient    awsprovider.Client
+	accountID    string
+	toOwner      string
+	payerAccount string
+	output       string
+	dryRun       bool
+
+	flags      *genericclioptions.ConfigFlags
+	printFlags *printer.PrintFlags
+	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+type reassignResponse struct {
+	Id            string `json:"id" yaml:"id"`
+	PreviousOwner string `json:"previousOwner" yaml:"previousOwner"`
+	Owner         string `json:"owner" yaml:"owner"`
+	ReassignedAt  string `json:"reassignedAt" yaml:"reassignedAt"`
+}
+
+func (f reassignResponse) String() string {
+	return fmt.Sprintf("  Account: %s\n  Previous Owner: %s\n  Owner: %s\n", f.Id, f.PreviousOwner, f.Owner)
+}
+
+func newAccountReassignOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *accountReassignOptions {
+	return &accountReassignOptions{
+		flags:         flags,
+		printFlags:    printer.NewPrintFlags(),
+		IOStreams:     streams,
+		GlobalOptions: globalOpts,
+	}
+}
+
+// newCmdAccountReassign hands an assigned account over to another user without releasing it
+func newCmdAccountReassign(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := newAccountReassignOptions(streams, flags, globalOpts)
+	accountReassignCmd := &cobra.Command{
+		Use:               "reassign <account id>",
+		Short:             "Transfer an assigned account to another user",
+		Long:              "Transfer an assigned account to another user by updating its owner tag in place. The account stays claimed\nand keeps its claimed-at tag, the time of the transfer is recorded in the reassigned-at tag.",
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			outputflag.CheckErr(globalOpts.Output, ops.complete(cmd, args))
+			outputflag.CheckErr(globalOpts.Output, ops.run())
+		},
+	}
+	ops.printFlags.AddFlags(accountReassignCmd)
+	accountReassignCmd.Flags().StringVarP(&ops.payerAccount, "payer-account", "p", "", "Payer account type")
+	accountReassignCmd.Flags().StringVar(&ops.toOwner, "to-owner", "", "LDAP username of the user taking over the account")
+	accountReassignCmd.Flags().BoolVar(&ops.dryRun, "dry-run", false, "Print how the account's tags would change without reassigning it")
+
+	return accountReassignCmd
+}
+
+func (o *accountReassignOptions) complete(cmd *cobra.Command, args []string) error {
+	if o.payerAccount == "" {
+		return outputflag.UsageErrorf(cmd, "Payer account was not provided")
+	}
+	if o.toOwner == "" {
+		return outputflag.UsageErrorf(cmd, "The new owner was not provided with --to-owner")
+	}
+	o.accountID = args[0]
+
+	o.output = o.GlobalOptions.Output
+
+	return nil
+}
+
+func (o *accountReassignOptions) run() error {
+	awsClient, err := newPayerAccountClient(o.payerAccount)
+	if err != nil {
+		return err
+	}
+	o.awsClient = awsClient
+
+	if o.dryRun {
+		return o.previewReassignment(time.Now().UTC())
+	}
+
+	resp, err := o.reassignAccount(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	return outputflag.PrintResponse(o.output, outputflag.NewEnvelope("AccountReassignment", resp))
+}
+
+var ErrAccountNotOwned = fmt.Errorf("the account is not assigned to anyone, use the 'assign' command to assign it")
+var ErrAccountAlreadyOwnedByUser = fmt.Errorf("the account is already assigned to the new owner")
+
+// reassignAccount moves the account's owner tag to the new owner, recording the given time in the reassigned-at tag
+func (o *accountReassignOptions) reassignAccount(now time.Time) (reassignResponse, error) {
+	current, tags, err := o.reassignmentTags(now)
+	if err != nil {
+		return reassignResponse{}, err
+	}
+
+	err = tagResource(o.awsClient, o.accountID, tags)
+	if err != nil {
+		return reassignResponse{}, err
+	}
+
+	return reassignResponse{
+		Id:            o.accountID,
+		PreviousOwner: current["owner"],
+		Owner:         o.toOwner,
+		ReassignedAt:  tags["reassigned-at"],
+	}, nil
+}
+
+// previewReassignment prints how the account's tags would change if it was reassigned at the given time, without
+// reassigning it
+func (o *accountReassignOptions) previewReassignment(now time.Time) error {
+	current, tags, err := o.reassignmentTags(now)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Dry run: account %s would be reassigned from %s to %s with the tag changes:\n", o.accountID, current["owner"], o.toOwner)
+	fmt.Print(diffTags(current, tags, nil))
+	return nil
+}
+
+// reassignmentTags returns the account's current tags and the tags that reassigning it at the given time would set.
+// The account must currently be claimed by someone else.
+func (o *accountReassignOptions) reassignmentTags(now time.Time) (map[string]string, map[string]string, error) {
+	current, err := listAccountTags(o.accountID, o.awsClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	previousOwner := current["owner"]
+	if current["claimed"] != "true" || previousOwner == "" {
+		return nil, nil, ErrAccountNotOwned
+	}
+	if previousOwner == o.toOwner {
+		return nil, nil, ErrAccountAlreadyOwnedByUser
+	}
+
+	return current, map[string]string{
+		"owner":         o.toOwner,
+		"reassigned-at": now.Format(time.RFC3339),
+		claimHistoryTagKey: appendClaimHistory(current[claimHistoryTagKey], claimHistoryEntry{
+			Action: claimHistoryReassign,
+			Owner:  o.toOwner,
+			Time:   now,
+		}),
+	}, nil
+}
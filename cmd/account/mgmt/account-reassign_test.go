@@ -0,0 +1,94 @@
+package mgmt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestReassignAccount(t *testing.T) {
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	testData := []struct {
+		name         string
+		tags         []*organizations.Tag
+		expectTag    bool
+		expectedResp reassignResponse
+		expectErr    error
+	}{
+		{
+			name: "test for owned account",
+			tags: []*organizations.Tag{
+				{Key: aws.String("owner"), Value: aws.String("olduser")},
+				{Key: aws.String("claimed"), Value: aws.String("true")},
+				{Key: aws.String("claimed-at"), Value: aws.String("2022-05-01T10:00:00Z")},
+				{Key: aws.String("claim-history"), Value: aws.String("assign:olduser:1651399200")},
+			},
+			expectTag: true,
+			expectedResp: reassignResponse{
+				Id:            "111111111111",
+				PreviousOwner: "olduser",
+				Owner:         "newuser",
+				ReassignedAt:  "2022-06-01T10:00:00Z",
+			},
+		},
+		{
+			name:      "test for unowned account",
+			tags:      []*organizations.Tag{},
+			expectErr: ErrAccountNotOwned,
+		},
+		{
+			name: "test for account already owned by the new owner",
+			tags: []*organizations.Tag{
+				{Key: aws.String("owner"), Value: aws.String("newuser")},
+				{Key: aws.String("claimed"), Value: aws.String("true")},
+			},
+			expectErr: ErrAccountAlreadyOwnedByUser,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+			accountID := "111111111111"
+
+			mockAWSClient.EXPECT().ListTagsForResource(
+				&organizations.ListTagsForResourceInput{
+					ResourceId: aws.String(accountID),
+				},
+			).Return(&organizations.ListTagsForResourceOutput{Tags: test.tags}, nil)
+
+			// Only the owner, reassigned-at and claim history tags are set, claimed-at is left as it was
+			if test.expectTag {
+				mockAWSClient.EXPECT().TagResource(&organizations.TagResourceInput{
+					ResourceId: aws.String(accountID),
+					Tags: []*organizations.Tag{
+						{Key: aws.String("claim-history"), Value: aws.String("assign:olduser:1651399200 reassign:newuser:1654077600")},
+						{Key: aws.String("owner"), Value: aws.String("newuser")},
+						{Key: aws.String("reassigned-at"), Value: aws.String("2022-06-01T10:00:00Z")},
+					},
+				}).Return(&organizations.TagResourceOutput{}, nil)
+			}
+
+			o := &accountReassignOptions{}
+			o.awsClient = mockAWSClient
+			o.accountID = accountID
+			o.toOwner = "newuser"
+
+			resp, err := o.reassignAccount(now)
+			if err != test.expectErr {
+				t.Errorf("expected error %v and got %v", test.expectErr, err)
+			}
+			if !reflect.DeepEqual(resp, test.expectedResp) {
+				t.Errorf("expected %v is %v", test.expectedResp, resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package mgmt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+type accountSearchOptions struct {
+	awsClient    awsprovider.Client
+	payerAccount string
+	output       string
+	tags         []string
+
+	// filters are the parsed --tag filters, all of which an account must match
+	filters map[string]string
+	sort    accountSort
+
+	flags      *genericclioptions.ConfigFlags
+	printFlags *printer.PrintFlags
+	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+type searchedAccount struct {
+	Id    string            `json:"id" yaml:"id"`
+	Email string            `json:"email" yaml:"email"`
+	Tags  map[string]string `json:"matchedTags" yaml:"matchedTags"`
+
+	// owner and claimedAt are only used to sort the accounts
+	owner     string
+	claimedAt string
+}
+
+type searchResponse struct {
+	Accounts []searchedAccount `json:"accounts" yaml:"accounts"`
+}
+
+func (f searchResponse) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-14s %-45s %s\n", "ID", "EMAIL", "MATCHED TAGS"))
+	for _, a := range f.Accounts {
+		sb.WriteString(fmt.Sprintf("%-14s %-45s %s\n", a.Id, a.Email, a.matchedTagPairs()))
+	}
+	return sb.String()
+}
+
+func (f searchResponse) CSVHeader() []string {
+	return []string{"id", "email", "matchedTags"}
+}
+
+func (f searchResponse) CSVRows() [][]string {
+	rows := make([][]string, 0, len(f.Accounts))
+	for _, a := range f.Accounts {
+		rows = append(rows, []string{a.Id, a.Email, a.matchedTagPairs()})
+	}
+	return rows
+}
+
+// matchedTagPairs returns the matched tags as comma separated key=value pairs, sorted by key
+func (a searchedAccount) matchedTagPairs() string {
+	keys := make([]string, 0, len(a.Tags))
+	for k := range a.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, a.Tags[k]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func newAccountSearchOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *accountSearchOptions {
+	return &accountSearchOptions{
+		flags:         flags,
+		printFlags:    printer.NewPrintFlags(),
+		IOStreams:     streams,
+		GlobalOptions: globalOpts,
+	}
+}
+
+// newCmdAccountSearch searches the organization for accounts matching all the given tags
+func newCmdAccountSearch(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := newAccountSearchOptions(streams, flags, globalOpts)
+	accountSearchCmd := &cobra.Command{
+		Use:               "search",
+		Short:             "Search the organization for accounts with the given tags",
+		Long:              "Search the organization for accounts with the given tags. Besides the global output formats, '-o csv' prints\na row per matching account.",
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			outputflag.CheckErr(globalOpts.Output, ops.complete(cmd, args))
+			outputflag.CheckErr(globalOpts.Output, ops.run())
+		},
+	}
+	ops.printFlags.AddFlags(accountSearchCmd)
+	accountSearchCmd.Flags().StringVarP(&ops.payerAccount, "payer-account", "p", "", "Payer account type")
+	accountSearchCmd.Flags().StringArrayVar(&ops.tags, "tag", []string{}, "key=value tag the accounts must have, can be repeated")
+	addSortFlags(accountSearchCmd, &ops.sort)
+
+	return accountSearchCmd
+}
+
+func (o *accountSearchOptions) complete(cmd *cobra.Command, _ []string) error {
+	if o.payerAccount == "" {
+		return outputflag.UsageErrorf(cmd, "Payer account was not provided")
+	}
+	if len(o.tags) == 0 {
+		return outputflag.UsageErrorf(cmd, "At least one --tag was expected")
+	}
+
+	filters, err := parseKeyValues(o.tags)
+	if err != nil {
+		return outputflag.UsageErrorf(cmd, err.Error())
+	}
+	o.filters = filters
+
+	if err := o.sort.validate(); err != nil {
+		return outputflag.UsageErrorf(cmd, err.Error())
+	}
+
+	o.output = o.GlobalOptions.Output
+
+	return nil
+}
+
+func (o *accountSearchOptions) run() error {
+	awsClient, err := newPayerAccountClient(o.payerAccount)
+	if err != nil {
+		return err
+	}
+	o.awsClient = awsClient
+
+	accounts, err := o.searchAccounts()
+	if err != nil {
+		return err
+	}
+	o.sortAccounts(accounts)
+
+	return outputflag.PrintResponse(o.output, outputflag.NewEnvelope("AccountSearch", searchResponse{Accounts: accounts}))
+}
+
+// searchAccounts returns every account in the organization whose tags match all the filters
+func (o *accountSearchOptions) searchAccounts() ([]searchedAccount, error) {
+	matched := []searchedAccount{}
+
+	err := awsprovider.ForEachAccount(o.awsClient, "", func(a *awsprovider.OrgAccount) error {
+		tags, err := a.Tags()
+		if err != nil {
+			return err
+		}
+		if !matchesTags(tags, o.filters) {
+			return nil
+		}
+
+		var email string
+		if a.Email != nil {
+			email = *a.Email
+		}
+		matchedTags := map[string]string{}
+		for k := range o.filters {
+			matchedTags[k] = tags[k]
+		}
+		matched = append(matched, searchedAccount{
+			Id:        *a.Id,
+			Email:     email,
+			Tags:      matchedTags,
+			owner:     tags["owner"],
+			claimedAt: tags["claimed-at"],
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matched, nil
+}
+
+// sortAccounts orders the accounts as set with --sort, leaving them in the order they were found otherwise
+func (o *accountSearchOptions) sortAccounts(accounts []searchedAccount) {
+	if o.sort.Key == "" {
+		return
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return o.sort.less(accounts[i].sortKey(), accounts[j].sortKey())
+	})
+}
+
+func (a searchedAccount) sortKey() sortKey {
+	return sortKey{ID: a.Id, Owner: a.owner, ClaimedAt: a.claimedAt}
+}
+
+// matchesTags returns true if the tags contain every filter
+func matchesTags(tags map[string]string, filters map[string]string) bool {
+	for k, v := range filters {
+		if value, ok := tags[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package mgmt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/golang/mock/gomock"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestSearchAccounts(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	accountTags := map[string]map[string]string{
+		// matches both filters
+		"111111111111": {"owner": "tuser", "cost-center": "1234", "team": "srep"},
+		// only matches one filter
+		"222222222222": {"owner": "tuser", "cost-center": "5678"},
+		// matches neither filter
+		"333333333333": {},
+		// matches both filters, listed on the second page
+		"444444444444": {"owner": "tuser", "cost-center": "1234"},
+	}
+
+	gomock.InOrder(
+		mockAWSClient.EXPECT().ListAccounts(&organizations.ListAccountsInput{}).Return(
+			&organizations.ListAccountsOutput{
+				Accounts: []*organizations.Account{
+					{Id: aws.String("111111111111"), Email: aws.String("osd-creds-mgmt+aaaaaa@redhat.com")},
+					{Id: aws.String("222222222222"), Email: aws.String("osd-creds-mgmt+bbbbbb@redhat.com")},
+					{Id: aws.String("333333333333"), Email: aws.String("osd-creds-mgmt+cccccc@redhat.com")},
+				},
+				NextToken: aws.String("page-2"),
+			}, nil),
+		mockAWSClient.EXPECT().ListAccounts(&organizations.ListAccountsInput{NextToken: aws.String("page-2")}).Return(
+			&organizations.ListAccountsOutput{
+				Accounts: []*organizations.Account{
+					{Id: aws.String("444444444444"), Email: aws.String("osd-creds-mgmt+dddddd@redhat.com")},
+				},
+			}, nil),
+	)
+	for id, tags := range accountTags {
+		awsTags := []*organizations.Tag{}
+		for k, v := range tags {
+			awsTags = append(awsTags, &organizations.Tag{Key: aws.String(k), Value: aws.String(v)})
+		}
+		mockAWSClient.EXPECT().ListTagsForResource(
+			&organizations.ListTagsForResourceInput{
+				ResourceId: aws.String(id),
+			},
+		).Return(&organizations.ListTagsForResourceOutput{Tags: awsTags}, nil)
+	}
+
+	o := &accountSearchOptions{}
+	o.awsClient = mockAWSClient
+	o.filters = map[string]string{"owner": "tuser", "cost-center": "1234"}
+
+	accounts, err := o.searchAccounts()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []searchedAccount{
+		{
+			Id:    "111111111111",
+			Email: "osd-creds-mgmt+aaaaaa@redhat.com",
+			Tags:  map[string]string{"owner": "tuser", "cost-center": "1234"},
+			owner: "tuser",
+		},
+		{
+			Id:    "444444444444",
+			Email: "osd-creds-mgmt+dddddd@redhat.com",
+			Tags:  map[string]string{"owner": "tuser", "cost-center": "1234"},
+			owner: "tuser",
+		},
+	}
+	if !reflect.DeepEqual(accounts, expected) {
+		t.Errorf("expected %v is %v", expected, accounts)
+	}
+}
+
+func TestSearchAccountsSort(t *testing.T) {
+	accounts := func() []searchedAccount {
+		return []searchedAccount{
+			{Id: "333333333333", owner: "auser", claimedAt: "2022-06-01T10:00:00Z"},
+			{Id: "111111111111", owner: "cuser", claimedAt: "2022-05-01T10:00:00Z"},
+			{Id: "444444444444"},
+			{Id: "222222222222", owner: "auser", claimedAt: "2022-07-01T12:00:00+02:00"},
+		}
+	}
+	ids := func(accounts []searchedAccount) []string {
+		ids := []string{}
+		for _, a := range accounts {
+			ids = append(ids, a.Id)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name     string
+		sort     accountSort
+		expected []string
+	}{
+		{
+			name:     "unsorted",
+			sort:     accountSort{},
+			expected: []string{"333333333333", "111111111111", "444444444444", "222222222222"},
+		},
+		{
+			name:     "by id",
+			sort:     accountSort{Key: sortByID},
+			expected: []string{"111111111111", "222222222222", "333333333333", "444444444444"},
+		},
+		{
+			name:     "by owner, ties broken by id",
+			sort:     accountSort{Key: sortByOwner},
+			expected: []string{"444444444444", "222222222222", "333333333333", "111111111111"},
+		},
+		{
+			name:     "by owner reversed",
+			sort:     accountSort{Key: sortByOwner, Reverse: true},
+			expected: []string{"111111111111", "333333333333", "222222222222", "444444444444"},
+		},
+		{
+			name:     "by claimed-at, unclaimed last",
+			sort:     accountSort{Key: sortByClaimedAt},
+			expected: []string{"111111111111", "333333333333", "222222222222", "444444444444"},
+		},
+		{
+			name:     "by claimed-at reversed, unclaimed still last",
+			sort:     accountSort{Key: sortByClaimedAt, Reverse: true},
+			expected: []string{"222222222222", "333333333333", "111111111111", "444444444444"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := &accountSearchOptions{sort: test.sort}
+			sorted := accounts()
+			o.sortAccounts(sorted)
+			if !reflect.DeepEqual(ids(sorted), test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, ids(sorted))
+			}
+		})
+	}
+}
+
+func TestSearchResponseCSV(t *testing.T) {
+	resp := searchResponse{Accounts: []searchedAccount{
+		{
+			Id:    "111111111111",
+			Email: "osd-creds-mgmt+aaaaaa@redhat.com",
+			Tags:  map[string]string{"owner": "tuser"},
+		},
+		{
+			Id:    "222222222222",
+			Email: "osd-creds-mgmt+bbbbbb@redhat.com",
+			Tags:  map[string]string{"owner": "tuser", "cost-center": "1234"},
+		},
+	}}
+
+	var out bytes.Buffer
+	err := outputflag.PrintCSV(&out, outputflag.NewEnvelope("AccountSearch", resp))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Several matched tags are joined with commas, so the field is quoted
+	expected := "id,email,matchedTags\n" +
+		"111111111111,osd-creds-mgmt+aaaaaa@redhat.com,owner=tuser\n" +
+		"222222222222,osd-creds-mgmt+bbbbbb@redhat.com,\"cost-center=1234,owner=tuser\"\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,169 @@
+package mgmt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// claimHistoryTagKey is the tag recording who claimed and released an account, and when
+const claimHistoryTagKey = "claim-history"
+
+// maxClaimHistoryLength is the AWS limit on tag value lengths, the oldest entries are dropped to stay within it
+const maxClaimHistoryLength = 256
+
+const (
+	claimHistoryAssign   = "assign"
+	claimHistoryRelease  = "release"
+	claimHistoryReassign = "reassign"
+)
+
+var ErrInvalidClaimHistory = fmt.Errorf("invalid claim history entry")
+
+// claimHistoryEntry is a single change of an account's owner. It is stored in the claim-history tag as
+// 'action:owner:unix time', entries are separated by spaces as tag values can't contain other separators.
+type claimHistoryEntry struct {
+	Action string    `json:"action" yaml:"action"`
+	Owner  string    `json:"owner" yaml:"owner"`
+	Time   time.Time `json:"time" yaml:"time"`
+}
+
+func (e claimHistoryEntry) encode() string {
+	return fmt.Sprintf("%s:%s:%d", e.Action, e.Owner, e.Time.Unix())
+}
+
+// appendClaimHistory returns the given claim history with the entry appended, dropping the oldest entries until it
+// fits in a tag value
+func appendClaimHistory(history string, entry claimHistoryEntry) string {
+	entries := append(strings.Fields(history), entry.encode())
+	for len(entries) > 1 && len(strings.Join(entries, " ")) > maxClaimHistoryLength {
+		entries = entries[1:]
+	}
+	return strings.Join(entries, " ")
+}
+
+// parseClaimHistory parses the entries of a claim-history tag, oldest first
+func parseClaimHistory(history string) ([]claimHistoryEntry, error) {
+	entries := []claimHistoryEntry{}
+	for _, field := range strings.Fields(history) {
+		first, last := strings.Index(field, ":"), strings.LastIndex(field, ":")
+		if first <= 0 || last == first {
+			return nil, fmt.Errorf("%w '%s'", ErrInvalidClaimHistory, field)
+		}
+		seconds, err := strconv.ParseInt(field[last+1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w '%s'", ErrInvalidClaimHistory, field)
+		}
+		entries = append(entries, claimHistoryEntry{
+			Action: field[:first],
+			Owner:  field[first+1 : last],
+			Time:   time.Unix(seconds, 0).UTC(),
+		})
+	}
+	return entries, nil
+}
+
+type accountTagHistoryOptions struct {
+	awsClient    awsprovider.Client
+	accountID    string
+	payerAccount string
+	output       string
+
+	flags      *genericclioptions.ConfigFlags
+	printFlags *printer.PrintFlags
+	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+type tagHistoryResponse struct {
+	Id      string              `json:"id" yaml:"id"`
+	History []claimHistoryEntry `json:"history" yaml:"history"`
+}
+
+func (f tagHistoryResponse) String() string {
+	if len(f.History) == 0 {
+		return fmt.Sprintf("No claim history recorded for account %s\n", f.Id)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-22s %-10s %s\n", "TIME", "ACTION", "OWNER"))
+	for _, e := range f.History {
+		sb.WriteString(fmt.Sprintf("%-22s %-10s %s\n", e.Time.Format(time.RFC3339), e.Action, e.Owner))
+	}
+	return sb.String()
+}
+
+func newAccountTagHistoryOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *accountTagHistoryOptions {
+	return &accountTagHistoryOptions{
+		flags:         flags,
+		printFlags:    printer.NewPrintFlags(),
+		IOStreams:     streams,
+		GlobalOptions: globalOpts,
+	}
+}
+
+// newCmdAccountTagHistory prints who claimed and released an account, as recorded in its claim-history tag
+func newCmdAccountTagHistory(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := newAccountTagHistoryOptions(streams, flags, globalOpts)
+	accountTagHistoryCmd := &cobra.Command{
+		Use:               "tag-history <account id>",
+		Short:             "Print the claim history of an account",
+		Long:              "Print who the account was assigned to, reassigned to and released by, oldest first. The history is kept in\nthe account's claim-history tag by the assign, reassign and unassign commands, so only the most recent\nentries fitting in a tag value are kept.",
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			outputflag.CheckErr(globalOpts.Output, ops.complete(cmd, args))
+			outputflag.CheckErr(globalOpts.Output, ops.run())
+		},
+	}
+	ops.printFlags.AddFlags(accountTagHistoryCmd)
+	accountTagHistoryCmd.Flags().StringVarP(&ops.payerAccount, "payer-account", "p", "", "Payer account type")
+
+	return accountTagHistoryCmd
+}
+
+func (o *accountTagHistoryOptions) complete(cmd *cobra.Command, args []string) error {
+	if o.payerAccount == "" {
+		return outputflag.UsageErrorf(cmd, "Payer account was not provided")
+	}
+	o.accountID = args[0]
+
+	o.output = o.GlobalOptions.Output
+
+	return nil
+}
+
+func (o *accountTagHistoryOptions) run() error {
+	awsClient, err := newPayerAccountClient(o.payerAccount)
+	if err != nil {
+		return err
+	}
+	o.awsClient = awsClient
+
+	resp, err := o.tagHistory()
+	if err != nil {
+		return err
+	}
+
+	return outputflag.PrintResponse(o.output, outputflag.NewEnvelope("AccountClaimHistory", resp))
+}
+
+// tagHistory reads and parses the account's claim-history tag
+func (o *accountTagHistoryOptions) tagHistory() (tagHistoryResponse, error) {
+	tags, err := listAccountTags(o.accountID, o.awsClient)
+	if err != nil {
+		return tagHistoryResponse{}, err
+	}
+	history, err := parseClaimHistory(tags[claimHistoryTagKey])
+	if err != nil {
+		return tagHistoryResponse{}, err
+	}
+	return tagHistoryResponse{Id: o.accountID, History: history}, nil
+}
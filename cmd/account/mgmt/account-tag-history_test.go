@@ -0,0 +1,138 @@
+package mgmt
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestAppendClaimHistory(t *testing.T) {
+	assignedAt := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	releasedAt := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	history := appendClaimHistory("", claimHistoryEntry{Action: claimHistoryAssign, Owner: "tuser", Time: assignedAt})
+	if history != "assign:tuser:1651399200" {
+		t.Errorf("expected the first entry alone, got %q", history)
+	}
+
+	history = appendClaimHistory(history, claimHistoryEntry{Action: claimHistoryRelease, Owner: "tuser", Time: releasedAt})
+	if history != "assign:tuser:1651399200 release:tuser:1654077600" {
+		t.Errorf("expected the release to be appended, got %q", history)
+	}
+
+	// Once full, the oldest entries are dropped to make room
+	for i := 0; i < 20; i++ {
+		history = appendClaimHistory(history, claimHistoryEntry{Action: claimHistoryAssign, Owner: "anotheruser", Time: releasedAt})
+	}
+	if len(history) > maxClaimHistoryLength {
+		t.Errorf("expected the history to fit in a tag value, got %d characters", len(history))
+	}
+	if strings.Contains(history, "tuser:") {
+		t.Errorf("expected the oldest entries to be dropped, got %q", history)
+	}
+	if !strings.HasSuffix(history, "assign:anotheruser:1654077600") {
+		t.Errorf("expected the latest entry to be kept, got %q", history)
+	}
+}
+
+func TestParseClaimHistory(t *testing.T) {
+	testData := []struct {
+		name            string
+		history         string
+		expectedEntries []claimHistoryEntry
+		expectErr       bool
+	}{
+		{
+			name:            "test for empty history",
+			history:         "",
+			expectedEntries: []claimHistoryEntry{},
+		},
+		{
+			name:    "test for several entries",
+			history: "assign:tuser:1651399200 reassign:other.user:1654077600",
+			expectedEntries: []claimHistoryEntry{
+				{Action: "assign", Owner: "tuser", Time: time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)},
+				{Action: "reassign", Owner: "other.user", Time: time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:    "test for a release without a recorded owner",
+			history: "release::1654077600",
+			expectedEntries: []claimHistoryEntry{
+				{Action: "release", Owner: "", Time: time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:      "test for entry without a time",
+			history:   "assign:tuser",
+			expectErr: true,
+		},
+		{
+			name:      "test for entry with an invalid time",
+			history:   "assign:tuser:yesterday",
+			expectErr: true,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := parseClaimHistory(test.history)
+			if test.expectErr {
+				if !errors.Is(err, ErrInvalidClaimHistory) {
+					t.Errorf("expected error %v and got %v", ErrInvalidClaimHistory, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(entries, test.expectedEntries) {
+				t.Errorf("expected %v is %v", test.expectedEntries, entries)
+			}
+		})
+	}
+}
+
+func TestTagHistory(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+	accountID := "111111111111"
+
+	mockAWSClient.EXPECT().ListTagsForResource(
+		&organizations.ListTagsForResourceInput{
+			ResourceId: aws.String(accountID),
+		},
+	).Return(&organizations.ListTagsForResourceOutput{Tags: []*organizations.Tag{
+		{Key: aws.String("owner"), Value: aws.String("tuser")},
+		{Key: aws.String("claim-history"), Value: aws.String("assign:tuser:1651399200")},
+	}}, nil)
+
+	o := &accountTagHistoryOptions{}
+	o.awsClient = mockAWSClient
+	o.accountID = accountID
+
+	resp, err := o.tagHistory()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := tagHistoryResponse{
+		Id: accountID,
+		History: []claimHistoryEntry{
+			{Action: "assign", Owner: "tuser", Time: time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)},
+		},
+	}
+	if !reflect.DeepEqual(resp, expected) {
+		t.Errorf("expected %v is %v", expected, resp)
+	}
+	if !strings.Contains(resp.String(), "2022-05-01T10:00:00Z   assign     tuser") {
+		t.Errorf("expected the entry to be printed, got %q", resp.String())
+	}
+}
@@ -3,36 +3,45 @@ package mgmt
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go/service/sts"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
 	"github.com/openshift/osdctl/pkg/printer"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
-func newCmdAccountUnassign(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags) *cobra.Command {
-	ops := newAccountUnassignOptions(streams, flags)
+func newCmdAccountUnassign(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := newAccountUnassignOptions(streams, flags, globalOpts)
 	accountUnassignCmd := &cobra.Command{
 		Use:               "unassign",
 		Short:             "Unassign account to user",
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			outputflag.CheckErr(globalOpts.Output, ops.complete(cmd, args))
+			err := ops.run()
+			ops.metrics.push()
+			outputflag.CheckErr(globalOpts.Output, err)
 		},
 	}
 	ops.printFlags.AddFlags(accountUnassignCmd)
 	accountUnassignCmd.Flags().StringVarP(&ops.payerAccount, "payer-account", "p", "", "Payer account type")
 	accountUnassignCmd.Flags().StringVarP(&ops.username, "username", "u", "", "LDAP username")
 	accountUnassignCmd.Flags().StringVarP(&ops.accountID, "account-id", "i", "", "Account ID")
+	accountUnassignCmd.Flags().BoolVar(&ops.all, "all", false, "Release every account in the developers OU owned by --username, continuing past failures and printing a summary")
+	addOUFlags(accountUnassignCmd, &ops.ous)
+	accountUnassignCmd.Flags().StringVar(&ops.metricsGateway, "metrics-push-gateway", "", "(optional) URL of a Prometheus push gateway to push the number of released accounts to, in a group of its own per invocation")
 	return accountUnassignCmd
 }
 
@@ -41,55 +50,78 @@ type accountUnassignOptions struct {
 	username     string
 	payerAccount string
 	accountID    string
-	flags        *genericclioptions.ConfigFlags
-	printFlags   *printer.PrintFlags
+	// all releases every account owned by the user found in the destination OU, after confirming their number
+	all bool
+	// ous overrides the OUs of the payer account
+	ous accountOUs
+
+	// assumeRole returns a client acting in the given account, used to clean up its IAM resources once released
+	assumeRole func(accountID string) (awsprovider.Client, error)
+
+	metricsGateway string
+	metrics        *accountMetrics
+
+	flags      *genericclioptions.ConfigFlags
+	printFlags *printer.PrintFlags
 	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
 }
 
-func newAccountUnassignOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags) *accountUnassignOptions {
-	return &accountUnassignOptions{
-		flags:      flags,
-		printFlags: printer.NewPrintFlags(),
-		IOStreams:  streams,
+func newAccountUnassignOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *accountUnassignOptions {
+	o := &accountUnassignOptions{
+		flags:         flags,
+		printFlags:    printer.NewPrintFlags(),
+		IOStreams:     streams,
+		GlobalOptions: globalOpts,
 	}
+	o.assumeRole = o.assumeRoleForAccount
+	return o
 }
 func (o *accountUnassignOptions) complete(cmd *cobra.Command, _ []string) error {
 	if o.payerAccount == "" {
-		return cmdutil.UsageErrorf(cmd, "Payer account was not provided")
+		return outputflag.UsageErrorf(cmd, "Payer account was not provided")
 	}
 	if o.username == "" && o.accountID == "" {
-		return cmdutil.UsageErrorf(cmd, "Please provide either an username or account ID")
+		return outputflag.UsageErrorf(cmd, "Please provide either an username or account ID")
 	}
 	if o.username != "" && o.accountID != "" {
-		return cmdutil.UsageErrorf(cmd, "Please provider only a username or an account ID, not both.")
+		return outputflag.UsageErrorf(cmd, "Please provider only a username or an account ID, not both.")
 	}
+	if o.all && o.username == "" {
+		return outputflag.UsageErrorf(cmd, "--all releases the accounts of a user, it requires --username")
+	}
+	o.metrics = newAccountMetrics(o.metricsGateway)
 	return nil
 }
 func (o *accountUnassignOptions) run() error {
 	var (
-		accountUsername      string
-		accountIdList        []string
-		destinationOU        string
-		rootID               string
-		assumedRoleAwsClient awsprovider.Client
+		accountUsername string
+		accountIdList   []string
+		destinationOU   string
+		rootID          string
 	)
 	// Instantiate Aws client
-	awsClient, err := awsprovider.NewAwsClient(o.payerAccount, "us-east-1", "")
+	awsClient, err := newPayerAccountClient(o.payerAccount)
 	if err != nil {
 		return err
 	}
-	if o.payerAccount == "osd-staging-1" {
-		rootID = OSDStaging1RootID
-		destinationOU = OSDStaging1OuID
-	} else if o.payerAccount == "osd-staging-2" {
-		rootID = OSDStaging2RootID
-		destinationOU = OSDStaging2OuID
-	} else {
-		return fmt.Errorf("invalid payer account provided")
+	ous, err := resolveOUs(o.payerAccount, o.ous)
+	if err != nil {
+		return err
 	}
+	// Released accounts are returned to the pool
+	rootID = ous.PoolOU
+	destinationOU = ous.DestinationOU
 
 	o.awsClient = awsClient
-	var allUsers []string
+
+	if o.all {
+		if strings.HasPrefix(o.username, "hive") {
+			return ErrHiveNameProvided
+		}
+		_, err = o.releaseAllAccounts(o.username, rootID, destinationOU)
+		return err
+	}
 
 	if o.accountID != "" {
 		// Check aws tag to see if it's a ccs acct, if it's not return name of owner
@@ -128,45 +160,63 @@ func (o *accountUnassignOptions) run() error {
 
 	// loop through accounts list and untag and move them back into root OU
 	for _, id := range accountIdList {
-
-		// untag account
-		err = o.untagAccount(id)
+		err = o.releaseAccount(id, rootID, destinationOU)
 		if err != nil {
 			return err
 		}
+	}
 
-		// move account
-		err = o.moveAccount(id, rootID, destinationOU)
-		if err != nil {
-			return err
-		}
-		// instantiate new client with AssumeRole
-		assumedRoleAwsClient, err = o.assumeRoleForAccount(id)
-		if err != nil {
-			return err
-		}
-		// delete roles
-		err = deleteRoles(assumedRoleAwsClient)
-		if err != nil {
-			fmt.Println(err)
-		}
-		// delete account policies
-		err = deleteAccountPolicies(assumedRoleAwsClient)
-		if err != nil {
-			fmt.Println(err)
-		}
-		// list iam users created by each account and append to slice
-		users, err := listUsersFromAccount(assumedRoleAwsClient, id)
-		if err != nil {
-			fmt.Println(err)
-		}
+	return nil
+}
 
-		allUsers = append(allUsers, users...)
+// releaseAccount untags the account and moves it back into the pool, then deletes the IAM resources created in it.
+// Failures to delete IAM resources are printed rather than returned, as the account has already been released.
+func (o *accountUnassignOptions) releaseAccount(id string, rootID string, destinationOU string) error {
+	// untag account
+	err := o.untagAccount(id)
+	if err != nil {
+		return err
+	}
 
-		o.awsClient = assumedRoleAwsClient
+	// move account
+	err = o.moveAccount(id, rootID, destinationOU)
+	if err != nil {
+		return err
+	}
+	o.metrics.accountReleased()
+	// instantiate new client with AssumeRole
+	assumedRoleAwsClient, err := o.assumeRole(id)
+	if err != nil {
+		return err
+	}
+	// delete roles
+	err = deleteRoles(assumedRoleAwsClient)
+	if err != nil {
+		fmt.Println(err)
+	}
+	// delete account policies
+	err = deleteAccountPolicies(assumedRoleAwsClient)
+	if err != nil {
+		fmt.Println(err)
+	}
+	// list iam users created by the account
+	users, err := listUsersFromAccount(assumedRoleAwsClient, id)
+	if err != nil {
+		fmt.Println(err)
 	}
 
-	for _, userName := range allUsers {
+	// The users are deleted from within the account, the payer account's client is kept for the next account
+	accountOps := *o
+	accountOps.awsClient = assumedRoleAwsClient
+	accountOps.deleteUsers(users)
+	return nil
+}
+
+// deleteUsers deletes the given IAM users along with their credentials, policies and group memberships, printing
+// any failures
+func (o *accountUnassignOptions) deleteUsers(users []string) {
+	var err error
+	for _, userName := range users {
 		// Delete login profile
 		err = o.deleteLoginProfile(userName)
 		if err != nil {
@@ -203,13 +253,75 @@ func (o *accountUnassignOptions) run() error {
 			fmt.Println(err)
 		}
 	}
+}
 
-	return nil
+// findOwnedAccounts returns the IDs of the accounts directly under the given OU which the owner has claimed
+func (o *accountUnassignOptions) findOwnedAccounts(ouID string, owner string) ([]string, error) {
+	owned := []string{}
+	err := awsprovider.ForEachAccount(o.awsClient, ouID, func(a *awsprovider.OrgAccount) error {
+		tags, err := a.Tags()
+		if err != nil {
+			return err
+		}
+		if tagsOwned(tags) && tags["owner"] == owner {
+			owned = append(owned, *a.Id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return owned, nil
+}
+
+// releaseAllAccounts releases every account in the destination OU owned by the user, once their number has been
+// typed to confirm. A failure to release one account does not stop the others from being released, the released
+// accounts are returned and the failures are reported together.
+func (o *accountUnassignOptions) releaseAllAccounts(owner string, rootID string, destinationOU string) ([]string, error) {
+	accountIdList, err := o.findOwnedAccounts(destinationOU, owner)
+	if err != nil {
+		return nil, err
+	}
+	if len(accountIdList) == 0 {
+		return nil, ErrNoAccountsForUser
+	}
+
+	fmt.Printf("Found %d account(s) owned by %s:\n", len(accountIdList), owner)
+	for _, id := range accountIdList {
+		fmt.Printf("- %s\n", id)
+	}
+	fmt.Printf("Type the number of accounts to release all of them: ")
+	response, err := bufio.NewReader(o.In).ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(response) != strconv.Itoa(len(accountIdList)) {
+		fmt.Println("The number of accounts did not match, no accounts were released.")
+		return nil, nil
+	}
+
+	released := []string{}
+	failures := []string{}
+	for _, id := range accountIdList {
+		err = o.releaseAccount(id, rootID, destinationOU)
+		if err != nil {
+			fmt.Fprintf(o.errOut(), "Failed to release account %s: %v\n", id, err)
+			failures = append(failures, fmt.Sprintf("account %s: %v", id, err))
+			continue
+		}
+		released = append(released, id)
+	}
+
+	o.infof("Released %d of %d account(s) owned by %s.\n", len(released), len(accountIdList), owner)
+	if len(failures) > 0 {
+		return released, fmt.Errorf("failed to release %d of %d accounts:\n%s", len(failures), len(accountIdList), strings.Join(failures, "\n"))
+	}
+	return released, nil
 }
 
 func (o *accountUnassignOptions) assumeRoleForAccount(account_id string) (awsprovider.Client, error) {
 
-	roleArn := fmt.Sprintf("arn:aws:iam::%s:role/OrganizationAccountAccessRole", account_id)
+	roleArn := fmt.Sprintf("arn:aws:iam::%s:role/%s", account_id, defaultAccessRoleName)
 
 	input := &sts.AssumeRoleInput{
 		RoleArn:         aws.String(roleArn),
@@ -282,20 +394,34 @@ func (o *accountUnassignOptions) checkForHiveNameTag(id string) (string, error)
 	return "", ErrNoOwnerTag
 }
 
+// untagAccount removes the ownership tags from the account, recording its release in the claim-history tag
 func (o *accountUnassignOptions) untagAccount(id string) error {
+	tags, err := listAccountTags(id, o.awsClient)
+	if err != nil {
+		return err
+	}
 
 	inputUntag := &organizations.UntagResourceInput{
 		ResourceId: &id,
 		TagKeys: []*string{
 			aws.String("owner"),
 			aws.String("claimed"),
+			aws.String("claimed-at"),
+			aws.String("expires-at"),
+			aws.String("reassigned-at"),
 		},
 	}
-	_, err := o.awsClient.UntagResource(inputUntag)
+	_, err = o.awsClient.UntagResource(inputUntag)
 	if err != nil {
 		return err
 	}
-	return nil
+	return tagResource(o.awsClient, id, map[string]string{
+		claimHistoryTagKey: appendClaimHistory(tags[claimHistoryTagKey], claimHistoryEntry{
+			Action: claimHistoryRelease,
+			Owner:  tags["owner"],
+			Time:   time.Now().UTC(),
+		}),
+	})
 }
 
 func (o *accountUnassignOptions) moveAccount(id string, rootID string, destinationOU string) error {
@@ -546,6 +672,26 @@ func (o *accountUnassignOptions) deleteUser(user string) error {
 	if err != nil {
 		return err
 	}
-	fmt.Printf("user %s successfully deleted\n", user)
+	o.infof("user %s successfully deleted\n", user)
 	return nil
 }
+
+// infof prints an informational message to Out, or to stdout when no streams are set, unless --quiet is set
+func (o *accountUnassignOptions) infof(format string, a ...interface{}) {
+	if o.GlobalOptions != nil && o.GlobalOptions.Quiet {
+		return
+	}
+	out := o.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	fmt.Fprintf(out, format, a...)
+}
+
+// errOut returns ErrOut, or stderr when no streams are set
+func (o *accountUnassignOptions) errOut() io.Writer {
+	if o.ErrOut == nil {
+		return os.Stderr
+	}
+	return o.ErrOut
+}
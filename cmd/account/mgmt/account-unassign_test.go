@@ -2,6 +2,8 @@ package mgmt
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/golang/mock/gomock"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/openshift/osdctl/pkg/provider/aws/mock"
 
 	"github.com/openshift/osdctl/internal/utils/globalflags"
@@ -593,10 +596,29 @@ func TestUntagAccount(t *testing.T) {
 
 	accountId := "111111111111"
 
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{
+		Tags: []*organizations.Tag{
+			{Key: aws.String("owner"), Value: aws.String("tuser")},
+			{Key: aws.String("claimed"), Value: aws.String("true")},
+			{Key: aws.String("claim-history"), Value: aws.String("assign:tuser:1651399200")},
+		},
+	}, nil)
 	mockAWSClient.EXPECT().UntagResource(gomock.Any()).Return(
 		&organizations.UntagResourceOutput{},
 		nil,
 	)
+	// The claim history is kept, with the release appended
+	var history string
+	mockAWSClient.EXPECT().TagResource(gomock.Any()).DoAndReturn(
+		func(input *organizations.TagResourceInput) (*organizations.TagResourceOutput, error) {
+			for _, t := range input.Tags {
+				if *t.Key == "claim-history" {
+					history = *t.Value
+				}
+			}
+			return &organizations.TagResourceOutput{}, nil
+		},
+	)
 
 	o := &accountUnassignOptions{}
 	o.awsClient = mockAWSClient
@@ -604,6 +626,14 @@ func TestUntagAccount(t *testing.T) {
 	if err != nil {
 		t.Errorf("failed to untag aws account")
 	}
+
+	entries, err := parseClaimHistory(history)
+	if err != nil {
+		t.Fatalf("expected a valid claim history, got %q: %v", history, err)
+	}
+	if len(entries) != 2 || entries[0].Action != "assign" || entries[1].Action != "release" || entries[1].Owner != "tuser" {
+		t.Errorf("expected the release by tuser to be appended, got %q", history)
+	}
 }
 
 func TestConflictingOptions(t *testing.T) {
@@ -620,3 +650,158 @@ func TestConflictingOptions(t *testing.T) {
 		t.Errorf("An error should have been raised")
 	}
 }
+
+func TestReleaseAllAccounts(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+	mockAccountClient := mock.NewMockClient(mocks.mockCtrl)
+
+	rootID := "r-abcd"
+	destinationOU := "ou-abcd-destdest"
+	accountTags := map[string]map[string]string{
+		"111111111111": {"owner": "tuser", "claimed": "true"},
+		"222222222222": {"owner": "otheruser", "claimed": "true"},
+		"333333333333": {"owner": "tuser", "claimed": "true"},
+		"444444444444": {},
+	}
+	accounts := []*organizations.Account{}
+	for _, id := range []string{"111111111111", "222222222222", "333333333333", "444444444444"} {
+		accounts = append(accounts, &organizations.Account{Id: aws.String(id)})
+	}
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+		Accounts: accounts,
+	}, nil)
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).DoAndReturn(
+		func(input *organizations.ListTagsForResourceInput) (*organizations.ListTagsForResourceOutput, error) {
+			output := &organizations.ListTagsForResourceOutput{}
+			for k, v := range accountTags[*input.ResourceId] {
+				output.Tags = append(output.Tags, &organizations.Tag{Key: aws.String(k), Value: aws.String(v)})
+			}
+			return output, nil
+		},
+	).AnyTimes()
+
+	// Only the user's accounts are released
+	released := map[string]bool{}
+	mockAWSClient.EXPECT().UntagResource(gomock.Any()).DoAndReturn(
+		func(input *organizations.UntagResourceInput) (*organizations.UntagResourceOutput, error) {
+			released[*input.ResourceId] = true
+			return &organizations.UntagResourceOutput{}, nil
+		},
+	).Times(2)
+	mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(&organizations.TagResourceOutput{}, nil).Times(2)
+	for _, id := range []string{"111111111111", "333333333333"} {
+		mockAWSClient.EXPECT().MoveAccount(&organizations.MoveAccountInput{
+			AccountId:           aws.String(id),
+			DestinationParentId: aws.String(rootID),
+			SourceParentId:      aws.String(destinationOU),
+		}).Return(&organizations.MoveAccountOutput{}, nil)
+	}
+
+	// The IAM resources are cleaned up from within each released account
+	mockAccountClient.EXPECT().ListRoles(gomock.Any()).Return(&iam.ListRolesOutput{}, nil).Times(2)
+	mockAccountClient.EXPECT().ListPolicies(gomock.Any()).Return(&iam.ListPoliciesOutput{}, nil).Times(2)
+	mockAccountClient.EXPECT().ListUsers(gomock.Any()).Return(&iam.ListUsersOutput{}, nil).Times(2)
+
+	o := &accountUnassignOptions{}
+	o.awsClient = mockAWSClient
+	o.IOStreams = genericclioptions.IOStreams{In: strings.NewReader("2\n")}
+	o.assumeRole = func(accountID string) (awsprovider.Client, error) {
+		return mockAccountClient, nil
+	}
+
+	ids, err := o.releaseAllAccounts("tuser", rootID, destinationOU)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []string{"111111111111", "333333333333"}) {
+		t.Errorf("expected the accounts owned by tuser to be released, got %v", ids)
+	}
+	if len(released) != 2 || !released["111111111111"] || !released["333333333333"] {
+		t.Errorf("expected only the accounts owned by tuser to be untagged, got %v", released)
+	}
+}
+
+func TestReleaseAllAccountsOutput(t *testing.T) {
+	for _, quiet := range []bool{false, true} {
+		t.Run(fmt.Sprintf("quiet=%t", quiet), func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+			mockAccountClient := mock.NewMockClient(mocks.mockCtrl)
+
+			rootID := "r-abcd"
+			destinationOU := "ou-abcd-destdest"
+
+			mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+				Accounts: []*organizations.Account{{Id: aws.String("111111111111")}, {Id: aws.String("333333333333")}},
+			}, nil)
+			mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{
+				Tags: []*organizations.Tag{
+					{Key: aws.String("owner"), Value: aws.String("tuser")},
+					{Key: aws.String("claimed"), Value: aws.String("true")},
+				},
+			}, nil).AnyTimes()
+			// Releasing the first account fails, the second one is released
+			mockAWSClient.EXPECT().UntagResource(gomock.Any()).DoAndReturn(
+				func(input *organizations.UntagResourceInput) (*organizations.UntagResourceOutput, error) {
+					if *input.ResourceId == "111111111111" {
+						return nil, fmt.Errorf("AccessDeniedException")
+					}
+					return &organizations.UntagResourceOutput{}, nil
+				},
+			).Times(2)
+			mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(&organizations.TagResourceOutput{}, nil)
+			mockAWSClient.EXPECT().MoveAccount(gomock.Any()).Return(&organizations.MoveAccountOutput{}, nil)
+			mockAccountClient.EXPECT().ListRoles(gomock.Any()).Return(&iam.ListRolesOutput{}, nil)
+			mockAccountClient.EXPECT().ListPolicies(gomock.Any()).Return(&iam.ListPoliciesOutput{}, nil)
+			mockAccountClient.EXPECT().ListUsers(gomock.Any()).Return(&iam.ListUsersOutput{}, nil)
+
+			out := &strings.Builder{}
+			errOut := &strings.Builder{}
+			o := &accountUnassignOptions{GlobalOptions: &globalflags.GlobalOptions{Quiet: quiet}}
+			o.awsClient = mockAWSClient
+			o.IOStreams = genericclioptions.IOStreams{In: strings.NewReader("2\n"), Out: out, ErrOut: errOut}
+			o.assumeRole = func(accountID string) (awsprovider.Client, error) {
+				return mockAccountClient, nil
+			}
+
+			_, err := o.releaseAllAccounts("tuser", rootID, destinationOU)
+			if err == nil {
+				t.Fatal("expected the failure to release an account to be returned")
+			}
+			if !strings.Contains(errOut.String(), "Failed to release account 111111111111") {
+				t.Errorf("expected the failure to be printed to stderr, got %q", errOut.String())
+			}
+			summary := strings.Contains(out.String(), "Released 1 of 2 account(s) owned by tuser.")
+			if summary == quiet {
+				t.Errorf("expected the summary to be printed: %t, got %q", !quiet, out.String())
+			}
+		})
+	}
+}
+
+func TestReleaseAllAccountsNotConfirmed(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+		Accounts: []*organizations.Account{{Id: aws.String("111111111111")}, {Id: aws.String("333333333333")}},
+	}, nil)
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{
+		Tags: []*organizations.Tag{{Key: aws.String("owner"), Value: aws.String("tuser")}},
+	}, nil).Times(2)
+
+	o := &accountUnassignOptions{}
+	o.awsClient = mockAWSClient
+	// Answering yes isn't enough, the number of accounts must be typed
+	o.IOStreams = genericclioptions.IOStreams{In: strings.NewReader("y\n")}
+
+	ids, err := o.releaseAllAccounts("tuser", "r-abcd", "ou-abcd-destdest")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Errorf("expected no accounts to be released, got %v", ids)
+	}
+}
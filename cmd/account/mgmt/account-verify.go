@@ -0,0 +1,220 @@
+package mgmt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// The checks run by 'verify', in the order they are reported
+const (
+	verifyCheckActive = "active"
+	verifyCheckTagged = "tagged"
+	verifyCheckOU     = "ou"
+)
+
+type accountVerifyOptions struct {
+	awsClient    awsprovider.Client
+	accountID    string
+	owner        string
+	payerAccount string
+	output       string
+	// ous overrides the OUs of the payer account
+	ous accountOUs
+
+	flags      *genericclioptions.ConfigFlags
+	printFlags *printer.PrintFlags
+	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// verifyCheck is the outcome of one of the checks of a claimed account
+type verifyCheck struct {
+	Name   string `json:"name" yaml:"name"`
+	Passed bool   `json:"passed" yaml:"passed"`
+	Detail string `json:"detail" yaml:"detail"`
+}
+
+type verifyResponse struct {
+	Id     string        `json:"id" yaml:"id"`
+	Passed bool          `json:"passed" yaml:"passed"`
+	Checks []verifyCheck `json:"checks" yaml:"checks"`
+}
+
+func (f verifyResponse) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  Account: %s\n", f.Id))
+	for _, c := range f.Checks {
+		result := "PASS"
+		if !c.Passed {
+			result = "FAIL"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s: %s\n", result, c.Name, c.Detail))
+	}
+	return sb.String()
+}
+
+func newAccountVerifyOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *accountVerifyOptions {
+	return &accountVerifyOptions{
+		flags:         flags,
+		printFlags:    printer.NewPrintFlags(),
+		IOStreams:     streams,
+		GlobalOptions: globalOpts,
+	}
+}
+
+// newCmdAccountVerify checks that a claimed account is active, tagged as claimed and in the developers OU
+func newCmdAccountVerify(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := newAccountVerifyOptions(streams, flags, globalOpts)
+	accountVerifyCmd := &cobra.Command{
+		Use:               "verify <account id>",
+		Short:             "Check that a claimed account is active, tagged and in the developers OU",
+		Long:              "Check that a claimed account is active, is tagged with an owner and as claimed, and is in the developers OU of the payer account. Each check is reported as passed or failed, and the command fails if any check failed.",
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			outputflag.CheckErr(globalOpts.Output, ops.complete(cmd, args))
+			outputflag.CheckErr(globalOpts.Output, ops.run())
+		},
+	}
+	ops.printFlags.AddFlags(accountVerifyCmd)
+	accountVerifyCmd.Flags().StringVarP(&ops.payerAccount, "payer-account", "p", "", "Payer account type")
+	accountVerifyCmd.Flags().StringVar(&ops.owner, "owner", "", "(optional) LDAP username the account must be owned by")
+	addOUFlags(accountVerifyCmd, &ops.ous)
+
+	return accountVerifyCmd
+}
+
+func (o *accountVerifyOptions) complete(cmd *cobra.Command, args []string) error {
+	if o.payerAccount == "" {
+		return outputflag.UsageErrorf(cmd, "Payer account was not provided")
+	}
+	o.accountID = args[0]
+	if len(o.accountID) != 12 || strings.Trim(o.accountID, "0123456789") != "" {
+		return outputflag.UsageErrorf(cmd, "Account ID '%s' must be 12 digits", o.accountID)
+	}
+
+	o.output = o.GlobalOptions.Output
+
+	return nil
+}
+
+var ErrVerificationFailed = fmt.Errorf("the account failed verification")
+
+func (o *accountVerifyOptions) run() error {
+	ous, err := resolveOUs(o.payerAccount, o.ous)
+	if err != nil {
+		return err
+	}
+
+	awsClient, err := newPayerAccountClient(o.payerAccount)
+	if err != nil {
+		return err
+	}
+	o.awsClient = awsClient
+
+	resp, err := o.verifyAccount(ous.DestinationOU)
+	if err != nil {
+		return err
+	}
+
+	err = outputflag.PrintResponse(o.output, outputflag.NewEnvelope("AccountVerification", resp))
+	if err != nil {
+		return err
+	}
+	if !resp.Passed {
+		return ErrVerificationFailed
+	}
+	return nil
+}
+
+// verifyAccount runs every check against the account. A failed check is reported in the response, while an error is
+// only returned if AWS could not be queried.
+func (o *accountVerifyOptions) verifyAccount(destinationOU string) (verifyResponse, error) {
+	active, err := o.checkActive()
+	if err != nil {
+		return verifyResponse{}, err
+	}
+	tagged, err := o.checkTagged()
+	if err != nil {
+		return verifyResponse{}, err
+	}
+	ou, err := o.checkOU(destinationOU)
+	if err != nil {
+		return verifyResponse{}, err
+	}
+
+	resp := verifyResponse{
+		Id:     o.accountID,
+		Passed: true,
+		Checks: []verifyCheck{active, tagged, ou},
+	}
+	for _, c := range resp.Checks {
+		resp.Passed = resp.Passed && c.Passed
+	}
+	return resp, nil
+}
+
+// checkActive checks that the account's status is ACTIVE
+func (o *accountVerifyOptions) checkActive() (verifyCheck, error) {
+	account, err := o.awsClient.DescribeAccount(&organizations.DescribeAccountInput{
+		AccountId: aws.String(o.accountID),
+	})
+	if err != nil {
+		return verifyCheck{}, awsprovider.WithOrganizationsHint(awsprovider.WithRequestID(err))
+	}
+	status := aws.StringValue(account.Account.Status)
+	return verifyCheck{
+		Name:   verifyCheckActive,
+		Passed: status == organizations.AccountStatusActive,
+		Detail: fmt.Sprintf("status is %s", status),
+	}, nil
+}
+
+// checkTagged checks that the account has an owner, matching --owner if given, and is tagged as claimed
+func (o *accountVerifyOptions) checkTagged() (verifyCheck, error) {
+	tags, err := listAccountTags(o.accountID, o.awsClient)
+	if err != nil {
+		return verifyCheck{}, err
+	}
+	check := verifyCheck{Name: verifyCheckTagged}
+	owner, hasOwner := tags["owner"]
+	switch {
+	case !hasOwner:
+		check.Detail = "no owner tag"
+	case o.owner != "" && owner != o.owner:
+		check.Detail = fmt.Sprintf("owned by %s, expected %s", owner, o.owner)
+	case tags["claimed"] != "true":
+		check.Detail = fmt.Sprintf("owned by %s but not tagged as claimed", owner)
+	default:
+		check.Passed = true
+		check.Detail = fmt.Sprintf("owned by %s", owner)
+	}
+	return check, nil
+}
+
+// checkOU checks that the account is in the given OU
+func (o *accountVerifyOptions) checkOU(destinationOU string) (verifyCheck, error) {
+	parents, err := o.awsClient.ListParents(&organizations.ListParentsInput{
+		ChildId: aws.String(o.accountID),
+	})
+	if err != nil {
+		return verifyCheck{}, awsprovider.WithRequestID(err)
+	}
+	if len(parents.Parents) == 0 {
+		return verifyCheck{Name: verifyCheckOU, Detail: "no parent found"}, nil
+	}
+	parentID := aws.StringValue(parents.Parents[0].Id)
+	if parentID != destinationOU {
+		return verifyCheck{Name: verifyCheckOU, Detail: fmt.Sprintf("in %s, expected %s", parentID, destinationOU)}, nil
+	}
+	return verifyCheck{Name: verifyCheckOU, Passed: true, Detail: fmt.Sprintf("in %s", parentID)}, nil
+}
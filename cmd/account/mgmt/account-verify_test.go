@@ -0,0 +1,93 @@
+package mgmt
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestVerifyAccount(t *testing.T) {
+	accountID := "111111111111"
+	destOU := "ou-abcd-vnjfdshs"
+
+	tests := []struct {
+		name     string
+		parentID string
+		// expectedChecks maps each check to whether it passes
+		expectedChecks map[string]bool
+		expectedPassed bool
+	}{
+		{
+			name:     "passes all checks",
+			parentID: destOU,
+			expectedChecks: map[string]bool{
+				verifyCheckActive: true,
+				verifyCheckTagged: true,
+				verifyCheckOU:     true,
+			},
+			expectedPassed: true,
+		},
+		{
+			name:     "wrong OU",
+			parentID: "r-abcd",
+			expectedChecks: map[string]bool{
+				verifyCheckActive: true,
+				verifyCheckTagged: true,
+				verifyCheckOU:     false,
+			},
+			expectedPassed: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+			mockAWSClient.EXPECT().DescribeAccount(&organizations.DescribeAccountInput{
+				AccountId: aws.String(accountID),
+			}).Return(&organizations.DescribeAccountOutput{
+				Account: &organizations.Account{
+					Id:     aws.String(accountID),
+					Status: aws.String(organizations.AccountStatusActive),
+				},
+			}, nil)
+			mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{
+				Tags: []*organizations.Tag{
+					{Key: aws.String("owner"), Value: aws.String("tuser")},
+					{Key: aws.String("claimed"), Value: aws.String("true")},
+				},
+			}, nil)
+			mockAWSClient.EXPECT().ListParents(&organizations.ListParentsInput{
+				ChildId: aws.String(accountID),
+			}).Return(&organizations.ListParentsOutput{
+				Parents: []*organizations.Parent{{Id: aws.String(test.parentID)}},
+			}, nil)
+
+			o := &accountVerifyOptions{}
+			o.awsClient = mockAWSClient
+			o.accountID = accountID
+			o.owner = "tuser"
+
+			resp, err := o.verifyAccount(destOU)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Passed != test.expectedPassed {
+				t.Errorf("expected passed to be %t, got %t", test.expectedPassed, resp.Passed)
+			}
+			if len(resp.Checks) != len(test.expectedChecks) {
+				t.Fatalf("expected %d checks, got %v", len(test.expectedChecks), resp.Checks)
+			}
+			for _, c := range resp.Checks {
+				if c.Passed != test.expectedChecks[c.Name] {
+					t.Errorf("expected check %s to pass: %t, got %t (%s)", c.Name, test.expectedChecks[c.Name], c.Passed, c.Detail)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package mgmt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+type accountWhoamiOptions struct {
+	awsClient    awsprovider.Client
+	owner        string
+	payerAccount string
+	output       string
+	// ous overrides the OUs of the payer account
+	ous accountOUs
+
+	flags      *genericclioptions.ConfigFlags
+	printFlags *printer.PrintFlags
+	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+type ownedAccount struct {
+	Id    string `json:"id" yaml:"id"`
+	Email string `json:"email" yaml:"email"`
+	OU    string `json:"ou" yaml:"ou"`
+}
+
+type whoamiResponse struct {
+	Owner    string         `json:"owner" yaml:"owner"`
+	Accounts []ownedAccount `json:"accounts" yaml:"accounts"`
+}
+
+func (f whoamiResponse) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  Owner: %s\n", f.Owner))
+	for _, a := range f.Accounts {
+		sb.WriteString(fmt.Sprintf("  Account: %s\n    Email: %s\n    OU: %s\n", a.Id, a.Email, a.OU))
+	}
+	return sb.String()
+}
+
+func newAccountWhoamiOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *accountWhoamiOptions {
+	return &accountWhoamiOptions{
+		flags:         flags,
+		printFlags:    printer.NewPrintFlags(),
+		IOStreams:     streams,
+		GlobalOptions: globalOpts,
+	}
+}
+
+// newCmdAccountWhoami shows the account(s) tagged to the current user
+func newCmdAccountWhoami(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := newAccountWhoamiOptions(streams, flags, globalOpts)
+	accountWhoamiCmd := &cobra.Command{
+		Use:               "whoami",
+		Short:             "Show the account(s) assigned to the current user",
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			outputflag.CheckErr(globalOpts.Output, ops.complete(cmd, args))
+			outputflag.CheckErr(globalOpts.Output, ops.run())
+		},
+	}
+	ops.printFlags.AddFlags(accountWhoamiCmd)
+	accountWhoamiCmd.Flags().StringVarP(&ops.payerAccount, "payer-account", "p", "", "Payer account type")
+	accountWhoamiCmd.Flags().StringVar(&ops.owner, "owner", "", "LDAP username to look up, defaults to $USER")
+	addOUFlags(accountWhoamiCmd, &ops.ous)
+
+	return accountWhoamiCmd
+}
+
+func (o *accountWhoamiOptions) complete(cmd *cobra.Command, _ []string) error {
+	if o.payerAccount == "" {
+		return outputflag.UsageErrorf(cmd, "Payer account was not provided")
+	}
+	if o.owner == "" {
+		o.owner = os.Getenv("USER")
+	}
+	if o.owner == "" {
+		return outputflag.UsageErrorf(cmd, "Owner was not provided and $USER is unset")
+	}
+
+	o.output = o.GlobalOptions.Output
+
+	return nil
+}
+
+func (o *accountWhoamiOptions) run() error {
+	ous, err := resolveOUs(o.payerAccount, o.ous)
+	if err != nil {
+		return err
+	}
+
+	awsClient, err := newPayerAccountClient(o.payerAccount)
+	if err != nil {
+		return err
+	}
+	o.awsClient = awsClient
+
+	accounts, err := o.findOwnedAccounts(ous.DestinationOU)
+	if err != nil {
+		return err
+	}
+
+	resp := whoamiResponse{
+		Owner:    o.owner,
+		Accounts: accounts,
+	}
+
+	return outputflag.PrintResponse(o.output, outputflag.NewEnvelope("AccountWhoami", resp))
+}
+
+var ErrNoAccountsForOwner = fmt.Errorf("no accounts are tagged to the owner")
+
+// findOwnedAccounts returns the accounts in the given OU whose owner tag matches the owner, following every page of
+// accounts
+func (o *accountWhoamiOptions) findOwnedAccounts(ouID string) ([]ownedAccount, error) {
+	owned := []ownedAccount{}
+	err := awsprovider.ForEachAccount(o.awsClient, ouID, func(a *awsprovider.OrgAccount) error {
+		tags, err := a.Tags()
+		if err != nil {
+			return err
+		}
+		if tags["owner"] != o.owner {
+			return nil
+		}
+
+		var email string
+		if a.Email != nil {
+			email = *a.Email
+		}
+		owned = append(owned, ownedAccount{
+			Id:    *a.Id,
+			Email: email,
+			OU:    ouID,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(owned) == 0 {
+		return nil, ErrNoAccountsForOwner
+	}
+
+	return owned, nil
+}
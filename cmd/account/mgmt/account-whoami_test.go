@@ -0,0 +1,128 @@
+package mgmt
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestFindOwnedAccounts(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	ouID := "ou-abcd-vnjfdshs"
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+		&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{
+				{
+					Id:    aws.String("111111111111"),
+					Email: aws.String("osd-creds-mgmt+aaaaaa@redhat.com"),
+				},
+				{
+					Id:    aws.String("222222222222"),
+					Email: aws.String("osd-creds-mgmt+bbbbbb@redhat.com"),
+				},
+			},
+		}, nil,
+	)
+
+	mockAWSClient.EXPECT().ListTagsForResource(
+		&organizations.ListTagsForResourceInput{
+			ResourceId: aws.String("111111111111"),
+		},
+	).Return(&organizations.ListTagsForResourceOutput{
+		Tags: []*organizations.Tag{
+			{
+				Key:   aws.String("owner"),
+				Value: aws.String("otheruser"),
+			},
+		},
+	}, nil)
+
+	mockAWSClient.EXPECT().ListTagsForResource(
+		&organizations.ListTagsForResourceInput{
+			ResourceId: aws.String("222222222222"),
+		},
+	).Return(&organizations.ListTagsForResourceOutput{
+		Tags: []*organizations.Tag{
+			{
+				Key:   aws.String("owner"),
+				Value: aws.String("tuser"),
+			},
+			{
+				Key:   aws.String("claimed"),
+				Value: aws.String("true"),
+			},
+		},
+	}, nil)
+
+	o := &accountWhoamiOptions{}
+	o.awsClient = mockAWSClient
+	o.owner = "tuser"
+
+	expected := []ownedAccount{
+		{
+			Id:    "222222222222",
+			Email: "osd-creds-mgmt+bbbbbb@redhat.com",
+			OU:    ouID,
+		},
+	}
+
+	returnValue, err := o.findOwnedAccounts(ouID)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(returnValue, expected) {
+		t.Errorf("expected %v, got %v", expected, returnValue)
+	}
+}
+
+func TestFindOwnedAccountsPaginated(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	ouID := "ou-abcd-vnjfdshs"
+
+	// The owner has an account on each page of the OU
+	gomock.InOrder(
+		mockAWSClient.EXPECT().ListAccountsForParent(&organizations.ListAccountsForParentInput{
+			ParentId: aws.String(ouID),
+		}).Return(&organizations.ListAccountsForParentOutput{
+			Accounts:  []*organizations.Account{{Id: aws.String("111111111111")}},
+			NextToken: aws.String("page-2"),
+		}, nil),
+		mockAWSClient.EXPECT().ListAccountsForParent(&organizations.ListAccountsForParentInput{
+			ParentId:  aws.String(ouID),
+			NextToken: aws.String("page-2"),
+		}).Return(&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{{Id: aws.String("222222222222")}},
+		}, nil),
+	)
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{
+		Tags: []*organizations.Tag{{Key: aws.String("owner"), Value: aws.String("tuser")}},
+	}, nil).Times(2)
+
+	o := &accountWhoamiOptions{}
+	o.awsClient = mockAWSClient
+	o.owner = "tuser"
+
+	expected := []ownedAccount{
+		{Id: "111111111111", OU: ouID},
+		{Id: "222222222222", OU: ouID},
+	}
+
+	returnValue, err := o.findOwnedAccounts(ouID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(returnValue, expected) {
+		t.Errorf("expected the owned accounts of both pages %v, got %v", expected, returnValue)
+	}
+}
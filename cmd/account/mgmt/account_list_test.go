@@ -1,15 +1,21 @@
 package mgmt
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/organizations"
 
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/golang/mock/gomock"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
 	"github.com/openshift/osdctl/pkg/provider/aws/mock"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -182,6 +188,86 @@ func TestListAccountsByUser(t *testing.T) {
 	}
 }
 
+func TestListAccountsByUserSince(t *testing.T) {
+	now := time.Date(2022, 6, 10, 12, 0, 0, 0, time.UTC)
+	claimedAt := map[string]string{
+		"111111111111": now.Add(-72 * time.Hour).Format(time.RFC3339),
+		"222222222222": now.Add(-2 * time.Hour).Format(time.RFC3339),
+		"333333333333": "",
+		"444444444444": "not-a-timestamp",
+	}
+
+	testData := []struct {
+		name              string
+		since             time.Duration
+		includeUnknownAge bool
+		expectedAccounts  []string
+	}{
+		{
+			name:             "no age filter lists every account of the owner",
+			expectedAccounts: []string{"111111111111", "222222222222", "333333333333", "444444444444"},
+		},
+		{
+			name:             "only accounts claimed before --since are listed",
+			since:            48 * time.Hour,
+			expectedAccounts: []string{"111111111111"},
+		},
+		{
+			name:              "accounts of unknown age are listed with --include-unknown-age",
+			since:             48 * time.Hour,
+			includeUnknownAge: true,
+			expectedAccounts:  []string{"111111111111", "333333333333", "444444444444"},
+		},
+		{
+			name:  "the owner is dropped when none of their accounts are old enough",
+			since: 96 * time.Hour,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+			resources := []*resourcegroupstaggingapi.ResourceTagMapping{}
+			for _, id := range []string{"111111111111", "222222222222", "333333333333", "444444444444"} {
+				tags := []*resourcegroupstaggingapi.Tag{{Key: aws.String("owner"), Value: aws.String("auser")}}
+				if claimedAt[id] != "" {
+					tags = append(tags, &resourcegroupstaggingapi.Tag{Key: aws.String("claimed-at"), Value: aws.String(claimedAt[id])})
+				}
+				resources = append(resources, &resourcegroupstaggingapi.ResourceTagMapping{
+					ResourceARN: aws.String("arn:aws:organizations::000000000000:account/o-abcd/" + id),
+					Tags:        tags,
+				})
+			}
+			mockAWSClient.EXPECT().GetResources(gomock.Any()).Return(&resourcegroupstaggingapi.GetResourcesOutput{
+				ResourceTagMappingList: resources,
+			}, nil)
+
+			o := &accountListOptions{}
+			o.awsClient = mockAWSClient
+			o.since = test.since
+			o.includeUnknownAge = test.includeUnknownAge
+
+			accounts, err := o.listAccountsByUser("auser")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			m := o.filterBySince(map[string][]string{"auser": accounts}, now)
+
+			if len(test.expectedAccounts) == 0 {
+				if len(m) != 0 {
+					t.Errorf("expected no accounts to be listed, got %v", m)
+				}
+				return
+			}
+			if !reflect.DeepEqual(m["auser"], test.expectedAccounts) {
+				t.Errorf("expected accounts %v, got %v", test.expectedAccounts, m["auser"])
+			}
+		})
+	}
+}
+
 func TestListAllAccounts(t *testing.T) {
 
 	var genericAWSError error = fmt.Errorf("Generic AWS Error")
@@ -298,3 +384,229 @@ func TestListAllAccounts(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderAccountsTemplate(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+	OuId := "ou-abcd-efghlmno"
+
+	owners := map[string]string{
+		"111111111111": "userb",
+		"222222222222": "usera",
+	}
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+		&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{
+				{Id: aws.String("111111111111")},
+				{Id: aws.String("222222222222")},
+			},
+		}, nil)
+	for id, owner := range owners {
+		mockAWSClient.EXPECT().ListTagsForResource(
+			&organizations.ListTagsForResourceInput{
+				ResourceId: aws.String(id),
+			},
+		).Return(&organizations.ListTagsForResourceOutput{
+			Tags: []*organizations.Tag{
+				{
+					Key:   aws.String("owner"),
+					Value: aws.String(owner),
+				},
+			},
+		}, nil)
+	}
+
+	o := &accountListOptions{}
+	o.awsClient = mockAWSClient
+	o.accountTemplate = template.Must(template.New("account").Parse("{{.Id}}:{{.Username}}"))
+
+	m, err := o.listAllAccounts(OuId)
+	if err != nil {
+		t.Fatalf("unexpected error listing accounts: %v", err)
+	}
+
+	var out bytes.Buffer
+	err = o.renderAccounts(&out, m)
+	if err != nil {
+		t.Fatalf("unexpected error rendering accounts: %v", err)
+	}
+
+	expected := "222222222222:usera\n111111111111:userb\n"
+	if out.String() != expected {
+		t.Errorf("expected %q got %q", expected, out.String())
+	}
+}
+
+func TestListAllAccountsSort(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+	OuId := "ou-abcd-efghlmno"
+
+	accountTags := map[string]map[string]string{
+		"111111111111": {"owner": "userb", "claimed-at": "2022-05-03T10:00:00Z"},
+		"222222222222": {"owner": "usera", "claimed-at": "2022-05-02T10:00:00Z"},
+		"333333333333": {"owner": "userb", "claimed-at": "2022-05-01T10:00:00Z"},
+		"444444444444": {"owner": "userc"},
+	}
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+		&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{
+				{Id: aws.String("333333333333")},
+				{Id: aws.String("111111111111")},
+				{Id: aws.String("444444444444")},
+				{Id: aws.String("222222222222")},
+			},
+		}, nil)
+	for id, tags := range accountTags {
+		awsTags := []*organizations.Tag{}
+		for k, v := range tags {
+			awsTags = append(awsTags, &organizations.Tag{Key: aws.String(k), Value: aws.String(v)})
+		}
+		mockAWSClient.EXPECT().ListTagsForResource(
+			&organizations.ListTagsForResourceInput{
+				ResourceId: aws.String(id),
+			},
+		).Return(&organizations.ListTagsForResourceOutput{Tags: awsTags}, nil)
+	}
+
+	o := &accountListOptions{}
+	o.awsClient = mockAWSClient
+
+	m, err := o.listAllAccounts(OuId)
+	if err != nil {
+		t.Fatalf("unexpected error listing accounts: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		sort     accountSort
+		expected []listResponse
+	}{
+		{
+			name: "by id",
+			sort: accountSort{Key: sortByID},
+			expected: []listResponse{
+				{Username: "userb", Accounts: []string{"111111111111", "333333333333"}},
+				{Username: "usera", Accounts: []string{"222222222222"}},
+				{Username: "userc", Accounts: []string{"444444444444"}},
+			},
+		},
+		{
+			name: "by owner reversed",
+			sort: accountSort{Key: sortByOwner, Reverse: true},
+			expected: []listResponse{
+				{Username: "userc", Accounts: []string{"444444444444"}},
+				{Username: "userb", Accounts: []string{"333333333333", "111111111111"}},
+				{Username: "usera", Accounts: []string{"222222222222"}},
+			},
+		},
+		{
+			name: "by claimed-at",
+			sort: accountSort{Key: sortByClaimedAt},
+			expected: []listResponse{
+				{Username: "userb", Accounts: []string{"333333333333", "111111111111"}},
+				{Username: "usera", Accounts: []string{"222222222222"}},
+				{Username: "userc", Accounts: []string{"444444444444"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o.sort = test.sort
+			responses := o.listResponses(m)
+			if !reflect.DeepEqual(responses, test.expected) {
+				t.Errorf("expected %v got %v", test.expected, responses)
+			}
+		})
+	}
+}
+
+func TestStreamAllAccounts(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+	OuId := "ou-abcd-efghlmno"
+
+	var out bytes.Buffer
+
+	// The first page is printed before the second page is listed
+	mockAWSClient.EXPECT().ListAccountsForParent(&organizations.ListAccountsForParentInput{
+		ParentId: aws.String(OuId),
+	}).Return(&organizations.ListAccountsForParentOutput{
+		Accounts:  []*organizations.Account{{Id: aws.String("111111111111")}, {Id: aws.String("222222222222")}},
+		NextToken: aws.String("page-2"),
+	}, nil)
+	mockAWSClient.EXPECT().ListAccountsForParent(&organizations.ListAccountsForParentInput{
+		ParentId:  aws.String(OuId),
+		NextToken: aws.String("page-2"),
+	}).DoAndReturn(func(*organizations.ListAccountsForParentInput) (*organizations.ListAccountsForParentOutput, error) {
+		if lines := strings.Count(out.String(), "\n"); lines != 1 {
+			t.Errorf("expected the first page's account to be printed before listing the next page, got %d lines", lines)
+		}
+		return &organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{{Id: aws.String("333333333333")}},
+		}, nil
+	})
+
+	tags := map[string][]*organizations.Tag{
+		"111111111111": {{Key: aws.String("owner"), Value: aws.String("usera")}},
+		// Accounts without an owner are skipped
+		"222222222222": {},
+		"333333333333": {{Key: aws.String("owner"), Value: aws.String("userb")}},
+	}
+	for id, accountTags := range tags {
+		mockAWSClient.EXPECT().ListTagsForResource(
+			&organizations.ListTagsForResourceInput{
+				ResourceId: aws.String(id),
+			},
+		).Return(&organizations.ListTagsForResourceOutput{Tags: accountTags}, nil)
+	}
+
+	o := &accountListOptions{}
+	o.awsClient = mockAWSClient
+
+	err := o.streamAllAccounts(&out, OuId)
+	if err != nil {
+		t.Fatalf("unexpected error streaming accounts: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	expected := []claimedAccount{{Username: "usera", Id: "111111111111"}, {Username: "userb", Id: "333333333333"}}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected one line per owned account, got %q", out.String())
+	}
+	for i, line := range lines {
+		var envelope struct {
+			Kind string         `json:"kind"`
+			Data claimedAccount `json:"data"`
+		}
+		err = json.Unmarshal([]byte(line), &envelope)
+		if err != nil {
+			t.Fatalf("expected line %d to be JSON, got %q: %v", i, line, err)
+		}
+		if envelope.Kind != "ClaimedAccount" || envelope.Data != expected[i] {
+			t.Errorf("expected line %d to be %v, got %q", i, expected[i], line)
+		}
+	}
+}
+
+func TestListAccountsCSV(t *testing.T) {
+	o := &accountListOptions{}
+	m := map[string][]string{
+		"tuser": {"222222222222", "111111111111"},
+		"auser": {"333333333333"},
+	}
+
+	var out bytes.Buffer
+	err := outputflag.PrintCSV(&out, csvAccounts(o.listResponses(m)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "username,id\nauser,333333333333\ntuser,222222222222\ntuser,111111111111\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
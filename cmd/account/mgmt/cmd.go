@@ -2,11 +2,35 @@ package mgmt
 
 import (
 	"fmt"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
+func init() {
+	// The codes of the errors printed with '-o json', scripts rely on them so they must not change
+	outputflag.RegisterErrorCode("UnknownPayerAccount", ErrUnknownPayerAccount)
+	outputflag.RegisterErrorCode("NoUntaggedAccounts", ErrNoUntaggedAccounts)
+	outputflag.RegisterErrorCode("AccountAlreadyOwned", ErrAccountAlreadyOwned)
+	outputflag.RegisterErrorCode("AccountSuspended", ErrAccountSuspended)
+	outputflag.RegisterErrorCode("AccountNotOwned", ErrAccountNotOwned)
+	outputflag.RegisterErrorCode("AccountOwnedByUser", ErrAccountAlreadyOwnedByUser)
+	outputflag.RegisterErrorCode("AccountPartialTags", ErrAccountPartiallyTagged)
+	outputflag.RegisterErrorCode("NoAccountsForUser", ErrNoAccountsForUser)
+	outputflag.RegisterErrorCode("NoAccountsForOwner", ErrNoAccountsForOwner)
+	outputflag.RegisterErrorCode("PoolBelowMinimum", ErrPoolBelowMinimum)
+	outputflag.RegisterErrorCode("PoolWaitTimedOut", ErrPoolWaitTimedOut)
+	outputflag.RegisterErrorCode("MoveNotVerified", ErrMoveNotVerified)
+	outputflag.RegisterErrorCode("VerificationFailed", ErrVerificationFailed)
+	outputflag.RegisterErrorCode("InvalidOwnerFile", ErrInvalidOwnerFile)
+	outputflag.RegisterErrorCode("InvalidAssignmentFile", ErrInvalidAssignmentFile)
+	outputflag.RegisterErrorCode("AccountLimitExceeded", ErrAwsAccountLimitExceeded)
+	outputflag.RegisterErrorCode("Interrupted", ErrInterrupted)
+	outputflag.RegisterErrorCode("Timeout", ErrTimeout)
+}
+
 // NewCmdMgmt implements the mgmt command to get AWS Account resources
 func NewCmdMgmt(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
 	mgmtCmd := &cobra.Command{
@@ -18,7 +42,13 @@ func NewCmdMgmt(streams genericclioptions.IOStreams, flags *genericclioptions.Co
 
 	mgmtCmd.AddCommand(newCmdAccountList(streams, flags, globalOpts))
 	mgmtCmd.AddCommand(newCmdAccountAssign(streams, flags, globalOpts))
-	mgmtCmd.AddCommand(newCmdAccountUnassign(streams, flags))
+	mgmtCmd.AddCommand(newCmdAccountUnassign(streams, flags, globalOpts))
+	mgmtCmd.AddCommand(newCmdAccountReassign(streams, flags, globalOpts))
+	mgmtCmd.AddCommand(newCmdAccountTagHistory(streams, flags, globalOpts))
+	mgmtCmd.AddCommand(newCmdAccountWhoami(streams, flags, globalOpts))
+	mgmtCmd.AddCommand(newCmdAccountPoolStatus(streams, flags, globalOpts))
+	mgmtCmd.AddCommand(newCmdAccountSearch(streams, flags, globalOpts))
+	mgmtCmd.AddCommand(newCmdAccountVerify(streams, flags, globalOpts))
 
 	return mgmtCmd
 }
@@ -0,0 +1,25 @@
+package mgmt
+
+import (
+	"testing"
+
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// TestNewCmdMgmt builds every mgmt subcommand, pflag panics when one of them registers the same flag twice
+func TestNewCmdMgmt(t *testing.T) {
+	streams := genericclioptions.NewTestIOStreamsDiscard()
+	cmd := NewCmdMgmt(streams, genericclioptions.NewConfigFlags(false), &globalflags.GlobalOptions{})
+
+	list, _, err := cmd.Find([]string{"list"})
+	if err != nil {
+		t.Fatalf("expected a list subcommand: %v", err)
+	}
+	if list.Flags().Lookup("go-template") == nil {
+		t.Errorf("expected account mgmt list to have a --go-template flag")
+	}
+	if list.Flags().Lookup("template") == nil {
+		t.Errorf("expected account mgmt list to keep the --template flag of its print flags")
+	}
+}
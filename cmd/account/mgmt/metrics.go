@@ -0,0 +1,90 @@
+package mgmt
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+const (
+	// metricsJobName is the job the account counters are grouped under on the push gateway
+	metricsJobName = "osdctl_account_mgmt"
+	// metricsInvocationLabel is the grouping label telling the counters of each invocation apart on the push gateway
+	metricsInvocationLabel = "invocation"
+)
+
+// accountMetrics counts the accounts assigned and released by a single invocation. The counters are pushed to a
+// Prometheus push gateway when the command ends, if one was given with --metrics-push-gateway.
+// Pushing replaces the metrics of the same name in the pushed group, so each invocation pushes to its own group and
+// the totals are the sum() of the counters over the groups. Only the counters the invocation incremented are pushed.
+// A nil *accountMetrics is valid and does nothing.
+type accountMetrics struct {
+	gateway string
+	// invocation is the value of metricsInvocationLabel this invocation's counters are pushed with
+	invocation string
+	assigned   prometheus.Counter
+	released   prometheus.Counter
+	// incremented are the counters to push, in the order they were first incremented
+	incremented []prometheus.Collector
+}
+
+func newAccountMetrics(gateway string) *accountMetrics {
+	return &accountMetrics{
+		gateway:    gateway,
+		invocation: strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + strconv.Itoa(os.Getpid()),
+		assigned: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "osdctl_accounts_assigned_total",
+			Help: "Number of AWS accounts assigned to users",
+		}),
+		released: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "osdctl_accounts_released_total",
+			Help: "Number of AWS accounts released by users",
+		}),
+	}
+}
+
+func (m *accountMetrics) accountAssigned() {
+	if m == nil {
+		return
+	}
+	m.assigned.Inc()
+	m.touch(m.assigned)
+}
+
+func (m *accountMetrics) accountReleased() {
+	if m == nil {
+		return
+	}
+	m.released.Inc()
+	m.touch(m.released)
+}
+
+// touch records that the counter was incremented, so it is pushed
+func (m *accountMetrics) touch(c prometheus.Collector) {
+	for _, incremented := range m.incremented {
+		if incremented == c {
+			return
+		}
+	}
+	m.incremented = append(m.incremented, c)
+}
+
+// push sends the incremented counters to the push gateway, in this invocation's group. Failing to push only prints a
+// warning, as the accounts have already been assigned or released by then.
+func (m *accountMetrics) push() {
+	if m == nil || m.gateway == "" || len(m.incremented) == 0 {
+		return
+	}
+	pusher := push.New(m.gateway, metricsJobName).Grouping(metricsInvocationLabel, m.invocation)
+	for _, c := range m.incremented {
+		pusher = pusher.Collector(c)
+	}
+	err := pusher.Add()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to push metrics to %s: %v\n", m.gateway, err)
+	}
+}
@@ -0,0 +1,102 @@
+package mgmt
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestAssignAccountIncrementsMetrics(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	accountID := "111111111111"
+	rootOu := "r-abcd"
+	destOu := "ou-abcd-vnjfdshs"
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+		&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{{Id: aws.String(accountID)}},
+		}, nil)
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil).Times(2)
+	mockAWSClient.EXPECT().DescribeAccount(gomock.Any()).Return(&organizations.DescribeAccountOutput{
+		Account: &organizations.Account{
+			Id:     aws.String(accountID),
+			Status: aws.String(organizations.AccountStatusActive),
+		},
+	}, nil)
+	mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(&organizations.TagResourceOutput{}, nil)
+	mockAWSClient.EXPECT().ListParents(gomock.Any()).Return(&organizations.ListParentsOutput{
+		Parents: []*organizations.Parent{{Id: aws.String(rootOu)}},
+	}, nil)
+	mockAWSClient.EXPECT().MoveAccount(gomock.Any()).Return(&organizations.MoveAccountOutput{}, nil)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.username = "tuser"
+	o.metrics = newAccountMetrics("")
+
+	_, err := o.assignAccount(rootOu, destOu)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if assigned := testutil.ToFloat64(o.metrics.assigned); assigned != 1 {
+		t.Errorf("expected 1 assigned account to be counted, got %v", assigned)
+	}
+	if released := testutil.ToFloat64(o.metrics.released); released != 0 {
+		t.Errorf("expected no released accounts to be counted, got %v", released)
+	}
+}
+
+func TestAccountMetricsPush(t *testing.T) {
+	pushed := 0
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/metrics/job/"+metricsJobName+"/"+metricsInvocationLabel+"/") {
+			t.Errorf("unexpected push to %s", r.URL.Path)
+		}
+		var err error
+		body, err = io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read the pushed metrics: %v", err)
+		}
+		pushed++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	// Without a gateway, without metrics, or without any counted account, pushing does nothing
+	var noMetrics *accountMetrics
+	noMetrics.accountAssigned()
+	noMetrics.push()
+	newAccountMetrics("").push()
+	newAccountMetrics(server.URL).push()
+	if pushed != 0 {
+		t.Fatalf("expected nothing to be pushed, got %d pushes", pushed)
+	}
+
+	// Only the counter of released accounts is pushed, so the assigned accounts pushed by other invocations are kept
+	m := newAccountMetrics(server.URL)
+	m.accountReleased()
+	m.accountReleased()
+	m.push()
+	if pushed != 1 {
+		t.Fatalf("expected the metrics to be pushed once, got %d pushes", pushed)
+	}
+	if !bytes.Contains(body, []byte("osdctl_accounts_released_total")) {
+		t.Errorf("expected the released accounts to be pushed, got %q", body)
+	}
+	if bytes.Contains(body, []byte("osdctl_accounts_assigned_total")) {
+		t.Errorf("expected the assigned accounts not to be pushed, got %q", body)
+	}
+}
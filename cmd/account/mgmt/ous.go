@@ -0,0 +1,83 @@
+package mgmt
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// accountMgmtConfigKey is the section of the osdctl config file holding the OUs of each payer account, e.g.
+//
+//	account_mgmt:
+//	  osd-staging-2:
+//	    root_id: r-rs3h
+//	    pool_ou_id: ou-rs3h-abcdefgh
+//	    destination_ou_id: ou-rs3h-ry0hn2l9
+const accountMgmtConfigKey = "account_mgmt"
+
+// accountOUs are the organization root and OUs the account commands work with for a payer account
+type accountOUs struct {
+	// RootID is the root of the payer account's organization
+	RootID string
+	// PoolOU is where unassigned accounts wait to be assigned and released accounts are returned, it defaults to
+	// the root
+	PoolOU string
+	// DestinationOU is where assigned accounts are moved, also known as the developers OU
+	DestinationOU string
+}
+
+// addOUFlags adds the flags overriding the OUs of the payer account to the command
+func addOUFlags(cmd *cobra.Command, ous *accountOUs) {
+	cmd.Flags().StringVar(&ous.RootID, "root-id", "", "(optional) Organization root ID, overrides the config file and the payer account's default")
+	cmd.Flags().StringVar(&ous.PoolOU, "pool-ou", "", "(optional) OU ID unassigned accounts are kept in, overrides the config file and defaults to the root")
+	cmd.Flags().StringVar(&ous.DestinationOU, "destination-ou", "", "(optional) OU ID assigned accounts are moved to, overrides the config file and the payer account's default")
+}
+
+// defaultAccountOUs returns the built-in OUs of the given payer account, which are empty for unknown payer accounts
+func defaultAccountOUs(payerAccount string) accountOUs {
+	switch payerAccount {
+	case "osd-staging-1":
+		return accountOUs{RootID: OSDStaging1RootID, DestinationOU: OSDStaging1OuID}
+	case "osd-staging-2":
+		return accountOUs{RootID: OSDStaging2RootID, DestinationOU: OSDStaging2OuID}
+	}
+	return accountOUs{}
+}
+
+// resolveOUs returns the OUs of the given payer account. Each of them is taken from the given flags if set, then from
+// the payer account's entry in the account_mgmt section of the config file, then from the built-in defaults.
+func resolveOUs(payerAccount string, flags accountOUs) (accountOUs, error) {
+	ous := defaultAccountOUs(payerAccount)
+
+	configKey := fmt.Sprintf("%s.%s", accountMgmtConfigKey, payerAccount)
+	for _, setting := range []struct {
+		key   string
+		flag  string
+		value *string
+	}{
+		{key: "root_id", flag: flags.RootID, value: &ous.RootID},
+		{key: "pool_ou_id", flag: flags.PoolOU, value: &ous.PoolOU},
+		{key: "destination_ou_id", flag: flags.DestinationOU, value: &ous.DestinationOU},
+	} {
+		if configured := viper.GetString(configKey + "." + setting.key); configured != "" {
+			*setting.value = configured
+		}
+		if setting.flag != "" {
+			*setting.value = setting.flag
+		}
+	}
+
+	if ous.PoolOU == "" {
+		ous.PoolOU = ous.RootID
+	}
+	if ous.RootID == "" || ous.DestinationOU == "" {
+		return accountOUs{}, fmt.Errorf("invalid payer account provided, the OUs of payer accounts other than osd-staging-1 and osd-staging-2 must be set with --root-id and --destination-ou, or in the '%s' section of the config file", configKey)
+	}
+	for _, id := range []string{ous.RootID, ous.PoolOU, ous.DestinationOU} {
+		if err := validateParentID(id); err != nil {
+			return accountOUs{}, err
+		}
+	}
+	return ous, nil
+}
@@ -0,0 +1,94 @@
+package mgmt
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestResolveOUs(t *testing.T) {
+	testData := []struct {
+		name         string
+		payerAccount string
+		config       map[string]string
+		flags        accountOUs
+		expectedOUs  accountOUs
+		expectErr    bool
+	}{
+		{
+			name:         "test for built-in defaults",
+			payerAccount: "osd-staging-2",
+			expectedOUs:  accountOUs{RootID: OSDStaging2RootID, PoolOU: OSDStaging2RootID, DestinationOU: OSDStaging2OuID},
+		},
+		{
+			name:         "test for OUs from the config file when flags are absent",
+			payerAccount: "osd-staging-2",
+			config: map[string]string{
+				"account_mgmt.osd-staging-2.root_id":           "r-abcd",
+				"account_mgmt.osd-staging-2.pool_ou_id":        "ou-abcd-poolpool",
+				"account_mgmt.osd-staging-2.destination_ou_id": "ou-abcd-destdest",
+			},
+			expectedOUs: accountOUs{RootID: "r-abcd", PoolOU: "ou-abcd-poolpool", DestinationOU: "ou-abcd-destdest"},
+		},
+		{
+			name:         "test for flags overriding the config file",
+			payerAccount: "osd-staging-2",
+			config: map[string]string{
+				"account_mgmt.osd-staging-2.root_id":           "r-abcd",
+				"account_mgmt.osd-staging-2.pool_ou_id":        "ou-abcd-poolpool",
+				"account_mgmt.osd-staging-2.destination_ou_id": "ou-abcd-destdest",
+			},
+			flags:       accountOUs{PoolOU: "ou-abcd-flagpool", DestinationOU: "ou-abcd-flagdest"},
+			expectedOUs: accountOUs{RootID: "r-abcd", PoolOU: "ou-abcd-flagpool", DestinationOU: "ou-abcd-flagdest"},
+		},
+		{
+			name:         "test for the config file overriding only some defaults",
+			payerAccount: "osd-staging-1",
+			config: map[string]string{
+				"account_mgmt.osd-staging-1.pool_ou_id": "ou-0wd6-poolpool",
+			},
+			expectedOUs: accountOUs{RootID: OSDStaging1RootID, PoolOU: "ou-0wd6-poolpool", DestinationOU: OSDStaging1OuID},
+		},
+		{
+			name:         "test for payer account only known from the config file",
+			payerAccount: "osd-staging-3",
+			config: map[string]string{
+				"account_mgmt.osd-staging-3.root_id":           "r-efgh",
+				"account_mgmt.osd-staging-3.destination_ou_id": "ou-efgh-destdest",
+			},
+			expectedOUs: accountOUs{RootID: "r-efgh", PoolOU: "r-efgh", DestinationOU: "ou-efgh-destdest"},
+		},
+		{
+			name:         "test for unknown payer account",
+			payerAccount: "osd-staging-3",
+			expectErr:    true,
+		},
+		{
+			name:         "test for invalid OU in the config file",
+			payerAccount: "osd-staging-2",
+			config: map[string]string{
+				"account_mgmt.osd-staging-2.destination_ou_id": "developers",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			for k, v := range test.config {
+				viper.Set(k, v)
+			}
+
+			ous, err := resolveOUs(test.payerAccount, test.flags)
+			if test.expectErr != (err != nil) {
+				t.Fatalf("expected error to be %t, got %v", test.expectErr, err)
+			}
+			if !test.expectErr && !reflect.DeepEqual(ous, test.expectedOUs) {
+				t.Errorf("expected %v is %v", test.expectedOUs, ous)
+			}
+		})
+	}
+}
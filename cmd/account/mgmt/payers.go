@@ -0,0 +1,38 @@
+package mgmt
+
+import (
+	"fmt"
+
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/spf13/viper"
+)
+
+var ErrUnknownPayerAccount = fmt.Errorf("invalid payer account provided")
+
+// payerAccountProfile returns the AWS profile used to operate against the organization of the given payer account.
+// osd-staging-1 and osd-staging-2 are always known, other payer accounts must have an entry in the account_mgmt
+// section of the config file. The profile defaults to the payer account's name and can be set with the entry's
+// profile key, e.g.
+//
+//	account_mgmt:
+//	  osd-staging-3:
+//	    profile: osd-staging-3-admin
+func payerAccountProfile(payerAccount string) (string, error) {
+	configKey := fmt.Sprintf("%s.%s", accountMgmtConfigKey, payerAccount)
+	if defaultAccountOUs(payerAccount) == (accountOUs{}) && !viper.IsSet(configKey) {
+		return "", fmt.Errorf("%w '%s', payer accounts other than osd-staging-1 and osd-staging-2 must have an entry in the '%s' section of the config file", ErrUnknownPayerAccount, payerAccount, accountMgmtConfigKey)
+	}
+	if profile := viper.GetString(configKey + ".profile"); profile != "" {
+		return profile, nil
+	}
+	return payerAccount, nil
+}
+
+// newPayerAccountClient builds the client of the organization managed by the given payer account
+func newPayerAccountClient(payerAccount string) (awsprovider.Client, error) {
+	profile, err := payerAccountProfile(payerAccount)
+	if err != nil {
+		return nil, err
+	}
+	return awsprovider.NewAwsClient(profile, "us-east-1", "")
+}
@@ -0,0 +1,66 @@
+package mgmt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestPayerAccountProfile(t *testing.T) {
+	testData := []struct {
+		name            string
+		payerAccount    string
+		config          map[string]string
+		expectedProfile string
+		expectErr       error
+	}{
+		{
+			name:            "test for built-in payer account",
+			payerAccount:    "osd-staging-2",
+			expectedProfile: "osd-staging-2",
+		},
+		{
+			name:         "test for payer account from the config file",
+			payerAccount: "osd-staging-3",
+			config: map[string]string{
+				"account_mgmt.osd-staging-3.root_id": "r-efgh",
+			},
+			expectedProfile: "osd-staging-3",
+		},
+		{
+			name:         "test for payer account profile from the config file",
+			payerAccount: "osd-staging-3",
+			config: map[string]string{
+				"account_mgmt.osd-staging-3.profile": "osd-staging-3-admin",
+			},
+			expectedProfile: "osd-staging-3-admin",
+		},
+		{
+			name:         "test for unknown payer account",
+			payerAccount: "osd-staging-4",
+			config: map[string]string{
+				"account_mgmt.osd-staging-3.profile": "osd-staging-3-admin",
+			},
+			expectErr: ErrUnknownPayerAccount,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			for k, v := range test.config {
+				viper.Set(k, v)
+			}
+
+			profile, err := payerAccountProfile(test.payerAccount)
+			if !errors.Is(err, test.expectErr) {
+				t.Fatalf("expected error %v, got %v", test.expectErr, err)
+			}
+			if profile != test.expectedProfile {
+				t.Errorf("expected profile %s, got %s", test.expectedProfile, profile)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package mgmt
+
+import (
+	"fmt"
+	"io"
+)
+
+// skippedAccount is an account passed over while scanning the pool, along with why it couldn't be assigned
+type skippedAccount struct {
+	ID     string
+	Reason string
+}
+
+// skippedAccounts are the accounts passed over by the last scan of the pool, in the order they were checked. The zero
+// value is ready to use.
+type skippedAccounts struct {
+	accounts []skippedAccount
+}
+
+// record remembers that the account was skipped for the given reason
+func (s *skippedAccounts) record(accountID string, reason string) {
+	s.accounts = append(s.accounts, skippedAccount{ID: accountID, Reason: reason})
+}
+
+// write prints a table of the skipped accounts and their reasons, or that the scanned OUs held no accounts at all
+func (s *skippedAccounts) write(w io.Writer) {
+	if len(s.accounts) == 0 {
+		fmt.Fprintln(w, "No accounts were found in the pool")
+		return
+	}
+	fmt.Fprintf(w, "%-14s %s\n", "ACCOUNT", "SKIPPED BECAUSE")
+	for _, a := range s.accounts {
+		fmt.Fprintf(w, "%-14s %s\n", a.ID, a.Reason)
+	}
+}
+
+// ownedReason describes why an account with the given tags counts as owned
+func ownedReason(tags map[string]string) string {
+	owner, hasOwner := tags["owner"]
+	_, hasClaimed := tags["claimed"]
+	switch {
+	case hasOwner && hasClaimed:
+		return fmt.Sprintf("owned by %s", owner)
+	case hasOwner:
+		return fmt.Sprintf("partially tagged, owned by %s but not claimed", owner)
+	default:
+		return "partially tagged, claimed without an owner"
+	}
+}
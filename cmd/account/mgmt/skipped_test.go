@@ -0,0 +1,82 @@
+package mgmt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestFindUntaggedAccountRecordsSkipReasons(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+		&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{
+				{Id: aws.String("111111111111")},
+				{Id: aws.String("222222222222")},
+				{Id: aws.String("333333333333")},
+				{Id: aws.String("444444444444")},
+			},
+		}, nil)
+	tags := map[string][]*organizations.Tag{
+		"111111111111": {{Key: aws.String("owner"), Value: aws.String("tuser")}, {Key: aws.String("claimed"), Value: aws.String("true")}},
+		"222222222222": {{Key: aws.String("claimed"), Value: aws.String("true")}},
+		"333333333333": {{Key: aws.String("owner"), Value: aws.String("other")}},
+		"444444444444": {},
+	}
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).DoAndReturn(
+		func(input *organizations.ListTagsForResourceInput) (*organizations.ListTagsForResourceOutput, error) {
+			return &organizations.ListTagsForResourceOutput{Tags: tags[*input.ResourceId]}, nil
+		},
+	).Times(4)
+	mockAWSClient.EXPECT().DescribeAccount(gomock.Any()).Return(&organizations.DescribeAccountOutput{
+		Account: &organizations.Account{
+			Id:     aws.String("444444444444"),
+			Status: aws.String(organizations.AccountStatusSuspended),
+		},
+	}, nil)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+
+	_, err := o.findUntaggedAccount("r-abcd")
+	if err != ErrNoUntaggedAccounts {
+		t.Fatalf("expected %v, got %v", ErrNoUntaggedAccounts, err)
+	}
+
+	var out bytes.Buffer
+	o.skipped.write(&out)
+	expected := map[string]string{
+		"111111111111": "owned by tuser",
+		"222222222222": "partially tagged, claimed without an owner",
+		"333333333333": "partially tagged, owned by other but not claimed",
+		"444444444444": "suspended",
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(expected)+1 {
+		t.Fatalf("expected a header and %d accounts, got:\n%s", len(expected), out.String())
+	}
+	for _, line := range lines[1:] {
+		fields := strings.SplitN(line, " ", 2)
+		reason := strings.TrimSpace(fields[1])
+		if expected[fields[0]] != reason {
+			t.Errorf("expected account %s to be skipped because '%s', got '%s'", fields[0], expected[fields[0]], reason)
+		}
+	}
+}
+
+func TestSkippedAccountsEmptyPool(t *testing.T) {
+	var s skippedAccounts
+	var out bytes.Buffer
+	s.write(&out)
+	if !strings.Contains(out.String(), "No accounts were found") {
+		t.Errorf("expected an empty pool to be reported, got %s", out.String())
+	}
+}
@@ -0,0 +1,84 @@
+package mgmt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// The keys accounts can be sorted by with --sort
+const (
+	sortByOwner     = "owner"
+	sortByID        = "id"
+	sortByClaimedAt = "claimed-at"
+)
+
+var accountSortKeys = []string{sortByOwner, sortByID, sortByClaimedAt}
+
+// accountSort is how the --sort and --reverse flags ask for accounts to be ordered. The zero value leaves them in the
+// order the command would otherwise print them.
+type accountSort struct {
+	Key     string
+	Reverse bool
+}
+
+// sortKey is the part of an account it can be sorted by
+type sortKey struct {
+	ID    string
+	Owner string
+	// ClaimedAt is the value of the claimed-at tag, accounts without one, or with one that isn't an RFC 3339 time,
+	// sort after all the others
+	ClaimedAt string
+}
+
+// addSortFlags adds the flags choosing how listed accounts are ordered to the command
+func addSortFlags(cmd *cobra.Command, s *accountSort) {
+	cmd.Flags().StringVar(&s.Key, "sort", "", fmt.Sprintf("(optional) Sort the accounts by one of %s", strings.Join(accountSortKeys, ", ")))
+	cmd.Flags().BoolVarP(&s.Reverse, "reverse", "r", false, "Reverse the order set with --sort")
+}
+
+// validate returns an error if the sort key is unknown, or the order is reversed without a key
+func (s accountSort) validate() error {
+	if s.Key == "" {
+		if s.Reverse {
+			return fmt.Errorf("--reverse can only be used with --sort")
+		}
+		return nil
+	}
+	for _, key := range accountSortKeys {
+		if s.Key == key {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --sort key '%s', expected one of %s", s.Key, strings.Join(accountSortKeys, ", "))
+}
+
+// less reports whether the account with sort key x is listed before the one with sort key y. Accounts with the same
+// value for the sort key are ordered by account ID.
+func (s accountSort) less(x sortKey, y sortKey) bool {
+	if s.Reverse {
+		x, y = y, x
+	}
+
+	switch s.Key {
+	case sortByOwner:
+		if x.Owner != y.Owner {
+			return x.Owner < y.Owner
+		}
+	case sortByClaimedAt:
+		xt, xErr := time.Parse(time.RFC3339, x.ClaimedAt)
+		yt, yErr := time.Parse(time.RFC3339, y.ClaimedAt)
+		// Accounts without a claimed-at time stay last when the order is reversed
+		switch {
+		case xErr != nil && yErr == nil:
+			return s.Reverse
+		case xErr == nil && yErr != nil:
+			return !s.Reverse
+		case xErr == nil && yErr == nil && !xt.Equal(yt):
+			return xt.Before(yt)
+		}
+	}
+	return x.ID < y.ID
+}
@@ -0,0 +1,63 @@
+package mgmt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// tagChange is a tag whose value would change, OldValue is empty for added tags and NewValue for removed tags
+type tagChange struct {
+	Key      string
+	OldValue string
+	NewValue string
+}
+
+// tagDiff is how an account's tags would change, each list is sorted by key
+type tagDiff struct {
+	Added   []tagChange
+	Changed []tagChange
+	Removed []tagChange
+}
+
+// diffTags compares an account's current tags to the result of setting the given tags and removing the given keys.
+// Tags set to their current value, and removed tags which aren't set, are not changes.
+func diffTags(current map[string]string, set map[string]string, remove []string) tagDiff {
+	diff := tagDiff{}
+	for k, v := range set {
+		old, ok := current[k]
+		if !ok {
+			diff.Added = append(diff.Added, tagChange{Key: k, NewValue: v})
+		} else if old != v {
+			diff.Changed = append(diff.Changed, tagChange{Key: k, OldValue: old, NewValue: v})
+		}
+	}
+	for _, k := range remove {
+		if old, ok := current[k]; ok {
+			diff.Removed = append(diff.Removed, tagChange{Key: k, OldValue: old})
+		}
+	}
+	for _, changes := range [][]tagChange{diff.Added, diff.Changed, diff.Removed} {
+		sort.Slice(changes, func(i, j int) bool {
+			return changes[i].Key < changes[j].Key
+		})
+	}
+	return diff
+}
+
+func (d tagDiff) String() string {
+	if len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0 {
+		return "No tag changes\n"
+	}
+	var sb strings.Builder
+	for _, c := range d.Added {
+		sb.WriteString(fmt.Sprintf("+ %s=%s\n", c.Key, c.NewValue))
+	}
+	for _, c := range d.Changed {
+		sb.WriteString(fmt.Sprintf("~ %s: %s -> %s\n", c.Key, c.OldValue, c.NewValue))
+	}
+	for _, c := range d.Removed {
+		sb.WriteString(fmt.Sprintf("- %s=%s\n", c.Key, c.OldValue))
+	}
+	return sb.String()
+}
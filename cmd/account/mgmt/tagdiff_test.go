@@ -0,0 +1,119 @@
+package mgmt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDiffTags(t *testing.T) {
+	current := map[string]string{
+		"owner":         "olduser",
+		"claimed":       "true",
+		"team":          "srep",
+		"claim-history": "assign:olduser:1651399200",
+	}
+	set := map[string]string{
+		"owner":         "newuser",
+		"claimed":       "true",
+		"reassigned-at": "2022-06-01T10:00:00Z",
+		"claim-history": "assign:olduser:1651399200 reassign:newuser:1654077600",
+	}
+
+	diff := diffTags(current, set, []string{"team", "expires-at"})
+
+	expected := tagDiff{
+		Added: []tagChange{{Key: "reassigned-at", NewValue: "2022-06-01T10:00:00Z"}},
+		Changed: []tagChange{
+			{Key: "claim-history", OldValue: "assign:olduser:1651399200", NewValue: "assign:olduser:1651399200 reassign:newuser:1654077600"},
+			{Key: "owner", OldValue: "olduser", NewValue: "newuser"},
+		},
+		// expires-at isn't set, so removing it is not a change
+		Removed: []tagChange{{Key: "team", OldValue: "srep"}},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected %v is %v", expected, diff)
+	}
+
+	expectedOutput := "+ reassigned-at=2022-06-01T10:00:00Z\n" +
+		"~ claim-history: assign:olduser:1651399200 -> assign:olduser:1651399200 reassign:newuser:1654077600\n" +
+		"~ owner: olduser -> newuser\n" +
+		"- team=srep\n"
+	if diff.String() != expectedOutput {
+		t.Errorf("expected output %q, got %q", expectedOutput, diff.String())
+	}
+
+	if diffTags(current, map[string]string{"team": "srep"}, nil).String() != "No tag changes\n" {
+		t.Errorf("expected setting a tag to its current value not to be a change")
+	}
+}
+
+func TestPreviewAssignment(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+	accountID := "111111111111"
+
+	// A previously released account, which still has a team tag and its claim history
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+		Accounts: []*organizations.Account{{Id: aws.String(accountID)}},
+	}, nil)
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{
+		Tags: []*organizations.Tag{
+			{Key: aws.String("team"), Value: aws.String("hypershift")},
+			{Key: aws.String("claim-history"), Value: aws.String("assign:olduser:1651399200 release:olduser:1654077600")},
+		},
+	}, nil).Times(2)
+	mockAWSClient.EXPECT().DescribeAccount(gomock.Any()).Return(&organizations.DescribeAccountOutput{
+		Account: &organizations.Account{
+			Id:     aws.String(accountID),
+			Status: aws.String(organizations.AccountStatusActive),
+		},
+	}, nil)
+	// Nothing is changed in a dry run
+	mockAWSClient.EXPECT().TagResource(gomock.Any()).Times(0)
+	mockAWSClient.EXPECT().MoveAccount(gomock.Any()).Times(0)
+	mockAWSClient.EXPECT().CreateAccount(gomock.Any()).Times(0)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.username = "tuser"
+	o.extraTags = map[string]string{"team": "srep"}
+
+	err := o.previewAssignment("r-abcd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	diff := diffTags(o.listedTags[accountID], o.accountAssignmentTags(accountID), nil)
+	added := []string{}
+	for _, c := range diff.Added {
+		added = append(added, c.Key)
+	}
+	if strings.Join(added, ",") != "claimed,claimed-at,owner" {
+		t.Errorf("expected the ownership tags to be added, got %v", diff.Added)
+	}
+	changed := map[string]tagChange{}
+	for _, c := range diff.Changed {
+		changed[c.Key] = c
+	}
+	if len(changed) != 2 || changed["team"].NewValue != "srep" || changed["team"].OldValue != "hypershift" {
+		t.Errorf("expected the team tag to change from hypershift to srep, got %v", diff.Changed)
+	}
+	if !strings.HasPrefix(changed["claim-history"].NewValue, "assign:olduser:1651399200 release:olduser:1654077600 assign:tuser:") {
+		t.Errorf("expected the assignment to be appended to the claim history, got %v", changed["claim-history"])
+	}
+	if o.claimedAt.IsZero() || time.Since(o.claimedAt) > time.Minute {
+		t.Errorf("expected the previewed claimed-at to be the current time, got %v", o.claimedAt)
+	}
+	if len(diff.Removed) != 0 {
+		t.Errorf("expected no tags to be removed, got %v", diff.Removed)
+	}
+}
@@ -0,0 +1,32 @@
+package mgmt
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// assignPhases are the phases of an assignment whose durations are printed with --verbose, in the order they happen
+var assignPhases = []string{"discovery", "create", "tag", "move"}
+
+// phaseTimings accumulates how long each phase took, summed across all of the accounts handled by one invocation.
+// The zero value is ready to use.
+type phaseTimings struct {
+	durations map[string]time.Duration
+}
+
+// record adds the time elapsed since start to the given phase
+func (p *phaseTimings) record(phase string, start time.Time) {
+	if p.durations == nil {
+		p.durations = map[string]time.Duration{}
+	}
+	p.durations[phase] += time.Since(start)
+}
+
+// write prints one line per phase with the time spent in it, phases which never ran are printed as taking 0s
+func (p *phaseTimings) write(w io.Writer, phases []string) {
+	fmt.Fprintln(w, "Phase timings:")
+	for _, phase := range phases {
+		fmt.Fprintf(w, "  %s: %s\n", phase, p.durations[phase].Round(time.Millisecond))
+	}
+}
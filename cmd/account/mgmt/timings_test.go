@@ -0,0 +1,68 @@
+package mgmt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestAssignAccountRecordsPhaseTimings(t *testing.T) {
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+	accountID := "111111111111"
+
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+		&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{{Id: aws.String(accountID)}},
+		}, nil)
+	mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil).Times(2)
+	mockAWSClient.EXPECT().DescribeAccount(gomock.Any()).Return(&organizations.DescribeAccountOutput{
+		Account: &organizations.Account{
+			Id:     aws.String(accountID),
+			Status: aws.String(organizations.AccountStatusActive),
+		},
+	}, nil)
+	mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(&organizations.TagResourceOutput{}, nil)
+	mockAWSClient.EXPECT().ListParents(gomock.Any()).Return(&organizations.ListParentsOutput{
+		Parents: []*organizations.Parent{{Id: aws.String("r-abcd")}},
+	}, nil)
+	mockAWSClient.EXPECT().MoveAccount(gomock.Any()).Return(&organizations.MoveAccountOutput{}, nil)
+
+	o := &accountAssignOptions{}
+	o.awsClient = mockAWSClient
+	o.username = "tuser"
+
+	_, err := o.assignAccount("r-abcd", "ou-abcd-vnjfdshs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, phase := range []string{"discovery", "tag", "move"} {
+		if _, ok := o.timings.durations[phase]; !ok {
+			t.Errorf("expected the %s phase to be timed", phase)
+		}
+	}
+	// A pooled account is never created
+	if _, ok := o.timings.durations["create"]; ok {
+		t.Errorf("expected the create phase not to be timed")
+	}
+
+	out := &bytes.Buffer{}
+	o.timings.write(out, assignPhases)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(assignPhases)+1 {
+		t.Fatalf("expected a header and one line per phase, got:\n%s", out.String())
+	}
+	for i, phase := range assignPhases {
+		if !strings.HasPrefix(strings.TrimSpace(lines[i+1]), phase+": ") {
+			t.Errorf("expected line %d to time the %s phase, got '%s'", i+1, phase, lines[i+1])
+		}
+	}
+}
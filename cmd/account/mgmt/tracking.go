@@ -0,0 +1,77 @@
+package mgmt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var ErrInvalidTrackConfigMap = fmt.Errorf("the tracking ConfigMap must be given as namespace/name")
+
+// assignmentEntry is what the tracking ConfigMap holds for an assigned account, keyed by the account ID
+type assignmentEntry struct {
+	Owner      string    `json:"owner"`
+	AccountID  string    `json:"accountId"`
+	AssignedAt time.Time `json:"assignedAt"`
+}
+
+// assignmentTracker records the accounts assigned by a single invocation in a ConfigMap, if one was given with
+// --track-configmap. The ConfigMap is created if it doesn't exist, and each account replaces its previous entry.
+// A nil *assignmentTracker is valid and does nothing.
+type assignmentTracker struct {
+	client    client.Client
+	namespace string
+	name      string
+}
+
+// newAssignmentTracker returns a tracker writing to the ConfigMap given as namespace/name
+func newAssignmentTracker(configMap string, kubeCli client.Client) (*assignmentTracker, error) {
+	parts := strings.Split(configMap, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, ErrInvalidTrackConfigMap
+	}
+	return &assignmentTracker{client: kubeCli, namespace: parts[0], name: parts[1]}, nil
+}
+
+// accountAssigned writes the entry to the ConfigMap. Someone else assigning at the same time can create or update it
+// concurrently, so the write is retried when it conflicts with theirs.
+func (t *assignmentTracker) accountAssigned(ctx context.Context, entry assignmentEntry) error {
+	if t == nil {
+		return nil
+	}
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	retriable := func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}
+	return retry.OnError(retry.DefaultRetry, retriable, func() error {
+		cm := &corev1.ConfigMap{}
+		err := t.client.Get(ctx, client.ObjectKey{Namespace: t.namespace, Name: t.name}, cm)
+		if apierrors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: t.namespace, Name: t.name},
+				Data:       map[string]string{entry.AccountID: string(value)},
+			}
+			return t.client.Create(ctx, cm)
+		}
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[entry.AccountID] = string(value)
+		return t.client.Update(ctx, cm)
+	})
+}
@@ -0,0 +1,129 @@
+package mgmt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/golang/mock/gomock"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAssignAccountTracksConfigMap(t *testing.T) {
+	const (
+		accountID = "111111111111"
+		rootOu    = "r-abcd"
+		destOu    = "ou-abcd-vnjfdshs"
+	)
+
+	testData := []struct {
+		name    string
+		objects []runtime.Object
+		// kept are the entries of other accounts expected to remain in the ConfigMap
+		kept []string
+	}{
+		{
+			name: "ConfigMap is created when missing",
+		},
+		{
+			name: "existing ConfigMap is updated",
+			objects: []runtime.Object{&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "tracking", Name: "accounts"},
+				Data:       map[string]string{"222222222222": `{"owner":"otheruser","accountId":"222222222222"}`},
+			}},
+			kept: []string{"222222222222"},
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			mocks := setupDefaultMocks(t, []runtime.Object{})
+			mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+
+			mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(
+				&organizations.ListAccountsForParentOutput{
+					Accounts: []*organizations.Account{{Id: aws.String(accountID)}},
+				}, nil)
+			mockAWSClient.EXPECT().ListTagsForResource(gomock.Any()).Return(&organizations.ListTagsForResourceOutput{}, nil).Times(2)
+			mockAWSClient.EXPECT().DescribeAccount(gomock.Any()).Return(&organizations.DescribeAccountOutput{
+				Account: &organizations.Account{
+					Id:     aws.String(accountID),
+					Status: aws.String(organizations.AccountStatusActive),
+				},
+			}, nil)
+			mockAWSClient.EXPECT().TagResource(gomock.Any()).Return(&organizations.TagResourceOutput{}, nil)
+			mockAWSClient.EXPECT().ListParents(gomock.Any()).Return(&organizations.ListParentsOutput{
+				Parents: []*organizations.Parent{{Id: aws.String(rootOu)}},
+			}, nil)
+			mockAWSClient.EXPECT().MoveAccount(gomock.Any()).Return(&organizations.MoveAccountOutput{}, nil)
+
+			scheme := runtime.NewScheme()
+			err := corev1.AddToScheme(scheme)
+			if err != nil {
+				t.Fatalf("failed to add corev1 to scheme: %v", err)
+			}
+			kubeCli := fake.NewFakeClientWithScheme(scheme, test.objects...)
+
+			o := &accountAssignOptions{}
+			o.awsClient = mockAWSClient
+			o.username = "tuser"
+			o.trackConfigMap = "tracking/accounts"
+			o.tracker, err = newAssignmentTracker(o.trackConfigMap, kubeCli)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err = o.assignAccount(rootOu, destOu)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cm := &corev1.ConfigMap{}
+			err = kubeCli.Get(context.TODO(), client.ObjectKey{Namespace: "tracking", Name: "accounts"}, cm)
+			if err != nil {
+				t.Fatalf("expected the tracking ConfigMap to exist: %v", err)
+			}
+			if len(cm.Data) != len(test.kept)+1 {
+				t.Errorf("expected %d entries in the ConfigMap, got %v", len(test.kept)+1, cm.Data)
+			}
+			for _, kept := range test.kept {
+				if _, ok := cm.Data[kept]; !ok {
+					t.Errorf("expected the entry of account %s to be kept, got %v", kept, cm.Data)
+				}
+			}
+			entry := assignmentEntry{}
+			err = json.Unmarshal([]byte(cm.Data[accountID]), &entry)
+			if err != nil {
+				t.Fatalf("expected a JSON entry for account %s, got %q: %v", accountID, cm.Data[accountID], err)
+			}
+			if entry.Owner != "tuser" || entry.AccountID != accountID || !entry.AssignedAt.Equal(o.claimedAt) {
+				t.Errorf("expected the entry to record tuser, %s and %v, got %+v", accountID, o.claimedAt, entry)
+			}
+		})
+	}
+}
+
+func TestNewAssignmentTracker(t *testing.T) {
+	for _, configMap := range []string{"accounts", "tracking/", "/accounts", "tracking/accounts/extra"} {
+		_, err := newAssignmentTracker(configMap, nil)
+		if !errors.Is(err, ErrInvalidTrackConfigMap) {
+			t.Errorf("expected %q to be rejected with ErrInvalidTrackConfigMap, got %v", configMap, err)
+		}
+	}
+
+	tracker, err := newAssignmentTracker("tracking/accounts", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tracker.namespace != "tracking" || tracker.name != "accounts" {
+		t.Errorf("expected namespace tracking and name accounts, got %s and %s", tracker.namespace, tracker.name)
+	}
+}
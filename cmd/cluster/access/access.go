@@ -7,11 +7,13 @@ import (
 	"os"
 	"os/exec"
 	fpath "path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	clustersmgmtv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
-	"github.com/openshift/osdctl/pkg/k8s"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
 	osdctlutil "github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
@@ -32,48 +34,110 @@ const (
 	impersonateUser     = "backplane-cluster-admin"
 	kubeconfigSecretKey = "kubeconfig"
 
-	// PrivateLink "jump pod" configuration
-	jumpImage         = "image-registry.openshift-image-registry.svc:5000/openshift/cli:latest"
+	// PrivateLink "jump pod" configuration. The image can be overridden with --jump-pod-image, jump pods are only ever
+	// selected by their label, never by their image.
+	defaultJumpImage  = "image-registry.openshift-image-registry.svc:5000/openshift/cli:latest"
 	jumpContainerName = "jump"
-	jumpPodLabelKey   = "automated-break-glass-access/cluster"
+	// jumpPodNamePrefix starts the name of every jump pod, it is used to recognize jump pods created before they were
+	// labelled
+	jumpPodNamePrefix = "jumphost-"
 
 	// Lifespan for jump pods in seconds. Currently, PrivateLink jump pods will expire after 8 hours
 	jumpPodLifespan = 28800
+
+	// jumpPodExpiresAtAnnotation holds the unix time at which a jump pod exits. It is mounted into the pod through the
+	// downward API, so 'break-glass extend' can move it without recreating the pod.
+	jumpPodExpiresAtAnnotation = "automated-break-glass-access/expires-at"
+	jumpPodInfoVolume          = "jump-pod-info"
+	jumpPodInfoMountPath       = "/etc/jump-pod"
+	jumpPodExpiresAtFile       = "expires-at"
+
+	// jumpPodOwnerAnnotation holds the username of whoever created a jump pod. Pods are created while impersonating
+	// impersonateUser, so their creator can't be told from the pod otherwise.
+	jumpPodOwnerAnnotation = "automated-break-glass-access/owner"
+
+	// jumpSessionLabelKey marks a namespace created for a single break-glass session, its value is the ID of the
+	// cluster the session was for. 'cleanup --prune-namespace' only ever deletes namespaces carrying it.
+	jumpSessionLabelKey = "automated-break-glass-access/jump-session"
+)
+
+const (
+	// JumpPodLabelKey is the default key of the label set on jump pods, its value is the ID of the cluster the pod
+	// gives access to. Tooling selecting jump pods should use the same key.
+	JumpPodLabelKey = "automated-break-glass-access/cluster"
+	// JumpPodLabelKeyEnvVar overrides JumpPodLabelKey when set, the --jump-pod-label-key flag takes precedence over it
+	JumpPodLabelKeyEnvVar = "OSDCTL_JUMP_POD_LABEL_KEY"
 )
 
+// jumpPodLabelKey is the label key used to create and select jump pods by all the break-glass subcommands
+var jumpPodLabelKey = getDefaultJumpPodLabelKey()
+
+// getDefaultJumpPodLabelKey returns the jump pod label key from the environment, falling back to JumpPodLabelKey
+func getDefaultJumpPodLabelKey() string {
+	val, present := os.LookupEnv(JumpPodLabelKeyEnvVar)
+	if present && val != "" {
+		return val
+	}
+	return JumpPodLabelKey
+}
+
+func init() {
+	// The codes of the errors printed with '-o json', scripts rely on them so they must not change
+	outputflag.RegisterErrorCode("ClusterNamespaceNotFound", ErrClusterNamespaceNotFound)
+	outputflag.RegisterErrorCode("MultipleClusterNamespaces", ErrMultipleClusterNamespaces)
+	outputflag.RegisterErrorCode("EmptyClusterID", ErrEmptyClusterID)
+	outputflag.RegisterErrorCode("PrivateLinkMismatch", ErrPrivateLinkMismatch)
+	outputflag.RegisterErrorCode("NoJumpPods", ErrNoJumpPods)
+	outputflag.RegisterErrorCode("NotPrivateLink", ErrNotPrivateLink)
+	outputflag.RegisterErrorCode("Interrupted", ErrInterrupted)
+	outputflag.RegisterErrorCode("UnsupportedProvider", ErrUnsupportedProvider)
+	outputflag.RegisterErrorCode("MultipleHiveContexts", ErrMultipleHiveContexts)
+}
+
 var (
 	jumpPodPollInterval = 5 * time.Second
 	jumpPodPollTimeout  = 5 * time.Minute
 )
 
 // NewCmdCluster implements the 'cluster access' subcommand
-func NewCmdAccess(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags) *cobra.Command {
+func NewCmdAccess(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	var (
+		clusterID string
+		jumpImage string
+	)
 	accessCmd := &cobra.Command{
 		Use:               "break-glass <cluster identifier>",
 		Short:             "Emergency access to a cluster",
-		Long:              "Obtain emergency credentials to access the given cluster. You must be logged into the cluster's hive shard",
-		Args:              cobra.ExactArgs(1),
+		Long:              "Obtain emergency credentials to access the given cluster. You must be logged into the cluster's hive shard.\nAWS PrivateLink clusters are accessed through a jump pod on hive, other AWS clusters and GCP clusters\nthrough a local kubeconfig. Clusters on other cloud providers are unsupported.\nThe cluster identifier can also be given with --cluster-id.",
+		Args:              cobra.MaximumNArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(accessCmdComplete(cmd, args))
+			clusterIdentifier, err := accessCmdComplete(cmd, args, clusterID)
+			outputflag.CheckErr(globalOpts.Output, err)
 			// Prior to creating k8s client, verify the user has elevated permissions
-			cmdutil.CheckErr(verifyPermissions(streams, flags))
-			client := k8s.NewClient(flags)
+			outputflag.CheckErr(globalOpts.Output, verifyPermissions(streams, flags))
+			client := newClient(flags)
 			clusterAccess := newClusterAccessOptions(client, streams, flags)
-			cmdutil.CheckErr(clusterAccess.Run(cmd, args))
+			clusterAccess.selectCluster = cmdutil.GetFlagBool(cmd, selectFlag)
+			clusterAccess.jumpImage = jumpImage
+			outputflag.CheckErr(globalOpts.Output, clusterAccess.Run(cmd, []string{clusterIdentifier}))
 		},
 	}
-	accessCmd.AddCommand(newCmdCleanup(streams, flags))
+	addClusterIDFlag(accessCmd, &clusterID)
+	accessCmd.PersistentFlags().StringVar(&jumpPodLabelKey, "jump-pod-label-key", jumpPodLabelKey, fmt.Sprintf("Key of the label jump pods are created and selected with, can also be set with $%s", JumpPodLabelKeyEnvVar))
+	accessCmd.Flags().StringVar(&jumpImage, "jump-pod-image", defaultJumpImage, "Image the jump pods of PrivateLink clusters are created from")
+	accessCmd.PersistentFlags().Bool(selectFlag, false, "When several clusters match the identifier, pick one from a numbered list instead of failing")
+	accessCmd.AddCommand(newCmdCleanup(streams, flags, globalOpts))
+	accessCmd.AddCommand(newCmdList(streams, flags, globalOpts))
+	accessCmd.AddCommand(newCmdExtend(streams, flags, globalOpts))
+	accessCmd.AddCommand(newCmdWhoamiAccess(streams, flags, globalOpts))
 
 	return accessCmd
 }
 
-// clusterCmdComplete verifies the command's invocation, returning an error if the usage is invalid
-func accessCmdComplete(cmd *cobra.Command, args []string) error {
-	if len(args) != 1 {
-		return cmdutil.UsageErrorf(cmd, "Exactly one cluster identifier was expected")
-	}
-	return osdctlutil.IsValidClusterKey(args[0])
+// accessCmdComplete verifies the command's invocation, returning the cluster identifier or an error if the usage is invalid
+func accessCmdComplete(cmd *cobra.Command, args []string, clusterID string) (string, error) {
+	return clusterIdentifierFromArgs(cmd, args, clusterID)
 }
 
 // verifyPermissions determines if the user has supplied the correct permissions in order to retrieve a KubeConfig secret from hive.
@@ -109,6 +173,12 @@ type clusterAccessOptions struct {
 	*genericclioptions.ConfigFlags
 	genericclioptions.IOStreams
 	kclient.Client
+
+	selectCluster bool
+	// owner is recorded on the jump pods created, so 'whoami-access' can find them
+	owner string
+	// jumpImage is the image jump pods are created from, defaultJumpImage is used when empty
+	jumpImage string
 }
 
 // newAccessOptions creates a clusterAccessOptions object
@@ -149,16 +219,24 @@ func (c *clusterAccessOptions) Run(cmd *cobra.Command, args []string) error {
 	c.Println(fmt.Sprintf("Retrieving Kubeconfig for cluster '%s'", clusterIdentifier))
 
 	// Connect to ocm
-	conn := osdctlutil.CreateConnection()
+	conn, err := osdctlutil.NewOCMConnection()
+	if err != nil {
+		return err
+	}
 	defer func() {
 		cmdutil.CheckErr(conn.Close())
 	}()
 
-	cluster, err := osdctlutil.GetCluster(conn, clusterIdentifier)
+	cluster, err := getCluster(conn, clusterIdentifier, c.IOStreams, c.selectCluster)
 	if err != nil {
 		return err
 	}
 	c.Println(fmt.Sprintf("Internal Cluster ID: %s", cluster.ID()))
+	err = checkProvider(cluster)
+	if err != nil {
+		return err
+	}
+	c.owner = currentUsername(conn)
 
 	// Retrieve the kubeconfig secret from the cluster's namespace on hive
 	ns, err := getClusterNamespace(c.Client, cluster.ID())
@@ -174,7 +252,7 @@ func (c *clusterAccessOptions) Run(cmd *cobra.Command, args []string) error {
 	c.Println(fmt.Sprintf("Kubeconfig Secret: %s", kubeconfigSecret.Name))
 
 	// If Cluster is PrivateLink - access via jump pod on hive
-	if cluster.AWS().PrivateLink() {
+	if isPrivateLink(cluster) {
 		c.Println("")
 		c.Println("Cluster is PrivateLink, and is only accessible via a jump pod on Hive")
 		return c.createJumpPodAccess(cluster, kubeconfigSecret)
@@ -380,15 +458,25 @@ func saveAsLocalFile(data []byte, path string) error {
 
 // createJumpPod creates a deployment on hive to access a PrivateLink cluster from.
 func (c *clusterAccessOptions) createJumpPod(kubeconfigSecret corev1.Secret, clusterid string) (corev1.Pod, error) {
-	name := fmt.Sprintf("jumphost-%s-%d", time.Now().Format("20060102-150405-"), (time.Now().Nanosecond() / 1000000))
+	name := fmt.Sprintf("%s%s-%d", jumpPodNamePrefix, time.Now().Format("20060102-150405-"), (time.Now().Nanosecond() / 1000000))
 	ns := kubeconfigSecret.Namespace
 	label := map[string]string{jumpPodLabelKey: clusterid}
+	annotations := map[string]string{jumpPodExpiresAtAnnotation: jumpPodExpiresAt(time.Now().Add(jumpPodLifespan * time.Second))}
+	if c.owner != "" {
+		annotations[jumpPodOwnerAnnotation] = c.owner
+	}
+	image := c.jumpImage
+	if image == "" {
+		image = defaultJumpImage
+	}
+	expiresAtPath := fmt.Sprintf("%s/%s", jumpPodInfoMountPath, jumpPodExpiresAtFile)
 
 	deploy := corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: ns,
-			Labels:    label,
+			Name:        name,
+			Namespace:   ns,
+			Labels:      label,
+			Annotations: annotations,
 		},
 		Spec: corev1.PodSpec{
 			Volumes: []corev1.Volume{
@@ -400,14 +488,28 @@ func (c *clusterAccessOptions) createJumpPod(kubeconfigSecret corev1.Secret, clu
 						},
 					},
 				},
+				{
+					Name: jumpPodInfoVolume,
+					VolumeSource: corev1.VolumeSource{
+						DownwardAPI: &corev1.DownwardAPIVolumeSource{
+							Items: []corev1.DownwardAPIVolumeFile{
+								{
+									Path:     jumpPodExpiresAtFile,
+									FieldRef: &corev1.ObjectFieldSelector{FieldPath: fmt.Sprintf("metadata.annotations['%s']", jumpPodExpiresAtAnnotation)},
+								},
+							},
+						},
+					},
+				},
 			},
 			RestartPolicy: corev1.RestartPolicyOnFailure,
 			Containers: []corev1.Container{
 				{
 					Name:    jumpContainerName,
-					Image:   jumpImage,
+					Image:   image,
 					Command: []string{"/bin/sh"},
-					Args:    []string{"-c", fmt.Sprintf("sleep %d", jumpPodLifespan)},
+					// The expiry is re-read on every iteration, so extending it keeps the pod running
+					Args: []string{"-c", fmt.Sprintf(`while [ "$(date +%%s)" -lt "$(cat %s)" ]; do sleep 30; done`, expiresAtPath)},
 					Env: []corev1.EnvVar{
 						{
 							Name:  "KUBECONFIG",
@@ -419,6 +521,10 @@ func (c *clusterAccessOptions) createJumpPod(kubeconfigSecret corev1.Secret, clu
 							Name:      kubeconfigSecretKey,
 							MountPath: "/tmp",
 						},
+						{
+							Name:      jumpPodInfoVolume,
+							MountPath: jumpPodInfoMountPath,
+						},
 					},
 				},
 			},
@@ -428,6 +534,11 @@ func (c *clusterAccessOptions) createJumpPod(kubeconfigSecret corev1.Secret, clu
 	return deploy, err
 }
 
+// jumpPodExpiresAt formats the given time as the value of the expires-at annotation
+func jumpPodExpiresAt(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
 // waitForPod polls until the given pod is ready
 func (c *clusterAccessOptions) waitForJumpPod(pod corev1.Pod, interval time.Duration, timeout time.Duration) error {
 	key := types.NamespacedName{
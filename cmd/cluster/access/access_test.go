@@ -6,6 +6,7 @@ import (
 	"os"
 	fpath "path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -57,7 +58,7 @@ func TestAccessCmdComplete(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		err := accessCmdComplete(&cobra.Command{}, test.Args)
+		_, err := accessCmdComplete(&cobra.Command{}, test.Args, "")
 		if test.ErrorExpected {
 			if err == nil {
 				t.Fatalf("Test '%s' failed. Expected error, but got none", test.Name)
@@ -362,6 +363,7 @@ func TestClusterAccessOptions_createJumpPod(t *testing.T) {
 		flags := genericclioptions.ConfigFlags{}
 		streams := genericclioptions.IOStreams{In: genericclioptions.NewTestIOStreamsDiscard().In, Out: os.Stdout, ErrOut: os.Stderr}
 		access := newClusterAccessOptions(client, streams, &flags)
+		access.owner = "tuser"
 
 		// Generate test objects
 		serverURL := "https://api.test-cluster.fakedomain.devshift.org:6443"
@@ -376,11 +378,25 @@ func TestClusterAccessOptions_createJumpPod(t *testing.T) {
 		}
 
 		// Verify pod was built correctly
-		// Verify volume
-		if len(pod.Spec.Volumes) != 1 {
-			t.Errorf("Unexpected number of volumes: expected 1, got %d", len(pod.Spec.Volumes))
+		// Verify volumes
+		if len(pod.Spec.Volumes) != 2 {
+			t.Errorf("Unexpected number of volumes: expected 2, got %d", len(pod.Spec.Volumes))
 		} else if pod.Spec.Volumes[0].VolumeSource.Secret.SecretName != secretName {
 			t.Errorf("Pod's volume does not reference the kubeconfig secret")
+		} else if pod.Spec.Volumes[1].VolumeSource.DownwardAPI == nil {
+			t.Errorf("Pod's volume does not expose the expires-at annotation")
+		}
+
+		// Verify the pod expires after its lifespan
+		expiresAt, err := strconv.ParseInt(pod.Annotations[jumpPodExpiresAtAnnotation], 10, 64)
+		if err != nil {
+			t.Errorf("Unexpected expires-at annotation: %v", err)
+		} else if remaining := time.Until(time.Unix(expiresAt, 0)); remaining < (jumpPodLifespan-60)*time.Second || remaining > jumpPodLifespan*time.Second {
+			t.Errorf("Unexpected expires-at annotation: expected about %ds from now, got %s", jumpPodLifespan, remaining)
+		}
+
+		if pod.Annotations[jumpPodOwnerAnnotation] != "tuser" {
+			t.Errorf("Unexpected owner annotation: expected 'tuser', got '%s'", pod.Annotations[jumpPodOwnerAnnotation])
 		}
 
 		// Verify container - confirm that the mount path and the environment variables align so users needn't set anything manually for the pod to function
@@ -389,9 +405,12 @@ func TestClusterAccessOptions_createJumpPod(t *testing.T) {
 		}
 
 		container := pod.Spec.Containers[0]
+		if container.Image != defaultJumpImage {
+			t.Errorf("Unexpected image: expected '%s', got '%s'", defaultJumpImage, container.Image)
+		}
 		expectedMountPath := "/tmp"
-		if len(container.VolumeMounts) != 1 {
-			t.Errorf("Unexpected number of volumeMounts: expected 1, got %d", len(container.VolumeMounts))
+		if len(container.VolumeMounts) != 2 {
+			t.Errorf("Unexpected number of volumeMounts: expected 2, got %d", len(container.VolumeMounts))
 		} else if container.VolumeMounts[0].Name != kubeconfigSecretKey {
 			t.Errorf("Unexpected mount name for kubeconfig secret: expected '%s', got '%s'", kubeconfigSecretKey, container.VolumeMounts[0].Name)
 		} else if container.VolumeMounts[0].MountPath != expectedMountPath {
@@ -492,3 +511,15 @@ func generateKubeconfigSecretObjectForTesting(name, namespace, key, serverURL st
 	}
 	return secret, kubeconfig
 }
+
+func TestGetDefaultJumpPodLabelKey(t *testing.T) {
+	t.Setenv(JumpPodLabelKeyEnvVar, "")
+	if key := getDefaultJumpPodLabelKey(); key != JumpPodLabelKey {
+		t.Errorf("Expected the default label key '%s' when $%s is empty, got '%s'", JumpPodLabelKey, JumpPodLabelKeyEnvVar, key)
+	}
+
+	t.Setenv(JumpPodLabelKeyEnvVar, "example.com/break-glass-cluster")
+	if key := getDefaultJumpPodLabelKey(); key != "example.com/break-glass-cluster" {
+		t.Errorf("Expected the label key from $%s, got '%s'", JumpPodLabelKeyEnvVar, key)
+	}
+}
@@ -1,48 +1,276 @@
 package access
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	fpath "path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	sdk "github.com/openshift-online/ocm-sdk-go"
 	clustersmgmtv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
-	"github.com/openshift/osdctl/pkg/k8s"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
 	osdctlutil "github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/util/retry"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	kclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func newCmdCleanup(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags) *cobra.Command {
+// ErrEmptyClusterID is returned instead of acting on jump pods when the cluster has no ID
+var ErrEmptyClusterID = fmt.Errorf("the cluster has no ID, refusing to select jump pods by an empty label")
+
+// ErrInterrupted is returned when the command is interrupted before it finished dropping access
+var ErrInterrupted = fmt.Errorf("interrupted, some operations may be incomplete")
+
+// ErrPrivateLinkMismatch is returned before dropping any access when the cluster's PrivateLink status is not the one
+// given with --expect-privatelink
+var ErrPrivateLinkMismatch = fmt.Errorf("the cluster's PrivateLink status does not match --expect-privatelink")
+
+// nothingToDropExitCode is the exit code used by the cleanup command when there was no access to drop, allowing
+// automation to distinguish it from having dropped access
+const nothingToDropExitCode = 3
+
+// accessKeptExitCode is the exit code used by the cleanup command when access was found, but dropping it was declined
+// for every cluster, so automation doesn't mistake it for having dropped access
+const accessKeptExitCode = 4
+
+// defaultConfirmCount is the number of jump pods above which deleting them requires typing the cluster's name
+const defaultConfirmCount = 10
+
+// The cluster IDs jump pods of PrivateLink clusters can be labelled with, selected with --label-value
+const (
+	labelValueInternal = "internal"
+	labelValueExternal = "external"
+)
+
+// defaultConcurrency is the number of clusters access is dropped from at the same time with --stdin
+const defaultConcurrency = 3
+
+func newCmdCleanup(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	var (
+		dryRun            bool
+		waitForDelete     bool
+		interactive       bool
+		fromStdin         bool
+		clusterID         string
+		confirmCount      int
+		allStale          bool
+		staleAge          time.Duration
+		expectPrivateLink bool
+		keepPods          bool
+		keepKubeconfig    bool
+		pruneNamespace    bool
+		concurrency       int
+		labelValue        string
+		printNamespace    bool
+	)
 	cleanupCmd := &cobra.Command{
 		Use:               "cleanup <cluster identifier>",
 		Short:             "Drop emergency access to a cluster",
-		Long:              "Relinquish emergency access from the given cluster. If the cluster is PrivateLink, it deletes\nall jump pods in the cluster's namespace (because of this, you must be logged into the hive shard\nwhen dropping access for PrivateLink clusters). For non-PrivateLink clusters, the $KUBECONFIG\nenvironment variable is unset, if applicable.",
-		Args:              cobra.ExactArgs(1),
+		Long:              "Relinquish emergency access from the given cluster. If the cluster is PrivateLink, it deletes\nall jump pods in the cluster's namespace on the cluster's hive shard. The shard is looked up in OCM,\nand the kubeconfig context pointing to it is used, unless one is given with --context. If several\ncontexts point to it, the current context is preferred, otherwise the command fails and lists them. If no\ncontext points to it, e.g. when hive is reached through a proxy, the current context is used with a warning. For\nnon-PrivateLink clusters, including GCP clusters, the $KUBECONFIG environment variable is unset, if applicable.\nClusters on other cloud providers are unsupported.\nWith --dry-run, the jump pods or $KUBECONFIG that would be removed are printed and nothing is changed.\nWith --wait-for-delete=false, the command returns as soon as the jump pods' deletion has been requested.\nWith --interactive, each jump pod is confirmed individually, so some can be kept.\nDeleting more jump pods than --confirm-count also requires typing the cluster's name, to guard against\na selector matching more pods than expected.\nWith --stdin, or when the cluster identifier is '-', one cluster identifier per line is read from stdin\nuntil EOF or an empty line, and access is dropped from each of them. Without --as, the first line answers\nthe impersonation prompt. Any confirmation prompts read their answers from the remaining input. Up to\n--concurrency clusters are processed at the same time, with their prompts asked one at a time.\nWith --all-stale, no cluster identifier is given. Every jump pod on the current hive shard older than\n--since is found, and access is dropped from each cluster they were created for.\nWith --expect-privatelink=true or --expect-privatelink=false, the command fails before dropping any\naccess, or logging into hive, if the cluster's PrivateLink status is not the expected one.\nWith --keep-pods, the jump pods of PrivateLink clusters are kept, and with --keep-kubeconfig, $KUBECONFIG\nis left as is for non-PrivateLink clusters.\nWith --prune-namespace, namespaces labelled as jump sessions of a PrivateLink cluster are deleted once no\npods are left in them, after confirmation. The cluster's hive namespace, and other shared namespaces, are\nnever deleted.\nWith --print-namespace, only the hive namespace of each PrivateLink cluster is printed to stdout, and\nconfirmation prompts are printed to stderr, so the namespace can be captured by scripts.\nThe cluster identifier can also be given with --cluster-id.\nExits with code 3 if there was no access to drop, and with code 4 if access was found but dropping it was\ndeclined.",
+		Args:              cobra.MaximumNArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(cleanupCmdComplete(cmd, args))
-			cmdutil.CheckErr(verifyPermissions(streams, flags))
-			client := k8s.NewClient(flags)
-			cleanupAccess := newCleanupAccessOptions(client, streams, flags)
-			cmdutil.CheckErr(cleanupAccess.Run(cmd, args))
+			batch := fromStdin || (len(args) == 1 && args[0] == "-")
+			var clusterIdentifier string
+			if allStale {
+				outputflag.CheckErr(globalOpts.Output, cleanupAllStaleCmdComplete(cmd, args, clusterID, batch, interactive, staleAge))
+			} else if cmd.Flags().Changed("since") {
+				outputflag.CheckErr(globalOpts.Output, outputflag.UsageErrorf(cmd, "--since can only be used with --all-stale"))
+			} else if batch {
+				outputflag.CheckErr(globalOpts.Output, cleanupBatchCmdComplete(cmd, args, clusterID))
+			} else {
+				var err error
+				clusterIdentifier, err = cleanupCmdComplete(cmd, args, clusterID)
+				outputflag.CheckErr(globalOpts.Output, err)
+			}
+			if allStale && cmd.Flags().Changed("expect-privatelink") {
+				outputflag.CheckErr(globalOpts.Output, outputflag.UsageErrorf(cmd, "--expect-privatelink cannot be used with --all-stale"))
+			}
+			if keepPods && keepKubeconfig {
+				outputflag.CheckErr(globalOpts.Output, outputflag.UsageErrorf(cmd, "--keep-pods and --keep-kubeconfig cannot both be set, nothing would be dropped"))
+			}
+			if allStale && (keepPods || keepKubeconfig || pruneNamespace) {
+				outputflag.CheckErr(globalOpts.Output, outputflag.UsageErrorf(cmd, "--keep-pods, --keep-kubeconfig and --prune-namespace cannot be used with --all-stale"))
+			}
+			if keepPods && pruneNamespace {
+				outputflag.CheckErr(globalOpts.Output, outputflag.UsageErrorf(cmd, "--prune-namespace cannot be used with --keep-pods"))
+			}
+			if confirmCount < 0 {
+				outputflag.CheckErr(globalOpts.Output, outputflag.UsageErrorf(cmd, "--confirm-count cannot be negative"))
+			}
+			if concurrency < 1 {
+				outputflag.CheckErr(globalOpts.Output, outputflag.UsageErrorf(cmd, "--concurrency must be at least 1"))
+			}
+			if labelValue != labelValueInternal && labelValue != labelValueExternal {
+				outputflag.CheckErr(globalOpts.Output, outputflag.UsageErrorf(cmd, "--label-value must be %s or %s", labelValueInternal, labelValueExternal))
+			}
+			// Interrupting the command cancels the pending requests and prompts, rather than killing it mid-way
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			cleanupAccess := newCleanupAccessOptions(nil, streams, flags)
+			cleanupAccess.ctx = ctx
+			cleanupAccess.printNamespace = printNamespace
+			outputflag.CheckErr(globalOpts.Output, cleanupAccess.verifyPermissions())
+			// The hive client is built once the cluster's shard is known, unless every cluster on the current shard is
+			// being cleaned up
+			if allStale {
+				cleanupAccess.Client = newClient(flags)
+			}
+			cleanupAccess.dryRun = dryRun
+			cleanupAccess.waitForDelete = waitForDelete
+			cleanupAccess.interactive = interactive
+			cleanupAccess.confirmCount = confirmCount
+			cleanupAccess.quiet = globalOpts.Quiet
+			cleanupAccess.selectCluster = cmdutil.GetFlagBool(cmd, selectFlag)
+			cleanupAccess.keepPods = keepPods
+			cleanupAccess.keepKubeconfig = keepKubeconfig
+			cleanupAccess.pruneNamespace = pruneNamespace
+			cleanupAccess.concurrency = concurrency
+			cleanupAccess.labelValue = labelValue
+			if cmd.Flags().Changed("expect-privatelink") {
+				cleanupAccess.expectPrivateLink = &expectPrivateLink
+			}
+			var (
+				results []CleanupResult
+				err     error
+			)
+			if allStale {
+				results, err = cleanupAccess.RunAllStale(staleAge)
+			} else if batch {
+				results, err = cleanupAccess.RunBatch()
+			} else {
+				var result CleanupResult
+				result, err = cleanupAccess.Run(cmd, []string{clusterIdentifier})
+				results = append(results, result)
+			}
+			outputflag.CheckErr(globalOpts.Output, cleanupAccess.interrupted(err))
+			if !anyAccessFound(results) {
+				os.Exit(nothingToDropExitCode)
+			}
+			if allAccessKept(results) {
+				os.Exit(accessKeptExitCode)
+			}
 		},
 	}
+	cleanupCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the access that would be dropped without dropping it")
+	cleanupCmd.Flags().BoolVar(&waitForDelete, "wait-for-delete", true, "Wait for the jump pods to terminate after deleting them")
+	cleanupCmd.Flags().BoolVar(&interactive, "interactive", false, "Confirm the deletion of each jump pod individually")
+	cleanupCmd.Flags().IntVar(&confirmCount, "confirm-count", defaultConfirmCount, "Require typing the cluster name to delete more than this many jump pods")
+	cleanupCmd.Flags().BoolVar(&fromStdin, "stdin", false, "Read the cluster identifiers to drop access from stdin, one per line")
+	cleanupCmd.Flags().BoolVar(&allStale, "all-stale", false, "Drop access from every cluster with jump pods older than --since on the current hive shard")
+	cleanupCmd.Flags().DurationVar(&staleAge, "since", defaultStaleAge, "With --all-stale, only drop jump pods older than the given duration")
+	cleanupCmd.Flags().BoolVar(&expectPrivateLink, "expect-privatelink", false, "Fail early unless the cluster's PrivateLink status is the given one")
+	cleanupCmd.Flags().BoolVar(&keepPods, "keep-pods", false, "Keep the jump pods of PrivateLink clusters")
+	cleanupCmd.Flags().BoolVar(&keepKubeconfig, "keep-kubeconfig", false, "Leave $KUBECONFIG as is for non-PrivateLink clusters")
+	cleanupCmd.Flags().IntVar(&concurrency, "concurrency", defaultConcurrency, "With --stdin, the number of clusters to drop access from at the same time")
+	cleanupCmd.Flags().StringVar(&labelValue, "label-value", labelValueInternal, "Which cluster ID the jump pod label of PrivateLink clusters is matched against, 'internal' or 'external'")
+	cleanupCmd.Flags().BoolVar(&printNamespace, "print-namespace", false, "Only print the hive namespace of PrivateLink clusters to stdout")
+	cleanupCmd.Flags().BoolVar(&pruneNamespace, "prune-namespace", false, "Also delete the empty jump session namespaces of PrivateLink clusters")
+	addClusterIDFlag(cleanupCmd, &clusterID)
 	return cleanupCmd
 }
 
-func cleanupCmdComplete(cmd *cobra.Command, args []string) error {
-	if len(args) != 1 {
-		return cmdutil.UsageErrorf(cmd, "Exactly one cluster identifier was expected")
+// anyAccessFound returns true if there was access to drop from any of the given clusters
+func anyAccessFound(results []CleanupResult) bool {
+	for _, result := range results {
+		if result.AccessFound {
+			return true
+		}
+	}
+	return false
+}
+
+// allAccessKept returns true if access was found on some of the given clusters, but none of it was dropped. Dry runs
+// don't drop access by design, so their access isn't counted as kept.
+func allAccessKept(results []CleanupResult) bool {
+	kept := false
+	for _, result := range results {
+		if !result.AccessFound || result.DryRun {
+			continue
+		}
+		if result.accessDropped() {
+			return false
+		}
+		kept = true
+	}
+	return kept
+}
+
+// accessDropped returns true if any jump pod, kubeconfig or jump session namespace of the cluster was removed
+func (r CleanupResult) accessDropped() bool {
+	return len(r.PodsDeleted) != 0 || r.KubeconfigUnset || len(r.NamespacesDeleted) != 0
+}
+
+// cleanupCmdComplete verifies the command's invocation, returning the cluster identifier or an error if the usage is invalid
+func cleanupCmdComplete(cmd *cobra.Command, args []string, clusterID string) (string, error) {
+	return clusterIdentifierFromArgs(cmd, args, clusterID)
+}
+
+func cleanupBatchCmdComplete(cmd *cobra.Command, args []string, clusterID string) error {
+	if clusterID != "" || (len(args) == 1 && args[0] != "-") {
+		return outputflag.UsageErrorf(cmd, "A cluster identifier cannot be given when reading them from stdin")
+	}
+	return nil
+}
+
+// cleanupAllStaleCmdComplete verifies the command's invocation with --all-stale, returning an error if the usage is
+// invalid. The clusters are discovered from the jump pods, so none can be given.
+func cleanupAllStaleCmdComplete(cmd *cobra.Command, args []string, clusterID string, batch bool, interactive bool, staleAge time.Duration) error {
+	if len(args) != 0 || clusterID != "" || batch {
+		return outputflag.UsageErrorf(cmd, "--all-stale cannot be used with a cluster identifier, --cluster-id or --stdin")
+	}
+	if interactive {
+		return outputflag.UsageErrorf(cmd, "--all-stale cannot be used with --interactive")
+	}
+	if staleAge <= 0 {
+		return outputflag.UsageErrorf(cmd, "--since must be positive")
+	}
+	return nil
+}
+
+// CleanupResult describes the access dropped from a cluster by the 'cleanup' access subcommand
+type CleanupResult struct {
+	ClusterID   string
+	ClusterName string
+	PrivateLink bool
+	DryRun      bool
+
+	// AccessFound is false if there was no access to drop
+	AccessFound bool
+	// PodsDeleted are the jump pods which were deleted
+	PodsDeleted []string
+	// PodsKept are the jump pods which were found but not deleted, because of a dry run or because deleting them
+	// was declined
+	PodsKept []string
+	// KubeconfigUnset is true if $KUBECONFIG was unset, or had the cluster's kubeconfig removed from it
+	KubeconfigUnset bool
+	// NamespacesDeleted are the jump session namespaces which were deleted
+	NamespacesDeleted []string
+}
+
+// newCleanupResult returns the result of dropping access to the given cluster, before anything is dropped
+func (c *cleanupAccessOptions) newCleanupResult(cluster *clustersmgmtv1.Cluster) CleanupResult {
+	return CleanupResult{
+		ClusterID:   cluster.ID(),
+		ClusterName: cluster.Name(),
+		PrivateLink: isPrivateLink(cluster),
+		DryRun:      c.dryRun,
 	}
-	return osdctlutil.IsValidClusterKey(args[0])
 }
 
 // cleanupAccessOptions contains the objects and information required to drop access to a cluster
@@ -50,6 +278,40 @@ type cleanupAccessOptions struct {
 	*genericclioptions.ConfigFlags
 	genericclioptions.IOStreams
 	kclient.Client
+
+	dryRun        bool
+	waitForDelete bool
+	interactive   bool
+	quiet         bool
+	selectCluster bool
+	// confirmCount is the number of jump pods above which the cluster's name must be typed to delete them
+	confirmCount int
+	// expectPrivateLink is the PrivateLink status the cluster must have for access to be dropped, if set
+	expectPrivateLink *bool
+	// keepPods skips deleting the jump pods of PrivateLink clusters
+	keepPods bool
+	// keepKubeconfig skips unsetting $KUBECONFIG for non-PrivateLink clusters
+	keepKubeconfig bool
+	// pruneNamespace deletes the cluster's jump session namespaces once the jump pods are gone
+	pruneNamespace bool
+	// labelValue is which of the cluster's IDs jump pods are selected by, labelValueExternal selects them by its
+	// external ID and anything else by its internal ID
+	labelValue string
+	// printNamespace prints only the cluster's hive namespace to Out, moving prompts to ErrOut and dropping other output
+	printNamespace bool
+
+	// concurrency is the number of clusters runBatch drops access from at the same time
+	concurrency int
+
+	// ctx is cancelled when the command is interrupted
+	ctx context.Context
+	// reader buffers user input, so successive prompts don't lose lines already read from In
+	reader *bufio.Reader
+	// ioMu serializes output and prompts, as access is dropped from several clusters concurrently. It is shared by
+	// the copies of the options made for each cluster.
+	ioMu *sync.Mutex
+	// envMu serializes changes to $KUBECONFIG across clusters
+	envMu *sync.Mutex
 }
 
 // newCleanupAccessOptions creates a cleanupAccessOptions object
@@ -58,164 +320,758 @@ func newCleanupAccessOptions(client kclient.Client, streams genericclioptions.IO
 		IOStreams:   streams,
 		ConfigFlags: flags,
 		Client:      client,
+
+		waitForDelete: true,
+		confirmCount:  defaultConfirmCount,
+		concurrency:   1,
+		ctx:           context.Background(),
+		ioMu:          &sync.Mutex{},
+		envMu:         &sync.Mutex{},
 	}
 	return c
 }
 
-// Println appends a newline then prints the given msg using the cleanupAccessOptions' IOStreams
+// Println appends a newline then prints the given msg using the cleanupAccessOptions' IOStreams, unless --quiet or
+// --print-namespace is set
 func (c *cleanupAccessOptions) Println(msg string) {
+	if c.quiet || c.printNamespace {
+		return
+	}
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
 	osdctlutil.StreamPrintln(c.IOStreams, msg)
 }
 
-// Println prints the given msg using the cleanupAccessOptions' IOStreams
+// Print prints the given msg using the cleanupAccessOptions' IOStreams. It is used for prompts, so it ignores --quiet
 func (c *cleanupAccessOptions) Print(msg string) {
-	osdctlutil.StreamPrint(c.IOStreams, msg)
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
+	osdctlutil.StreamPrint(c.promptStreams(), msg)
+}
+
+// promptStreams returns the IOStreams prompts are printed with. With --print-namespace, they are printed to ErrOut so
+// Out only holds the namespace.
+func (c *cleanupAccessOptions) promptStreams() genericclioptions.IOStreams {
+	if !c.printNamespace {
+		return c.IOStreams
+	}
+	return genericclioptions.IOStreams{In: c.In, Out: c.ErrOut, ErrOut: c.ErrOut}
+}
+
+// PrintNamespace prints the given namespace on its own line using the cleanupAccessOptions' IOStreams, if
+// --print-namespace is set
+func (c *cleanupAccessOptions) PrintNamespace(namespace string) {
+	if !c.printNamespace {
+		return
+	}
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
+	osdctlutil.StreamPrintln(c.IOStreams, namespace)
 }
 
 // Println appends a newline then prints the given error msg using the cleanupAccessOptions' IOStreams
 func (c *cleanupAccessOptions) Errorln(msg string) {
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
 	osdctlutil.StreamErrorln(c.IOStreams, msg)
 }
 
+// prompt prints the given question and reads the answer. No other output is printed until the question is answered,
+// so the answer can't be meant for another cluster's prompt.
+func (c *cleanupAccessOptions) prompt(question string) (string, error) {
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
+	osdctlutil.StreamPrint(c.promptStreams(), question)
+	return c.Readln()
+}
+
 // Readln reads a single line of user input using the cleanupAccessOptions' IOStreams. User input is returned with all
-// proceeding and following whitespace trimmed
+// proceeding and following whitespace trimmed. It stops waiting for input when the command is interrupted.
 func (c *cleanupAccessOptions) Readln() (string, error) {
-	in, err := osdctlutil.StreamRead(c.IOStreams, '\n')
-	return strings.TrimSpace(in), err
+	c.bufferIn()
+	type line struct {
+		in  string
+		err error
+	}
+	read := make(chan line, 1)
+	go func() {
+		in, err := c.reader.ReadString('\n')
+		read <- line{in: in, err: err}
+	}()
+	select {
+	case <-c.ctx.Done():
+		return "", ErrInterrupted
+	case l := <-read:
+		return strings.TrimSpace(l.in), l.err
+	}
+}
+
+// bufferIn wraps In in the reader shared by every prompt, if it isn't already
+func (c *cleanupAccessOptions) bufferIn() {
+	if c.reader == nil {
+		c.reader = bufio.NewReader(c.In)
+	}
+}
+
+// bufferedPromptStreams returns the prompt streams reading from the reader shared by every prompt, for prompts asked
+// outside of the cleanupAccessOptions, so they don't read the lines meant for the following prompts
+func (c *cleanupAccessOptions) bufferedPromptStreams() genericclioptions.IOStreams {
+	c.bufferIn()
+	streams := c.promptStreams()
+	streams.In = c.reader
+	return streams
+}
+
+// verifyPermissions asks whether to impersonate, unless --as is set. With --stdin, the answer is read from the
+// same buffered reader as the cluster identifiers, so the identifiers following it are left for runBatch.
+func (c *cleanupAccessOptions) verifyPermissions() error {
+	return verifyPermissions(c.bufferedPromptStreams(), c.ConfigFlags)
+}
+
+// resolveCluster resolves the cluster with the given identifier. With --select, the cluster picker reads from the
+// same buffered reader as the other prompts, and picks one cluster at a time like they do.
+func (c *cleanupAccessOptions) resolveCluster(clusteridentifier string) (*clustersmgmtv1.Cluster, *sdk.Connection, error) {
+	if !c.selectCluster {
+		return resolveCluster(clusteridentifier, c.IOStreams, false)
+	}
+	streams := c.bufferedPromptStreams()
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
+	return resolveCluster(clusteridentifier, streams, true)
 }
 
-// Run executes the 'cleanup' access subcommand
-func (c *cleanupAccessOptions) Run(cmd *cobra.Command, args []string) error {
-	clusteridentifier := args[0]
+// interrupted returns ErrInterrupted in place of the given error if the command was interrupted, as the error is then
+// only a consequence of the interruption
+func (c *cleanupAccessOptions) interrupted(err error) error {
+	if err != nil && c.ctx.Err() != nil {
+		return ErrInterrupted
+	}
+	return err
+}
+
+// Run executes the 'cleanup' access subcommand, returning what was dropped from the cluster
+func (c *cleanupAccessOptions) Run(cmd *cobra.Command, args []string) (CleanupResult, error) {
+	return c.dropAccess(args[0])
+}
+
+// RunBatch executes the 'cleanup' access subcommand against each cluster identifier read from stdin, returning what
+// was dropped from each of the clusters which could be processed
+func (c *cleanupAccessOptions) RunBatch() ([]CleanupResult, error) {
+	return c.runBatch(func(clusterIdentifier string) (CleanupResult, error) {
+		// The hive client is set for each cluster, so each cluster gets its own copy of the options
+		worker := *c
+		return worker.dropAccess(clusterIdentifier)
+	})
+}
+
+// runBatch reads cluster identifiers until EOF or an empty line, then calls drop for each valid one, running up to
+// c.concurrency of them at the same time. Clusters that fail do not stop the remaining ones from being processed;
+// their errors are reported together, in the order the clusters were read.
+func (c *cleanupAccessOptions) runBatch(drop func(clusterIdentifier string) (CleanupResult, error)) ([]CleanupResult, error) {
+	identifiers := []string{}
+	for {
+		in, err := c.Readln()
+		if in != "" {
+			identifiers = append(identifiers, in)
+		}
+		if err == io.EOF || (err == nil && in == "") {
+			break
+		}
+		if err != nil {
+			c.Errorln("Failed to read cluster identifiers")
+			return nil, err
+		}
+	}
+	if len(identifiers) == 0 {
+		return nil, fmt.Errorf("no cluster identifiers were read from stdin")
+	}
+
+	concurrency := c.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	// Each cluster's outcome is stored at its index, so it is reported in the order the clusters were read
+	var (
+		dropped     = make([]bool, len(identifiers))
+		outcomes    = make([]CleanupResult, len(identifiers))
+		errs        = make([]error, len(identifiers))
+		slots       = make(chan struct{}, concurrency)
+		wg          sync.WaitGroup
+		interrupted bool
+	)
+	for i, identifier := range identifiers {
+		if err := osdctlutil.IsValidClusterKey(identifier); err != nil {
+			errs[i] = err
+			continue
+		}
+		slots <- struct{}{}
+		if c.ctx.Err() != nil {
+			interrupted = true
+			break
+		}
+		wg.Add(1)
+		go func(i int, identifier string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			outcomes[i], errs[i] = drop(identifier)
+			dropped[i] = errs[i] == nil
+			if errs[i] != nil {
+				c.Errorln(fmt.Sprintf("Failed to drop access to cluster '%s': %v", identifier, errs[i]))
+			}
+		}(i, identifier)
+	}
+	wg.Wait()
 
-	conn := osdctlutil.CreateConnection()
+	results := []CleanupResult{}
+	failures := []string{}
+	for i, identifier := range identifiers {
+		if dropped[i] {
+			results = append(results, outcomes[i])
+		} else if errs[i] != nil {
+			failures = append(failures, fmt.Sprintf("cluster '%s': %v", identifier, errs[i]))
+		}
+	}
+	if interrupted {
+		return results, ErrInterrupted
+	}
+	if len(failures) > 0 {
+		return results, fmt.Errorf("failed to drop access to %d of %d clusters:\n%s", len(failures), len(identifiers), strings.Join(failures, "\n"))
+	}
+	return results, nil
+}
+
+// dropAccess drops access to the cluster with the given identifier
+func (c *cleanupAccessOptions) dropAccess(clusteridentifier string) (CleanupResult, error) {
+	cluster, conn, err := c.resolveCluster(clusteridentifier)
+	if err != nil {
+		return CleanupResult{}, err
+	}
 	defer func() {
 		cmdutil.CheckErr(conn.Close())
 	}()
 
-	cluster, err := osdctlutil.GetCluster(conn, clusteridentifier)
+	err = checkProvider(cluster)
 	if err != nil {
-		return err
+		return c.newCleanupResult(cluster), err
 	}
+	err = c.checkPrivateLinkExpectation(cluster)
+	if err != nil {
+		return c.newCleanupResult(cluster), err
+	}
+
 	c.Println(fmt.Sprintf("Dropping access to cluster '%s'", cluster.Name()))
-	if cluster.AWS().PrivateLink() {
-		return c.dropPrivateLinkAccess(cluster)
+	if isPrivateLink(cluster) {
+		if c.Client == nil && !c.keepPods {
+			// Clusters can live on different hive shards, so the client is only kept for this cluster
+			c.Client, err = newHiveClient(conn, c.ConfigFlags, cluster.ID(), c.Errorln)
+			if err != nil {
+				return c.newCleanupResult(cluster), err
+			}
+			defer func() {
+				c.Client = nil
+			}()
+		}
+		result, err := c.dropPrivateLinkAccess(cluster)
+		if err != nil || !c.pruneNamespace {
+			return result, err
+		}
+		return c.pruneJumpSessionNamespaces(result)
 	} else {
+		c.envMu.Lock()
+		defer c.envMu.Unlock()
 		return c.dropLocalAccess(cluster)
 	}
 }
 
+// deleteAllJumpPods deletes every pod matched by the given list options. Controllers recreating or finalizing
+// the pods can make the request conflict with them, so it is retried a bounded number of times on conflict.
+func (c *cleanupAccessOptions) deleteAllJumpPods(listOpts kclient.ListOptions) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pod := corev1.Pod{}
+		return c.Client.DeleteAllOf(c.ctx, &pod, &kclient.DeleteAllOfOptions{ListOptions: listOpts})
+	})
+}
+
+// checkPrivateLinkExpectation returns ErrPrivateLinkMismatch if an expected PrivateLink status was given and the
+// cluster's differs from it
+func (c *cleanupAccessOptions) checkPrivateLinkExpectation(cluster *clustersmgmtv1.Cluster) error {
+	if c.expectPrivateLink == nil || *c.expectPrivateLink == isPrivateLink(cluster) {
+		return nil
+	}
+	c.Errorln(fmt.Sprintf("Cluster '%s' has PrivateLink %t, but --expect-privatelink=%t was given", cluster.Name(), isPrivateLink(cluster), *c.expectPrivateLink))
+	return ErrPrivateLinkMismatch
+}
+
 // dropPrivateLinkAccess removes access to a PrivateLink cluster.
 // This primarily consists of deleting any jump pods found to be running against the cluster in hive.
-func (c *cleanupAccessOptions) dropPrivateLinkAccess(cluster *clustersmgmtv1.Cluster) error {
+func (c *cleanupAccessOptions) dropPrivateLinkAccess(cluster *clustersmgmtv1.Cluster) (CleanupResult, error) {
+	result := c.newCleanupResult(cluster)
+	if c.keepPods {
+		c.Println("Cluster is PrivateLink - keeping the jump pods, as --keep-pods was given.")
+		return result, nil
+	}
+	// An empty cluster ID would select pods with an empty jump pod label, which may not be jump pods
+	labelValue := c.jumpPodLabelValue(cluster)
+	if labelValue == "" {
+		return result, ErrEmptyClusterID
+	}
 	c.Println("Cluster is PrivateLink - removing jump pods in the cluster's namespace.")
-	ns, err := getClusterNamespace(c.Client, cluster.ID())
+	ns, err := getClusterNamespaceWithRetry(c.ctx, c.Client, cluster.ID())
 	if err != nil {
-		c.Errorln("Failed to retrieve cluster namespace")
-		return err
+		if errors.Is(err, ErrClusterNamespaceNotFound) {
+			c.Errorln(fmt.Sprintf("No namespace was found for cluster '%s' on hive, you are likely logged into the wrong hive shard", cluster.ID()))
+		} else {
+			c.Errorln("Failed to retrieve cluster namespace from hive")
+		}
+		return result, c.interrupted(err)
 	}
+	c.PrintNamespace(ns.Name)
 
 	// Generate label selector to only target pods w/ matching jump pod label
-	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{jumpPodLabelKey: cluster.ID()}}
+	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{jumpPodLabelKey: labelValue}}
 	selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
 	if err != nil {
 		c.Errorln("Failed to convert labelSelector to selector")
-		return err
+		return result, err
 	}
 
 	listOpts := kclient.ListOptions{Namespace: ns.Name, LabelSelector: selector}
 	pods := corev1.PodList{}
-	err = c.Client.List(context.TODO(), &pods, &listOpts)
+	err = c.Client.List(c.ctx, &pods, &listOpts)
+	if err != nil {
+		c.Errorln(fmt.Sprintf("Failed to list pods in cluster namespace '%s'", ns.Name))
+		return result, err
+	}
+	legacyPods, err := c.listLegacyJumpPods(ns.Name, true)
 	if err != nil {
 		c.Errorln(fmt.Sprintf("Failed to list pods in cluster namespace '%s'", ns.Name))
-		return err
+		return result, err
 	}
+	pods.Items = append(pods.Items, legacyPods...)
 
 	numPods := len(pods.Items)
 	if numPods == 0 {
 		c.Println(fmt.Sprintf("No jump pods found running in namespace '%s'.", ns.Name))
 		c.Println("Access has been dropped.")
-		return nil
+		return result, nil
 	}
 
 	c.Println("")
-	c.Println(fmt.Sprintf("This will delete %d pods in the namespace '%s'", numPods, ns.Name))
+	if c.dryRun {
+		c.Println(fmt.Sprintf("Dry run: would delete %d pods in the namespace '%s'", numPods, ns.Name))
+	} else {
+		c.Println(fmt.Sprintf("This will delete %d pods in the namespace '%s'", numPods, ns.Name))
+	}
 	for _, pod := range pods.Items {
-		c.Println(fmt.Sprintf("- %s", pod.Name))
+		c.Println(fmt.Sprintf("- %s (%s)", pod.Name, podNodeDescription(pod)))
 	}
 	c.Println("")
-	c.Print("Continue? [y/N] ")
-	input, err := c.Readln()
+	result.AccessFound = true
+	if c.dryRun {
+		c.Println("Access has not been dropped.")
+		result.PodsKept = podNames(pods.Items)
+		return result, nil
+	}
+	if c.interactive {
+		return c.dropJumpPodsInteractively(result, pods.Items)
+	}
+	input, err := c.prompt(fmt.Sprintf("Drop access to cluster '%s'? [y/N] ", cluster.Name()))
 	if err != nil {
 		c.Errorln("Failed to read user input")
-		return err
+		return result, err
 	}
-	if isAffirmative(input) {
-		pod := corev1.Pod{}
-		err = c.Client.DeleteAllOf(context.TODO(), &pod, &kclient.DeleteAllOfOptions{ListOptions: listOpts})
+	confirmed := isAffirmative(input)
+	if confirmed && numPods > c.confirmCount {
+		confirmed, err = c.confirmClusterName(cluster, numPods)
+		if err != nil {
+			c.Errorln("Failed to read user input")
+			return result, err
+		}
+	}
+	if confirmed {
+		// Some pods may have been removed since they were listed (ie - by a previous, interrupted cleanup),
+		// so re-list them to accurately report how many are actually being removed
+		remaining := corev1.PodList{}
+		err = c.Client.List(c.ctx, &remaining, &listOpts)
+		if err != nil {
+			c.Errorln(fmt.Sprintf("Failed to list pods in cluster namespace '%s'", ns.Name))
+			return result, err
+		}
+		remainingLegacy, err := c.listLegacyJumpPods(ns.Name, false)
+		if err != nil {
+			c.Errorln(fmt.Sprintf("Failed to list pods in cluster namespace '%s'", ns.Name))
+			return result, err
+		}
+		numPods = len(remaining.Items) + len(remainingLegacy)
+		if numPods == 0 {
+			c.Println("All jump pods have already been removed.")
+			c.Println("Access has been dropped.")
+			result.AccessFound = false
+			return result, nil
+		}
+
+		err = c.deleteAllJumpPods(listOpts)
 		if err != nil {
 			c.Errorln("Failed to delete pod(s)")
-			return err
+			return result, err
+		}
+		// Legacy jump pods aren't matched by the label selector, so they are deleted one by one
+		deletedLegacy, err := c.deleteJumpPods(remainingLegacy)
+		if err != nil {
+			c.Errorln("Failed to delete pod(s)")
+			return result, err
+		}
+		result.PodsDeleted = podNames(append(remaining.Items, deletedLegacy...))
+
+		if !c.waitForDelete {
+			c.Println(fmt.Sprintf("Requested deletion of %d pod(s). They terminate asynchronously and may still be running.", numPods))
+			c.Println("Access has been dropped.")
+			return result, nil
 		}
 
 		c.Println(fmt.Sprintf("Waiting for %d pod(s) to terminate", numPods))
-		err = wait.PollImmediate(jumpPodPollInterval, jumpPodPollTimeout, func() (done bool, err error) {
+		err = wait.PollImmediateWithContext(c.ctx, jumpPodPollInterval, jumpPodPollTimeout, func(ctx context.Context) (done bool, err error) {
 			// For some reason, we have to recreate the podList after deleting the pods, otherwise the listOpts don't filter properly,
 			// and we end up waiting for irrelevant pods. I've tried reproducing this bug in other places, but I haven't been able to
 			// figure it out. If someone does, please fix it.
 			pods := corev1.PodList{}
-			err = c.Client.List(context.TODO(), &pods, &listOpts)
+			err = c.Client.List(ctx, &pods, &listOpts)
 			if err != nil || len(pods.Items) != 0 {
 				return false, err
 			}
 			return true, nil
 		})
+		if err == nil {
+			err = c.waitForPodsDeleted(deletedLegacy)
+		}
 		if err != nil {
 			c.Errorln("Error while waiting for pods to terminate")
-			return err
+			return result, c.interrupted(err)
 		}
+		c.Println(fmt.Sprintf("Removed %d pod(s).", numPods))
 		c.Println("Access has been dropped.")
 	} else {
 		c.Println("Access has not been dropped.")
+		result.PodsKept = podNames(pods.Items)
 	}
-	return nil
+	return result, nil
+}
+
+// jumpPodLabelValue returns the value of the jump pod label selecting the cluster's jump pods, its external ID with
+// --label-value external and its internal ID otherwise
+func (c *cleanupAccessOptions) jumpPodLabelValue(cluster *clustersmgmtv1.Cluster) string {
+	if c.labelValue == labelValueExternal {
+		return cluster.ExternalID()
+	}
+	return cluster.ID()
+}
+
+// listLegacyJumpPods lists the pods of the namespace which have no jump pod label but are named like jump pods, or are
+// owned by an object named like one, as jump pods created before they were labelled are. Pods labelled for any
+// cluster are left to the label selector. When report is set, each pod matched this way is logged.
+func (c *cleanupAccessOptions) listLegacyJumpPods(namespace string, report bool) ([]corev1.Pod, error) {
+	pods := corev1.PodList{}
+	err := c.Client.List(c.ctx, &pods, &kclient.ListOptions{Namespace: namespace})
+	if err != nil {
+		return nil, err
+	}
+	legacy := []corev1.Pod{}
+	for _, pod := range pods.Items {
+		if _, ok := pod.Labels[jumpPodLabelKey]; ok {
+			continue
+		}
+		match := legacyJumpPodMatch(pod)
+		if match == "" {
+			continue
+		}
+		if report {
+			c.Println(fmt.Sprintf("Pod '%s' has no '%s' label, matched as a legacy jump pod by %s.", pod.Name, jumpPodLabelKey, match))
+		}
+		legacy = append(legacy, pod)
+	}
+	return legacy, nil
+}
+
+// legacyJumpPodMatch returns how the unlabelled pod was recognized as a jump pod, or an empty string if it wasn't
+func legacyJumpPodMatch(pod corev1.Pod) string {
+	if strings.HasPrefix(pod.Name, jumpPodNamePrefix) {
+		return "its name"
+	}
+	for _, owner := range pod.OwnerReferences {
+		if strings.HasPrefix(owner.Name, jumpPodNamePrefix) {
+			return fmt.Sprintf("its owner %s '%s'", owner.Kind, owner.Name)
+		}
+	}
+	return ""
+}
+
+// pruneJumpSessionNamespaces deletes the namespaces labelled as jump sessions of the result's cluster. Deleting a
+// namespace deletes everything in it, so a namespace is kept if it is also a cluster's hive namespace, is a shared
+// namespace, still has any pod in it, or deleting it isn't confirmed.
+func (c *cleanupAccessOptions) pruneJumpSessionNamespaces(result CleanupResult) (CleanupResult, error) {
+	// An empty cluster ID would select namespaces with an empty jump session label
+	if result.ClusterID == "" {
+		return result, ErrEmptyClusterID
+	}
+	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{jumpSessionLabelKey: result.ClusterID}}
+	selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+	if err != nil {
+		c.Errorln("Failed to convert labelSelector to selector")
+		return result, err
+	}
+	namespaces := corev1.NamespaceList{}
+	err = c.Client.List(c.ctx, &namespaces, &kclient.ListOptions{LabelSelector: selector})
+	if err != nil {
+		c.Errorln("Failed to list jump session namespaces")
+		return result, err
+	}
+
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		if ns.DeletionTimestamp != nil {
+			continue
+		}
+		if _, ok := ns.Labels[hiveNSLabelKey]; ok || isSharedNamespace(ns.Name) {
+			c.Println(fmt.Sprintf("Keeping namespace '%s': it is shared, not a jump session namespace.", ns.Name))
+			continue
+		}
+		pods := corev1.PodList{}
+		err = c.Client.List(c.ctx, &pods, &kclient.ListOptions{Namespace: ns.Name})
+		if err != nil {
+			c.Errorln(fmt.Sprintf("Failed to list pods in namespace '%s'", ns.Name))
+			return result, err
+		}
+		if len(pods.Items) != 0 {
+			c.Println(fmt.Sprintf("Keeping namespace '%s': %d pod(s) are still in it.", ns.Name, len(pods.Items)))
+			continue
+		}
+
+		result.AccessFound = true
+		if c.dryRun {
+			c.Println(fmt.Sprintf("Dry run: would delete the jump session namespace '%s'", ns.Name))
+			continue
+		}
+		input, err := c.prompt(fmt.Sprintf("Delete the jump session namespace '%s'? [y/N] ", ns.Name))
+		if err != nil {
+			c.Errorln("Failed to read user input")
+			return result, err
+		}
+		if !isAffirmative(input) {
+			c.Println(fmt.Sprintf("Keeping namespace '%s'.", ns.Name))
+			continue
+		}
+		err = c.Client.Delete(c.ctx, ns)
+		if err != nil && !apierrors.IsNotFound(err) {
+			c.Errorln(fmt.Sprintf("Failed to delete namespace '%s'", ns.Name))
+			return result, err
+		}
+		result.NamespacesDeleted = append(result.NamespacesDeleted, ns.Name)
+		c.Println(fmt.Sprintf("Deleted namespace '%s'.", ns.Name))
+	}
+	return result, nil
+}
+
+// isSharedNamespace returns true for the namespaces of the platform and hive, which are never jump session namespaces
+func isSharedNamespace(name string) bool {
+	return name == "default" || name == "hive" || strings.HasPrefix(name, "kube-") || strings.HasPrefix(name, "openshift")
+}
+
+// confirmClusterName asks for the cluster's name to be typed before deleting more jump pods than the confirmCount,
+// returning true if it was typed correctly
+func (c *cleanupAccessOptions) confirmClusterName(cluster *clustersmgmtv1.Cluster, numPods int) (bool, error) {
+	input, err := c.prompt(fmt.Sprintf("%d pods is more than the --confirm-count of %d. Type the cluster name '%s' to continue: ", numPods, c.confirmCount, cluster.Name()))
+	if err != nil {
+		return false, err
+	}
+	if input != cluster.Name() {
+		c.Println("The cluster name did not match.")
+		return false, nil
+	}
+	return true, nil
+}
+
+// dropJumpPodsInteractively prompts for each of the given pods, deleting only those confirmed, and records them in
+// the given result
+func (c *cleanupAccessOptions) dropJumpPodsInteractively(result CleanupResult, pods []corev1.Pod) (CleanupResult, error) {
+	deleted := []corev1.Pod{}
+	for i := range pods {
+		pod := pods[i]
+		input, err := c.prompt(fmt.Sprintf("Delete pod '%s' in namespace '%s'? [y/N] ", pod.Name, pod.Namespace))
+		if err != nil {
+			c.Errorln("Failed to read user input")
+			return result, err
+		}
+		if !isAffirmative(input) {
+			result.PodsKept = append(result.PodsKept, pod.Name)
+			continue
+		}
+
+		err = c.Client.Delete(c.ctx, &pod)
+		if err != nil {
+			// The pod may have been removed since it was listed
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			c.Errorln(fmt.Sprintf("Failed to delete pod '%s'", pod.Name))
+			return result, err
+		}
+		deleted = append(deleted, pod)
+	}
+	result.PodsDeleted = podNames(deleted)
+
+	kept := len(pods) - len(deleted)
+	if len(deleted) == 0 {
+		c.Println("No pods were deleted.")
+		c.Println("Access has not been dropped.")
+		return result, nil
+	}
+
+	if !c.waitForDelete {
+		c.Println(fmt.Sprintf("Requested deletion of %d pod(s). They terminate asynchronously and may still be running.", len(deleted)))
+	} else {
+		c.Println(fmt.Sprintf("Waiting for %d pod(s) to terminate", len(deleted)))
+		err := c.waitForPodsDeleted(deleted)
+		if err != nil {
+			c.Errorln("Error while waiting for pods to terminate")
+			return result, err
+		}
+		c.Println(fmt.Sprintf("Removed %d pod(s).", len(deleted)))
+	}
+
+	if kept != 0 {
+		c.Println(fmt.Sprintf("Kept %d pod(s), access has not been fully dropped.", kept))
+	} else {
+		c.Println("Access has been dropped.")
+	}
+	return result, nil
+}
+
+// waitForPodsDeleted polls until none of the given pods exist anymore
+func (c *cleanupAccessOptions) waitForPodsDeleted(pods []corev1.Pod) error {
+	err := wait.PollImmediateWithContext(c.ctx, jumpPodPollInterval, jumpPodPollTimeout, func(ctx context.Context) (done bool, err error) {
+		for _, pod := range pods {
+			err = c.Client.Get(ctx, kclient.ObjectKeyFromObject(&pod), &corev1.Pod{})
+			if err == nil {
+				return false, nil
+			}
+			if !apierrors.IsNotFound(err) {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+	return c.interrupted(err)
+}
+
+// podNames returns the names of the given pods
+func podNames(pods []corev1.Pod) []string {
+	names := []string{}
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+// podNodeDescription describes the node the pod is running on, for correlating jump pods with node issues
+func podNodeDescription(pod corev1.Pod) string {
+	if pod.Spec.NodeName == "" {
+		return "unscheduled"
+	}
+	return fmt.Sprintf("node: %s", pod.Spec.NodeName)
 }
 
 // dropLocalAccess removes access to a non-PrivateLink cluster.
 // Basically it just unsets KUBECONFIG if it appears to be set to the given cluster, since we can't make assumptions
-// around local files.
-func (c *cleanupAccessOptions) dropLocalAccess(cluster *clustersmgmtv1.Cluster) error {
+// around local files. No access is found if KUBECONFIG did not point to the cluster.
+func (c *cleanupAccessOptions) dropLocalAccess(cluster *clustersmgmtv1.Cluster) (CleanupResult, error) {
+	result := c.newCleanupResult(cluster)
+	if c.keepKubeconfig {
+		c.Println("Cluster is not PrivateLink - leaving $KUBECONFIG as is, as --keep-kubeconfig was given.")
+		return result, nil
+	}
 	c.Println("Unsetting $KUBECONFIG for cluster")
 	kubeconfigPath, found := os.LookupEnv("KUBECONFIG")
 	if !found {
 		c.Errorln("'KUBECONFIG' unset. Access appears to have already been dropped.")
-		return nil
+		return result, nil
 	}
 
-	kubeconfigFileName := fpath.Base(kubeconfigPath)
-	if !strings.Contains(kubeconfigFileName, cluster.Name()) {
-		c.Errorln(fmt.Sprintf("'KUBECONFIG' set to '%s', which does not seem to be the kubeconfig for '%s'. Access assumed to have already been dropped.", kubeconfigFileName, cluster.Name()))
+	// $KUBECONFIG may list several files, only the cluster's own are removed from it
+	matched, remaining := splitClusterKubeconfigs(kubeconfigPath, cluster.Name())
+	if len(matched) == 0 {
+		c.Errorln(fmt.Sprintf("'KUBECONFIG' set to '%s', which does not seem to be the kubeconfig for '%s'. Access assumed to have already been dropped.", kubeconfigPath, cluster.Name()))
 		c.Errorln("(If you think this is a mistake, you can still manually drop access by running `unset KUBECONFIG` in the affected terminals)")
-		return nil
+		return result, nil
 	}
 
-	c.Print(fmt.Sprintf("$KUBECONFIG set to '%s'. Unset it? [y/N]", kubeconfigPath))
-	input, err := c.Readln()
+	result.AccessFound = true
+	var question string
+	if len(remaining) == 0 {
+		if c.dryRun {
+			c.Println(fmt.Sprintf("Dry run: would unset $KUBECONFIG, which is set to '%s'", kubeconfigPath))
+			c.Println("Access has not been dropped.")
+			return result, nil
+		}
+
+		question = fmt.Sprintf("$KUBECONFIG set to '%s'. Unset it? [y/N]", kubeconfigPath)
+	} else {
+		c.Errorln(fmt.Sprintf("Warning: $KUBECONFIG lists %d files, only '%s' belong to '%s'", len(matched)+len(remaining), strings.Join(matched, "', '"), cluster.Name()))
+		if c.dryRun {
+			c.Println(fmt.Sprintf("Dry run: would remove '%s' from $KUBECONFIG, leaving '%s'", strings.Join(matched, "', '"), strings.Join(remaining, string(os.PathListSeparator))))
+			c.Println("Access has not been dropped.")
+			return result, nil
+		}
+
+		question = fmt.Sprintf("Remove '%s' from $KUBECONFIG? [y/N]", strings.Join(matched, "', '"))
+	}
+	input, err := c.prompt(question)
 	if err != nil {
 		c.Errorln("Failed to read user input")
-		return err
+		return result, err
 	}
 
 	if isAffirmative(input) {
-		c.Println("Unsetting $KUBECONFIG")
-		err = os.Unsetenv("KUBECONFIG")
-		if err != nil {
-			c.Errorln("Failed to unset $KUBECONFIG")
-			return err
+		if len(remaining) == 0 {
+			c.Println("Unsetting $KUBECONFIG")
+			err = os.Unsetenv("KUBECONFIG")
+			if err != nil {
+				c.Errorln("Failed to unset $KUBECONFIG")
+				return result, err
+			}
+			c.Println("Successfully unset $KUBECONFIG.")
+			result.KubeconfigUnset = true
+		} else {
+			kubeconfigPath = strings.Join(remaining, string(os.PathListSeparator))
+			err = os.Setenv("KUBECONFIG", kubeconfigPath)
+			if err != nil {
+				c.Errorln("Failed to update $KUBECONFIG")
+				return result, err
+			}
+			c.Println(fmt.Sprintf("Successfully set $KUBECONFIG to '%s'.", kubeconfigPath))
+			result.KubeconfigUnset = true
 		}
-		c.Println("Successfully unset $KUBECONFIG.")
 	}
 
+	if !result.KubeconfigUnset {
+		c.Println("Access has not been dropped.")
+		return result, nil
+	}
 	c.Println("Access has been dropped.")
-	return nil
+	return result, nil
+}
+
+// splitClusterKubeconfigs splits a $KUBECONFIG value into the entries which look like the given cluster's kubeconfig
+// and the remaining ones. Empty entries are dropped.
+func splitClusterKubeconfigs(kubeconfig string, clusterName string) (matched []string, remaining []string) {
+	for _, path := range fpath.SplitList(kubeconfig) {
+		if path == "" {
+			continue
+		}
+		if strings.Contains(fpath.Base(path), clusterName) {
+			matched = append(matched, path)
+		} else {
+			remaining = append(remaining, path)
+		}
+	}
+	return matched, remaining
 }
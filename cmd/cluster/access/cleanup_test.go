@@ -1,19 +1,32 @@
 package access
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	clustersmgmtv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	osdctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
 )
 
 func TestCleanupAccessOptions_dropPrivateLinkAccess(t *testing.T) {
@@ -22,9 +35,11 @@ func TestCleanupAccessOptions_dropPrivateLinkAccess(t *testing.T) {
 	)
 
 	tests := []struct {
-		Name              string
-		Pods              []metav1.ObjectMeta
-		ExpectedPodsAfter []string
+		Name                string
+		Pods                []metav1.ObjectMeta
+		ExpectedPodsAfter   []string
+		ExpectedPodsDeleted []string
+		ExpectedAccessFound bool
 	}{
 		{
 			Name: "Single Jump Pod",
@@ -34,12 +49,15 @@ func TestCleanupAccessOptions_dropPrivateLinkAccess(t *testing.T) {
 					Labels: map[string]string{jumpPodLabelKey: clusterid},
 				},
 			},
-			ExpectedPodsAfter: []string{},
+			ExpectedPodsAfter:   []string{},
+			ExpectedPodsDeleted: []string{"jump"},
+			ExpectedAccessFound: true,
 		},
 		{
-			Name:              "No pods",
-			Pods:              []metav1.ObjectMeta{},
-			ExpectedPodsAfter: []string{},
+			Name:                "No pods",
+			Pods:                []metav1.ObjectMeta{},
+			ExpectedPodsAfter:   []string{},
+			ExpectedAccessFound: false,
 		},
 		{
 			Name: "Mixed use pods",
@@ -54,7 +72,9 @@ func TestCleanupAccessOptions_dropPrivateLinkAccess(t *testing.T) {
 					Labels: map[string]string{"a-provisioning-pod-label": "testing"},
 				},
 			},
-			ExpectedPodsAfter: []string{"provision"},
+			ExpectedPodsAfter:   []string{"provision"},
+			ExpectedPodsDeleted: []string{"jump"},
+			ExpectedAccessFound: true,
 		},
 		{
 			Name: "Multiple jump pods",
@@ -68,7 +88,9 @@ func TestCleanupAccessOptions_dropPrivateLinkAccess(t *testing.T) {
 					Labels: map[string]string{jumpPodLabelKey: clusterid},
 				},
 			},
-			ExpectedPodsAfter: []string{},
+			ExpectedPodsAfter:   []string{},
+			ExpectedPodsDeleted: []string{"jump1", "jump2"},
+			ExpectedAccessFound: true,
 		},
 	}
 
@@ -108,12 +130,21 @@ func TestCleanupAccessOptions_dropPrivateLinkAccess(t *testing.T) {
 		cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
 
 		// Run test
-		err = cleanupAccess.dropPrivateLinkAccess(&cluster)
+		result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
 
 		// Verify results
 		if err != nil {
 			t.Fatalf("Failed '%s': unexpected error encountered: %v", test.Name, err)
 		}
+		if result.AccessFound != test.ExpectedAccessFound {
+			t.Errorf("Failed '%s': expected access found to be %t, got %t", test.Name, test.ExpectedAccessFound, result.AccessFound)
+		}
+		if strings.Join(result.PodsDeleted, ",") != strings.Join(test.ExpectedPodsDeleted, ",") {
+			t.Errorf("Failed '%s': expected pods %v to be reported as deleted, got %v", test.Name, test.ExpectedPodsDeleted, result.PodsDeleted)
+		}
+		if result.ClusterID != clusterid || !result.PrivateLink {
+			t.Errorf("Failed '%s': expected the result to describe PrivateLink cluster '%s', got %+v", test.Name, clusterid, result)
+		}
 
 		// Verify only expected pods remain
 		podsAfter := corev1.PodList{}
@@ -132,3 +163,1628 @@ func TestCleanupAccessOptions_dropPrivateLinkAccess(t *testing.T) {
 		}
 	}
 }
+
+// vanishingPodClient wraps a client and deletes the named pod once the first pod listing has completed,
+// simulating a pod disappearing between the time it's listed and the time it's deleted
+type vanishingPodClient struct {
+	kclient.Client
+	vanishingPod corev1.Pod
+	podLists     int
+}
+
+func (v *vanishingPodClient) List(ctx context.Context, list kclient.ObjectList, opts ...kclient.ListOption) error {
+	err := v.Client.List(ctx, list, opts...)
+	if _, ok := list.(*corev1.PodList); ok && err == nil {
+		v.podLists++
+		if v.podLists == 1 {
+			return v.Client.Delete(ctx, &v.vanishingPod)
+		}
+	}
+	return err
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_PodsAlreadyGone(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	objs := []runtime.Object{&ns}
+	pods := []corev1.Pod{}
+	for _, name := range []string{"jump1", "jump2", "jump3"} {
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns.Name,
+				Labels:    map[string]string{jumpPodLabelKey: clusterid},
+			},
+		}
+		pods = append(pods, pod)
+		objs = append(objs, &pod)
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := &vanishingPodClient{
+		Client:       fake.NewFakeClientWithScheme(scheme, objs...),
+		vanishingPod: pods[2],
+	}
+
+	out := &bytes.Buffer{}
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: out, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if !result.AccessFound {
+		t.Errorf("Expected access to be found")
+	}
+	if strings.Join(result.PodsDeleted, ",") != "jump1,jump2" {
+		t.Errorf("Expected only the pods still present to be reported as deleted, got %v", result.PodsDeleted)
+	}
+
+	if !strings.Contains(out.String(), "This will delete 3 pods") {
+		t.Errorf("Expected all 3 pods to be listed before deletion, got output:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Removed 2 pod(s).") {
+		t.Errorf("Expected 2 pods to be reported as removed, got output:\n%s", out.String())
+	}
+
+	podsAfter := corev1.PodList{}
+	err = client.List(context.TODO(), &podsAfter)
+	if err != nil {
+		t.Fatalf("Error while listing pods after testing: %v", err)
+	}
+	if len(podsAfter.Items) != 0 {
+		t.Errorf("Expected no pods to remain, got %d", len(podsAfter.Items))
+	}
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_AnyImage(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	objs := []runtime.Object{&ns}
+	for name, image := range map[string]string{"jump1": defaultJumpImage, "jump2": "quay.io/example/jump:latest"} {
+		objs = append(objs, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns.Name,
+				Labels:    map[string]string{jumpPodLabelKey: clusterid},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: jumpContainerName, Image: image}},
+			},
+		})
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := fake.NewFakeClientWithScheme(scheme, objs...)
+
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: os.Stdout, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	deleted := append([]string{}, result.PodsDeleted...)
+	sort.Strings(deleted)
+	if !reflect.DeepEqual(deleted, []string{"jump1", "jump2"}) {
+		t.Errorf("Expected the jump pods of both images to be deleted, got %v", result.PodsDeleted)
+	}
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_LegacyPods(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "jump1", Namespace: ns.Name, Labels: map[string]string{jumpPodLabelKey: clusterid}}},
+		// Predates the label, only its name tells it is a jump pod
+		{ObjectMeta: metav1.ObjectMeta{Name: "jumphost-20200101-120000-1", Namespace: ns.Name}},
+		// Predates the label, owned by a jump pod's replica set
+		{ObjectMeta: metav1.ObjectMeta{
+			Name:            "legacy-abcde",
+			Namespace:       ns.Name,
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "jumphost-legacy", APIVersion: "apps/v1", UID: "uid"}},
+		}},
+		// Not a jump pod
+		{ObjectMeta: metav1.ObjectMeta{Name: "hive-provision", Namespace: ns.Name}},
+		// Labelled for another cluster, the fallback must not select it by its name
+		{ObjectMeta: metav1.ObjectMeta{Name: "jumphost-other", Namespace: ns.Name, Labels: map[string]string{jumpPodLabelKey: "other-cluster"}}},
+	}
+	objs := []runtime.Object{&ns}
+	for i := range pods {
+		objs = append(objs, &pods[i])
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := fake.NewFakeClientWithScheme(scheme, objs...)
+
+	var out bytes.Buffer
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: &out, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	deleted := append([]string{}, result.PodsDeleted...)
+	sort.Strings(deleted)
+	expected := []string{"jump1", "jumphost-20200101-120000-1", "legacy-abcde"}
+	if !reflect.DeepEqual(deleted, expected) {
+		t.Errorf("Expected %v to be deleted, got %v", expected, deleted)
+	}
+	for _, name := range []string{"hive-provision", "jumphost-other"} {
+		err = client.Get(context.TODO(), kclient.ObjectKey{Namespace: ns.Name, Name: name}, &corev1.Pod{})
+		if err != nil {
+			t.Errorf("Expected pod '%s' to be kept, got %v", name, err)
+		}
+	}
+	if !strings.Contains(out.String(), "Pod 'jumphost-20200101-120000-1' has no '"+jumpPodLabelKey+"' label, matched as a legacy jump pod by its name") {
+		t.Errorf("Expected the legacy pod matched by its name to be logged, got %q", out.String())
+	}
+	if !strings.Contains(out.String(), "matched as a legacy jump pod by its owner ReplicaSet 'jumphost-legacy'") {
+		t.Errorf("Expected the legacy pod matched by its owner to be logged, got %q", out.String())
+	}
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_ExternalLabelValue(t *testing.T) {
+	const (
+		clusterid  = "fake-cluster-uuid-12345"
+		externalid = "fake-external-uuid-67890"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "jump-external", Namespace: ns.Name, Labels: map[string]string{jumpPodLabelKey: externalid}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "jump-internal", Namespace: ns.Name, Labels: map[string]string{jumpPodLabelKey: clusterid}}},
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := fake.NewFakeClientWithScheme(scheme, &ns, &pods[0], &pods[1])
+
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: os.Stdout, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+	cleanupAccess.labelValue = labelValueExternal
+
+	cluster, err := clustersmgmtv1.NewCluster().
+		Name("fake-cluster").
+		ID(clusterid).
+		ExternalID(externalid).
+		AWS(clustersmgmtv1.NewAWS().PrivateLink(true)).
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build cluster: %v", err)
+	}
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if !reflect.DeepEqual(result.PodsDeleted, []string{"jump-external"}) {
+		t.Errorf("Expected only the pod labelled with the external ID to be deleted, got %v", result.PodsDeleted)
+	}
+	err = client.Get(context.TODO(), kclient.ObjectKey{Namespace: ns.Name, Name: "jump-internal"}, &corev1.Pod{})
+	if err != nil {
+		t.Errorf("Expected the pod labelled with the internal ID to be kept, got %v", err)
+	}
+}
+
+func TestCleanupAccessOptions_dropLocalAccess(t *testing.T) {
+	tests := []struct {
+		Name                string
+		Kubeconfig          string
+		Input               string
+		ExpectedAccessFound bool
+		ExpectUnset         bool
+		ExpectedKubeconfig  string
+		// ExpectedKubeconfigUnset is whether the result reports the cluster's kubeconfig as removed from KUBECONFIG
+		ExpectedKubeconfigUnset bool
+	}{
+		{
+			Name:                "KUBECONFIG unset",
+			Kubeconfig:          "",
+			ExpectedAccessFound: false,
+		},
+		{
+			Name:                "KUBECONFIG set to another cluster",
+			Kubeconfig:          "/tmp/another-cluster-kubeconfig",
+			ExpectedAccessFound: false,
+		},
+		{
+			Name:                    "KUBECONFIG set to the cluster",
+			Kubeconfig:              "/tmp/fake-cluster-kubeconfig",
+			Input:                   "y\n",
+			ExpectedAccessFound:     true,
+			ExpectUnset:             true,
+			ExpectedKubeconfigUnset: true,
+		},
+		{
+			Name:                    "KUBECONFIG lists the cluster among others",
+			Kubeconfig:              "/home/user/.kube/config:/tmp/fake-cluster-kubeconfig:/tmp/another-cluster-kubeconfig",
+			Input:                   "y\n",
+			ExpectedAccessFound:     true,
+			ExpectedKubeconfig:      "/home/user/.kube/config:/tmp/another-cluster-kubeconfig",
+			ExpectedKubeconfigUnset: true,
+		},
+		{
+			Name:                "KUBECONFIG lists the cluster among others, declined",
+			Kubeconfig:          "/home/user/.kube/config:/tmp/fake-cluster-kubeconfig",
+			Input:               "n\n",
+			ExpectedAccessFound: true,
+			ExpectedKubeconfig:  "/home/user/.kube/config:/tmp/fake-cluster-kubeconfig",
+		},
+		{
+			Name:                "KUBECONFIG lists only other clusters",
+			Kubeconfig:          "/home/user/.kube/config:/tmp/another-cluster-kubeconfig",
+			ExpectedAccessFound: false,
+			ExpectedKubeconfig:  "/home/user/.kube/config:/tmp/another-cluster-kubeconfig",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			t.Setenv("KUBECONFIG", test.Kubeconfig)
+			if test.Kubeconfig == "" {
+				os.Unsetenv("KUBECONFIG")
+			}
+
+			streams := genericclioptions.IOStreams{In: strings.NewReader(test.Input), Out: os.Stdout, ErrOut: os.Stderr}
+			flags := genericclioptions.ConfigFlags{}
+			cleanupAccess := newCleanupAccessOptions(nil, streams, &flags)
+
+			cluster := generateClusterObjectForTesting("fake-cluster", "fake-cluster-uuid-12345", false, false)
+
+			result, err := cleanupAccess.dropLocalAccess(&cluster)
+			if err != nil {
+				t.Fatalf("Unexpected error encountered: %v", err)
+			}
+			if result.AccessFound != test.ExpectedAccessFound {
+				t.Errorf("Expected access found to be %t, got %t", test.ExpectedAccessFound, result.AccessFound)
+			}
+			if result.KubeconfigUnset != test.ExpectedKubeconfigUnset {
+				t.Errorf("Expected kubeconfig unset to be %t, got %t", test.ExpectedKubeconfigUnset, result.KubeconfigUnset)
+			}
+			kubeconfig, found := os.LookupEnv("KUBECONFIG")
+			if test.ExpectUnset && found {
+				t.Errorf("Expected KUBECONFIG to be unset")
+			}
+			if test.ExpectedKubeconfig != "" && kubeconfig != test.ExpectedKubeconfig {
+				t.Errorf("Expected KUBECONFIG to be '%s', got '%s'", test.ExpectedKubeconfig, kubeconfig)
+			}
+		})
+	}
+}
+
+// mutationFailingClient wraps a client and fails the test if pods are deleted through it
+type mutationFailingClient struct {
+	kclient.Client
+	t *testing.T
+}
+
+func (m *mutationFailingClient) Delete(ctx context.Context, obj kclient.Object, opts ...kclient.DeleteOption) error {
+	m.t.Errorf("Unexpected Delete of '%s' during dry run", obj.GetName())
+	return nil
+}
+
+func (m *mutationFailingClient) DeleteAllOf(ctx context.Context, obj kclient.Object, opts ...kclient.DeleteAllOfOption) error {
+	m.t.Errorf("Unexpected DeleteAllOf during dry run")
+	return nil
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_DryRun(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	objs := []runtime.Object{&ns}
+	for _, name := range []string{"jump1", "jump2"} {
+		objs = append(objs, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns.Name,
+				Labels:    map[string]string{jumpPodLabelKey: clusterid},
+			},
+		})
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := &mutationFailingClient{
+		Client: fake.NewFakeClientWithScheme(scheme, objs...),
+		t:      t,
+	}
+
+	out := &bytes.Buffer{}
+	// No input is given, as a dry run should not prompt
+	streams := genericclioptions.IOStreams{In: strings.NewReader(""), Out: out, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+	cleanupAccess.dryRun = true
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if !result.AccessFound || !result.DryRun {
+		t.Errorf("Expected access to be found during a dry run, got %+v", result)
+	}
+	if len(result.PodsDeleted) != 0 || strings.Join(result.PodsKept, ",") != "jump1,jump2" {
+		t.Errorf("Expected all pods to be reported as kept, got deleted %v and kept %v", result.PodsDeleted, result.PodsKept)
+	}
+	for _, expected := range []string{"Dry run: would delete 2 pods", "- jump1", "- jump2"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain '%s', got output:\n%s", expected, out.String())
+		}
+	}
+
+	podsAfter := corev1.PodList{}
+	err = client.List(context.TODO(), &podsAfter)
+	if err != nil {
+		t.Fatalf("Error while listing pods after testing: %v", err)
+	}
+	if len(podsAfter.Items) != 2 {
+		t.Errorf("Expected 2 pods to remain, got %d", len(podsAfter.Items))
+	}
+}
+
+func TestCleanupAccessOptions_dropLocalAccess_DryRun(t *testing.T) {
+	kubeconfig := "/tmp/fake-cluster-kubeconfig"
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	out := &bytes.Buffer{}
+	streams := genericclioptions.IOStreams{In: strings.NewReader(""), Out: out, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(nil, streams, &flags)
+	cleanupAccess.dryRun = true
+
+	cluster := generateClusterObjectForTesting("fake-cluster", "fake-cluster-uuid-12345", false, false)
+
+	result, err := cleanupAccess.dropLocalAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if !result.AccessFound {
+		t.Errorf("Expected access to be found")
+	}
+	if result.KubeconfigUnset {
+		t.Errorf("Expected the kubeconfig not to be reported as unset during dry run")
+	}
+	if !strings.Contains(out.String(), fmt.Sprintf("Dry run: would unset $KUBECONFIG, which is set to '%s'", kubeconfig)) {
+		t.Errorf("Expected the kubeconfig to be reported, got output:\n%s", out.String())
+	}
+	if os.Getenv("KUBECONFIG") != kubeconfig {
+		t.Errorf("Expected KUBECONFIG to remain set during dry run")
+	}
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_KeepPods(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jump1",
+			Namespace: ns.Name,
+			Labels:    map[string]string{jumpPodLabelKey: clusterid},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := &mutationFailingClient{
+		Client: fake.NewFakeClientWithScheme(scheme, &ns, &pod),
+		t:      t,
+	}
+
+	out := &bytes.Buffer{}
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: out, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+	cleanupAccess.keepPods = true
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if len(result.PodsDeleted) != 0 {
+		t.Errorf("Expected no pods to be deleted, got %v", result.PodsDeleted)
+	}
+	if !strings.Contains(out.String(), "--keep-pods") {
+		t.Errorf("Expected the jump pods to be reported as kept, got output:\n%s", out.String())
+	}
+
+	podsAfter := corev1.PodList{}
+	err = client.List(context.TODO(), &podsAfter)
+	if err != nil {
+		t.Fatalf("Error while listing pods after testing: %v", err)
+	}
+	if len(podsAfter.Items) != 1 {
+		t.Errorf("Expected the pod to remain, got %d pods", len(podsAfter.Items))
+	}
+}
+
+func TestCleanupAccessOptions_pruneJumpSessionNamespaces(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	hiveNS := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{hiveNSLabelKey: clusterid, jumpSessionLabelKey: clusterid},
+		},
+	}
+	sessionNS := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "jump-session-1",
+			Labels: map[string]string{jumpSessionLabelKey: clusterid},
+		},
+	}
+	busyNS := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "jump-session-2",
+			Labels: map[string]string{jumpSessionLabelKey: clusterid},
+		},
+	}
+	unlabeledNS := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "jump-session-3",
+		},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other",
+			Namespace: busyNS.Name,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := fake.NewFakeClientWithScheme(scheme, &hiveNS, &sessionNS, &busyNS, &unlabeledNS, &pod)
+
+	out := &bytes.Buffer{}
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: out, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+	cleanupAccess.pruneNamespace = true
+
+	result, err := cleanupAccess.pruneJumpSessionNamespaces(CleanupResult{ClusterID: clusterid})
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if !reflect.DeepEqual(result.NamespacesDeleted, []string{sessionNS.Name}) {
+		t.Errorf("Expected only '%s' to be deleted, got %v", sessionNS.Name, result.NamespacesDeleted)
+	}
+
+	nsAfter := corev1.NamespaceList{}
+	err = client.List(context.TODO(), &nsAfter)
+	if err != nil {
+		t.Fatalf("Error while listing namespaces after testing: %v", err)
+	}
+	remaining := []string{}
+	for _, ns := range nsAfter.Items {
+		remaining = append(remaining, ns.Name)
+	}
+	sort.Strings(remaining)
+	expected := []string{busyNS.Name, unlabeledNS.Name, hiveNS.Name}
+	if !reflect.DeepEqual(remaining, expected) {
+		t.Errorf("Expected namespaces %v to remain, got %v", expected, remaining)
+	}
+}
+
+func TestCleanupAccessOptions_dropLocalAccess_KeepKubeconfig(t *testing.T) {
+	kubeconfig := "/tmp/fake-cluster-kubeconfig"
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	out := &bytes.Buffer{}
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: out, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(nil, streams, &flags)
+	cleanupAccess.keepKubeconfig = true
+
+	cluster := generateClusterObjectForTesting("fake-cluster", "fake-cluster-uuid-12345", false, false)
+
+	result, err := cleanupAccess.dropLocalAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if result.KubeconfigUnset {
+		t.Errorf("Expected the kubeconfig not to be reported as unset")
+	}
+	if !strings.Contains(out.String(), "--keep-kubeconfig") {
+		t.Errorf("Expected $KUBECONFIG to be reported as kept, got output:\n%s", out.String())
+	}
+	if os.Getenv("KUBECONFIG") != kubeconfig {
+		t.Errorf("Expected KUBECONFIG to remain set")
+	}
+}
+
+// lingeringPodClient wraps a client and ignores DeleteAllOf, simulating pods which take a long time to terminate
+type lingeringPodClient struct {
+	kclient.Client
+}
+
+func (l *lingeringPodClient) DeleteAllOf(ctx context.Context, obj kclient.Object, opts ...kclient.DeleteAllOfOption) error {
+	return nil
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_NoWait(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jump1",
+			Namespace: ns.Name,
+			Labels:    map[string]string{jumpPodLabelKey: clusterid},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	// The pod never terminates, so waiting for it would time out
+	client := &lingeringPodClient{
+		Client: fake.NewFakeClientWithScheme(scheme, &ns, &pod),
+	}
+
+	out := &bytes.Buffer{}
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: out, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+	cleanupAccess.waitForDelete = false
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if strings.Join(result.PodsDeleted, ",") != "jump1" {
+		t.Errorf("Expected the pod to be reported as deleted, got %v", result.PodsDeleted)
+	}
+	if strings.Contains(out.String(), "Waiting for") {
+		t.Errorf("Expected the wait for termination to be skipped, got output:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "terminate asynchronously") {
+		t.Errorf("Expected asynchronous termination to be noted, got output:\n%s", out.String())
+	}
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_NodeNames(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	scheduled := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jump1",
+			Namespace: ns.Name,
+			Labels:    map[string]string{jumpPodLabelKey: clusterid},
+		},
+		Spec: corev1.PodSpec{NodeName: "ip-10-0-1-1.ec2.internal"},
+	}
+	unscheduled := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jump2",
+			Namespace: ns.Name,
+			Labels:    map[string]string{jumpPodLabelKey: clusterid},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := fake.NewFakeClientWithScheme(scheme, &ns, &scheduled, &unscheduled)
+
+	out := &bytes.Buffer{}
+	// Declining keeps the pods, only the listing is of interest
+	streams := genericclioptions.IOStreams{In: strings.NewReader("n\n"), Out: out, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	_, err = cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	for _, expected := range []string{"- jump1 (node: ip-10-0-1-1.ec2.internal)", "- jump2 (unscheduled)"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain '%s', got output:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_Declined(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jump1",
+			Namespace: ns.Name,
+			Labels:    map[string]string{jumpPodLabelKey: clusterid},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := fake.NewFakeClientWithScheme(scheme, &ns, &pod)
+
+	streams := genericclioptions.IOStreams{In: strings.NewReader("n\n"), Out: os.Stdout, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if !result.AccessFound || len(result.PodsDeleted) != 0 || !reflect.DeepEqual(result.PodsKept, []string{"jump1"}) {
+		t.Errorf("Expected the access to be found and the pod to be kept, got %+v", result)
+	}
+	// The command exits with accessKeptExitCode rather than succeeding
+	if !allAccessKept([]CleanupResult{result}) {
+		t.Errorf("Expected the declined access to be reported as kept")
+	}
+}
+
+func TestAllAccessKept(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Results  []CleanupResult
+		Expected bool
+	}{
+		{Name: "No access found", Results: []CleanupResult{{}}, Expected: false},
+		{Name: "Declined", Results: []CleanupResult{{AccessFound: true, PodsKept: []string{"jump1"}}}, Expected: true},
+		{Name: "Dry run", Results: []CleanupResult{{AccessFound: true, DryRun: true, PodsKept: []string{"jump1"}}}, Expected: false},
+		{Name: "Pods deleted", Results: []CleanupResult{{AccessFound: true, PodsDeleted: []string{"jump1"}}}, Expected: false},
+		{Name: "Kubeconfig unset", Results: []CleanupResult{{AccessFound: true, KubeconfigUnset: true}}, Expected: false},
+		{
+			Name:     "Declined for one cluster, dropped from another",
+			Results:  []CleanupResult{{AccessFound: true, PodsKept: []string{"jump1"}}, {AccessFound: true, PodsDeleted: []string{"jump2"}}},
+			Expected: false,
+		},
+		{
+			Name:     "Declined for one cluster, nothing found on another",
+			Results:  []CleanupResult{{AccessFound: true, PodsKept: []string{"jump1"}}, {}},
+			Expected: true,
+		},
+	}
+	for _, test := range tests {
+		if kept := allAccessKept(test.Results); kept != test.Expected {
+			t.Errorf("Failed '%s': expected %t, got %t", test.Name, test.Expected, kept)
+		}
+	}
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_Interactive(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	objs := []runtime.Object{&ns}
+	for _, name := range []string{"jump1", "jump2", "jump3"} {
+		objs = append(objs, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns.Name,
+				Labels:    map[string]string{jumpPodLabelKey: clusterid},
+			},
+		})
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := fake.NewFakeClientWithScheme(scheme, objs...)
+
+	out := &bytes.Buffer{}
+	// Pods are listed in name order, so only jump2 is confirmed
+	streams := genericclioptions.IOStreams{In: strings.NewReader("n\ny\nn\n"), Out: out, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+	cleanupAccess.interactive = true
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if !result.AccessFound {
+		t.Errorf("Expected access to be found")
+	}
+	if strings.Join(result.PodsDeleted, ",") != "jump2" || strings.Join(result.PodsKept, ",") != "jump1,jump3" {
+		t.Errorf("Expected jump2 to be reported as deleted and jump1,jump3 as kept, got deleted %v and kept %v", result.PodsDeleted, result.PodsKept)
+	}
+
+	podsAfter := corev1.PodList{}
+	err = client.List(context.TODO(), &podsAfter)
+	if err != nil {
+		t.Fatalf("Error while listing pods after testing: %v", err)
+	}
+	remaining := []string{}
+	for _, pod := range podsAfter.Items {
+		remaining = append(remaining, pod.Name)
+	}
+	if strings.Join(remaining, ",") != "jump1,jump3" {
+		t.Errorf("Expected only jump2 to be deleted, remaining pods: %v", remaining)
+	}
+	if !strings.Contains(out.String(), "Kept 2 pod(s)") {
+		t.Errorf("Expected the kept pods to be reported, got output:\n%s", out.String())
+	}
+}
+
+// callCountingClient wraps a client and counts the List and DeleteAllOf calls made through it
+type callCountingClient struct {
+	kclient.Client
+	lists       int
+	deleteAllOf int
+}
+
+func (c *callCountingClient) List(ctx context.Context, list kclient.ObjectList, opts ...kclient.ListOption) error {
+	c.lists++
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *callCountingClient) DeleteAllOf(ctx context.Context, obj kclient.Object, opts ...kclient.DeleteAllOfOption) error {
+	c.deleteAllOf++
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_EmptyClusterID(t *testing.T) {
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	// A pod without the jump pod label, which an empty selector value must not reach
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "provision",
+			Namespace: "uhc-staging-",
+		},
+	}
+	client := &callCountingClient{Client: fake.NewFakeClientWithScheme(scheme, &pod)}
+
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: os.Stdout, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+
+	cluster := generateClusterObjectForTesting("fake-cluster", "", true, false)
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != ErrEmptyClusterID {
+		t.Errorf("Expected error %v, got %v", ErrEmptyClusterID, err)
+	}
+	if result.AccessFound {
+		t.Errorf("Expected no access to be found")
+	}
+	if client.lists != 0 || client.deleteAllOf != 0 {
+		t.Errorf("Expected no List or DeleteAllOf calls, got %d and %d", client.lists, client.deleteAllOf)
+	}
+}
+
+func TestCleanupAccessOptions_runBatch(t *testing.T) {
+	streams := genericclioptions.IOStreams{In: strings.NewReader("cluster-a\ncluster-b\n\ncluster-c\n"), Out: os.Stdout, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(nil, streams, &flags)
+
+	// Identifiers after the empty line are left for confirmation prompts
+	processed := []string{}
+	results, err := cleanupAccess.runBatch(func(clusterIdentifier string) (CleanupResult, error) {
+		processed = append(processed, clusterIdentifier)
+		return CleanupResult{ClusterID: clusterIdentifier, AccessFound: clusterIdentifier == "cluster-b"}, nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].ClusterID != "cluster-a" || results[1].ClusterID != "cluster-b" {
+		t.Fatalf("Expected a result for each of 'cluster-a,cluster-b', got %+v", results)
+	}
+	if !anyAccessFound(results) {
+		t.Errorf("Expected access to be found")
+	}
+	if strings.Join(processed, ",") != "cluster-a,cluster-b" {
+		t.Errorf("Expected clusters 'cluster-a,cluster-b' to be processed, got '%s'", strings.Join(processed, ","))
+	}
+}
+
+// TestCleanupAccessOptions_runBatch_Impersonation answers the impersonation prompt from the same input as the cluster
+// identifiers, which must all be left for runBatch
+func TestCleanupAccessOptions_runBatch_Impersonation(t *testing.T) {
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\ncluster-a\ncluster-b\ncluster-c\n"), Out: os.Stdout, ErrOut: os.Stderr}
+	noUser := ""
+	flags := genericclioptions.ConfigFlags{Impersonate: &noUser}
+	cleanupAccess := newCleanupAccessOptions(nil, streams, &flags)
+
+	err := cleanupAccess.verifyPermissions()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *flags.Impersonate != impersonateUser {
+		t.Errorf("Expected to impersonate '%s', got '%s'", impersonateUser, *flags.Impersonate)
+	}
+
+	processed := []string{}
+	_, err = cleanupAccess.runBatch(func(clusterIdentifier string) (CleanupResult, error) {
+		processed = append(processed, clusterIdentifier)
+		return CleanupResult{ClusterID: clusterIdentifier}, nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(processed, ",") != "cluster-a,cluster-b,cluster-c" {
+		t.Errorf("Expected clusters 'cluster-a,cluster-b,cluster-c' to be processed, got '%s'", strings.Join(processed, ","))
+	}
+}
+
+func TestCleanupAccessOptions_runBatch_Errors(t *testing.T) {
+	streams := genericclioptions.IOStreams{In: strings.NewReader("cluster-a\nnot/valid\ncluster-b\ncluster-c"), Out: os.Stdout, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(nil, streams, &flags)
+
+	processed := []string{}
+	results, err := cleanupAccess.runBatch(func(clusterIdentifier string) (CleanupResult, error) {
+		processed = append(processed, clusterIdentifier)
+		if clusterIdentifier == "cluster-b" {
+			return CleanupResult{}, fmt.Errorf("not found")
+		}
+		return CleanupResult{ClusterID: clusterIdentifier}, nil
+	})
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	for _, want := range []string{"2 of 4 clusters", "cluster 'not/valid'", "cluster 'cluster-b': not found"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got %v", want, err)
+		}
+	}
+	if len(results) != 2 || results[0].ClusterID != "cluster-a" || results[1].ClusterID != "cluster-c" {
+		t.Errorf("Expected results only for the clusters which succeeded, got %+v", results)
+	}
+	if anyAccessFound(results) {
+		t.Errorf("Expected no access to be found")
+	}
+	if strings.Join(processed, ",") != "cluster-a,cluster-b,cluster-c" {
+		t.Errorf("Expected clusters 'cluster-a,cluster-b,cluster-c' to be processed, got '%s'", strings.Join(processed, ","))
+	}
+}
+
+func TestCleanupAccessOptions_runBatch_Concurrent(t *testing.T) {
+	out := &bytes.Buffer{}
+	streams := genericclioptions.IOStreams{In: strings.NewReader("cluster-a\ncluster-b\ncluster-c\n"), Out: out, ErrOut: out}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(nil, streams, &flags)
+	cleanupAccess.concurrency = 3
+
+	// Each cluster waits for all three to be in progress, which only happens if they are dropped concurrently
+	var started sync.WaitGroup
+	started.Add(3)
+	allStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+	results, err := cleanupAccess.runBatch(func(clusterIdentifier string) (CleanupResult, error) {
+		started.Done()
+		select {
+		case <-allStarted:
+		case <-time.After(5 * time.Second):
+			return CleanupResult{}, fmt.Errorf("clusters were not dropped concurrently")
+		}
+		cleanupAccess.Println(fmt.Sprintf("Dropped access to cluster '%s'", clusterIdentifier))
+		if clusterIdentifier == "cluster-b" {
+			return CleanupResult{}, fmt.Errorf("not found")
+		}
+		return CleanupResult{ClusterID: clusterIdentifier, AccessFound: true}, nil
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 clusters") || !strings.Contains(err.Error(), "cluster 'cluster-b': not found") {
+		t.Errorf("Expected only 'cluster-b' to fail, got %v", err)
+	}
+	if len(results) != 2 || results[0].ClusterID != "cluster-a" || results[1].ClusterID != "cluster-c" {
+		t.Errorf("Expected results for 'cluster-a,cluster-c' in the order they were read, got %+v", results)
+	}
+	// Output from the clusters is serialized, so every line is whole
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.HasPrefix(line, "Dropped access to cluster '") && !strings.HasPrefix(line, "Failed to drop access to cluster '") {
+			t.Errorf("Unexpected interleaved output line %q", line)
+		}
+	}
+}
+
+func TestCleanupAccessOptions_Quiet(t *testing.T) {
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	streams := genericclioptions.IOStreams{In: strings.NewReader(""), Out: out, ErrOut: errOut}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(nil, streams, &flags)
+	cleanupAccess.quiet = true
+
+	cleanupAccess.Println("Access has been dropped.")
+	cleanupAccess.Errorln("Failed to read user input")
+	cleanupAccess.Print("Continue? [y/N] ")
+
+	if out.String() != "Continue? [y/N] " {
+		t.Errorf("Expected only the prompt to be printed, got %q", out.String())
+	}
+	if errOut.String() != "Failed to read user input\n" {
+		t.Errorf("Expected the error to be printed, got %q", errOut.String())
+	}
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_PrintNamespace(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jump1",
+			Namespace: ns.Name,
+			Labels:    map[string]string{jumpPodLabelKey: clusterid},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := fake.NewFakeClientWithScheme(scheme, &ns, &pod)
+
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: out, ErrOut: errOut}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+	cleanupAccess.printNamespace = true
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if strings.Join(result.PodsDeleted, ",") != "jump1" {
+		t.Errorf("Expected the jump pod to be deleted, got %v", result.PodsDeleted)
+	}
+	if out.String() != ns.Name+"\n" {
+		t.Errorf("Expected only the namespace to be printed, got %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "Drop access to cluster 'fake-cluster'? [y/N] ") {
+		t.Errorf("Expected the prompt to be printed to stderr, got %q", errOut.String())
+	}
+}
+
+func TestCleanupCmdComplete(t *testing.T) {
+	tests := []struct {
+		Name               string
+		Args               []string
+		ClusterID          string
+		ExpectedIdentifier string
+		ErrorExpected      bool
+	}{
+		{
+			Name:               "Positional identifier",
+			Args:               []string{"testCluster"},
+			ExpectedIdentifier: "testCluster",
+		},
+		{
+			Name:               "Flag identifier",
+			Args:               []string{},
+			ClusterID:          "testCluster",
+			ExpectedIdentifier: "testCluster",
+		},
+		{
+			Name:          "Positional and flag identifiers",
+			Args:          []string{"testCluster"},
+			ClusterID:     "testCluster2",
+			ErrorExpected: true,
+		},
+		{
+			Name:          "No identifier",
+			Args:          []string{},
+			ErrorExpected: true,
+		},
+		{
+			Name:          "Invalid flag identifier",
+			Args:          []string{},
+			ClusterID:     "inv@lid/cluster",
+			ErrorExpected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			identifier, err := cleanupCmdComplete(&cobra.Command{}, test.Args, test.ClusterID)
+			if test.ErrorExpected {
+				if err == nil {
+					t.Fatalf("Expected error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got '%v'", err)
+			}
+			if identifier != test.ExpectedIdentifier {
+				t.Errorf("Expected identifier '%s', got '%s'", test.ExpectedIdentifier, identifier)
+			}
+		})
+	}
+}
+
+func TestSplitClusterKubeconfigs(t *testing.T) {
+	tests := []struct {
+		Name              string
+		Kubeconfig        string
+		ExpectedMatched   []string
+		ExpectedRemaining []string
+	}{
+		{
+			Name:            "Single entry",
+			Kubeconfig:      "/tmp/fake-cluster-kubeconfig",
+			ExpectedMatched: []string{"/tmp/fake-cluster-kubeconfig"},
+		},
+		{
+			Name:              "Multiple entries",
+			Kubeconfig:        "/home/user/.kube/config::/tmp/fake-cluster-kubeconfig",
+			ExpectedMatched:   []string{"/tmp/fake-cluster-kubeconfig"},
+			ExpectedRemaining: []string{"/home/user/.kube/config"},
+		},
+		{
+			Name:              "Directory named after the cluster",
+			Kubeconfig:        "/tmp/fake-cluster/config",
+			ExpectedRemaining: []string{"/tmp/fake-cluster/config"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			matched, remaining := splitClusterKubeconfigs(test.Kubeconfig, "fake-cluster")
+			if strings.Join(matched, ",") != strings.Join(test.ExpectedMatched, ",") {
+				t.Errorf("Expected matched entries %v, got %v", test.ExpectedMatched, matched)
+			}
+			if strings.Join(remaining, ",") != strings.Join(test.ExpectedRemaining, ",") {
+				t.Errorf("Expected remaining entries %v, got %v", test.ExpectedRemaining, remaining)
+			}
+		})
+	}
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_ConfirmCount(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	tests := []struct {
+		Name                string
+		Input               string
+		ExpectedPodsDeleted []string
+	}{
+		{
+			Name:                "Cluster name typed",
+			Input:               "y\nfake-cluster\n",
+			ExpectedPodsDeleted: []string{"jump1", "jump2", "jump3"},
+		},
+		{
+			Name:  "Cluster name mistyped",
+			Input: "y\nother-cluster\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ns := corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+					Labels: map[string]string{"api.openshift.com/id": clusterid},
+				},
+			}
+			objs := []runtime.Object{&ns}
+			for _, name := range []string{"jump1", "jump2", "jump3"} {
+				objs = append(objs, &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: ns.Name,
+						Labels:    map[string]string{jumpPodLabelKey: clusterid},
+					},
+				})
+			}
+
+			scheme := runtime.NewScheme()
+			err := corev1.AddToScheme(scheme)
+			if err != nil {
+				t.Fatalf("Failed to add corev1 to scheme: %v", err)
+			}
+			client := fake.NewFakeClientWithScheme(scheme, objs...)
+
+			out := &bytes.Buffer{}
+			streams := genericclioptions.IOStreams{In: strings.NewReader(test.Input), Out: out, ErrOut: os.Stderr}
+			flags := genericclioptions.ConfigFlags{}
+			cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+			cleanupAccess.confirmCount = 2
+
+			cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+			result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+			if err != nil {
+				t.Fatalf("Unexpected error encountered: %v", err)
+			}
+			if !strings.Contains(out.String(), "Type the cluster name 'fake-cluster' to continue") {
+				t.Errorf("Expected the cluster name to be asked for, got output:\n%s", out.String())
+			}
+			if strings.Join(result.PodsDeleted, ",") != strings.Join(test.ExpectedPodsDeleted, ",") {
+				t.Errorf("Expected pods %v to be reported as deleted, got %v", test.ExpectedPodsDeleted, result.PodsDeleted)
+			}
+
+			podsAfter := corev1.PodList{}
+			err = client.List(context.TODO(), &podsAfter)
+			if err != nil {
+				t.Fatalf("Error while listing pods after testing: %v", err)
+			}
+			if len(podsAfter.Items) != 3-len(test.ExpectedPodsDeleted) {
+				t.Errorf("Expected %d pods to remain, got %d", 3-len(test.ExpectedPodsDeleted), len(podsAfter.Items))
+			}
+		})
+	}
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_LabelKeyOverride(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+	defer func(key string) { jumpPodLabelKey = key }(jumpPodLabelKey)
+	jumpPodLabelKey = "example.com/break-glass-cluster"
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	overridden := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jump",
+			Namespace: ns.Name,
+			Labels:    map[string]string{"example.com/break-glass-cluster": clusterid},
+		},
+	}
+	// Labelled with the default key, which is no longer selected
+	legacy := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "legacy",
+			Namespace: ns.Name,
+			Labels:    map[string]string{JumpPodLabelKey: clusterid},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := fake.NewFakeClientWithScheme(scheme, &ns, &overridden, &legacy)
+
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: os.Stdout, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if strings.Join(result.PodsDeleted, ",") != "jump" {
+		t.Errorf("Expected only the pod with the overridden label key to be deleted, got %v", result.PodsDeleted)
+	}
+}
+
+func TestCleanupAccessOptions_checkPrivateLinkExpectation(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name        string
+		expect      *bool
+		privateLink bool
+		wantErr     error
+	}{
+		{name: "no expectation, PrivateLink", expect: nil, privateLink: true, wantErr: nil},
+		{name: "no expectation, non-PrivateLink", expect: nil, privateLink: false, wantErr: nil},
+		{name: "expected PrivateLink, is PrivateLink", expect: &yes, privateLink: true, wantErr: nil},
+		{name: "expected non-PrivateLink, is non-PrivateLink", expect: &no, privateLink: false, wantErr: nil},
+		{name: "expected PrivateLink, is non-PrivateLink", expect: &yes, privateLink: false, wantErr: ErrPrivateLinkMismatch},
+		{name: "expected non-PrivateLink, is PrivateLink", expect: &no, privateLink: true, wantErr: ErrPrivateLinkMismatch},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errOut := bytes.Buffer{}
+			streams := genericclioptions.IOStreams{In: strings.NewReader(""), Out: os.Stdout, ErrOut: &errOut}
+			flags := genericclioptions.ConfigFlags{}
+			cleanupAccess := newCleanupAccessOptions(nil, streams, &flags)
+			cleanupAccess.expectPrivateLink = test.expect
+
+			cluster := generateClusterObjectForTesting("fake-cluster", "fake-id", test.privateLink, false)
+
+			err := cleanupAccess.checkPrivateLinkExpectation(&cluster)
+			if err != test.wantErr {
+				t.Errorf("Expected error %v, got %v", test.wantErr, err)
+			}
+			if test.wantErr != nil && !strings.Contains(errOut.String(), "--expect-privatelink") {
+				t.Errorf("Expected the mismatch to be reported, got '%s'", errOut.String())
+			}
+		})
+	}
+}
+
+// conflictingClient wraps a client and fails the first conflicts DeleteAllOf calls with a conflict error
+type conflictingClient struct {
+	kclient.Client
+	conflicts   int
+	deleteAllOf int
+}
+
+func (c *conflictingClient) DeleteAllOf(ctx context.Context, obj kclient.Object, opts ...kclient.DeleteAllOfOption) error {
+	c.deleteAllOf++
+	if c.deleteAllOf <= c.conflicts {
+		return apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, "jump1", fmt.Errorf("the object has been modified"))
+	}
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_RetryOnConflict(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jump1",
+			Namespace: ns.Name,
+			Labels:    map[string]string{jumpPodLabelKey: clusterid},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := &conflictingClient{
+		Client:    fake.NewFakeClientWithScheme(scheme, &ns, &pod),
+		conflicts: 1,
+	}
+
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: os.Stdout, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+	if client.deleteAllOf != 2 {
+		t.Errorf("Expected DeleteAllOf to be retried once after the conflict, got %d calls", client.deleteAllOf)
+	}
+	if strings.Join(result.PodsDeleted, ",") != "jump1" {
+		t.Errorf("Expected the pod to be reported as deleted, got %v", result.PodsDeleted)
+	}
+	pods := corev1.PodList{}
+	err = client.List(context.TODO(), &pods)
+	if err != nil {
+		t.Fatalf("Failed to list pods: %v", err)
+	}
+	if len(pods.Items) != 0 {
+		t.Errorf("Expected the jump pod to be deleted, found %d pods", len(pods.Items))
+	}
+}
+
+// namespaceFailingClient fails the first failures namespace listings, counting every namespace listing
+type namespaceFailingClient struct {
+	kclient.Client
+	failures       int
+	namespaceLists int
+}
+
+func (n *namespaceFailingClient) List(ctx context.Context, list kclient.ObjectList, opts ...kclient.ListOption) error {
+	if _, ok := list.(*corev1.NamespaceList); ok {
+		n.namespaceLists++
+		if n.namespaceLists <= n.failures {
+			return apierrors.NewServiceUnavailable("etcd is unavailable")
+		}
+	}
+	return n.Client.List(ctx, list, opts...)
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_NamespaceRetry(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	defaultBackoff := clusterNamespaceBackoff
+	defer func() { clusterNamespaceBackoff = defaultBackoff }()
+	clusterNamespaceBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jump1",
+			Namespace: ns.Name,
+			Labels:    map[string]string{jumpPodLabelKey: clusterid},
+		},
+	}
+
+	tests := []struct {
+		Name               string
+		Objects            []runtime.Object
+		Failures           int
+		ExpectedErr        error
+		ExpectedLists      int
+		ExpectedPodDeleted bool
+	}{
+		{
+			Name:               "Transient failure then success",
+			Objects:            []runtime.Object{&ns, &pod},
+			Failures:           2,
+			ExpectedErr:        nil,
+			ExpectedLists:      3,
+			ExpectedPodDeleted: true,
+		},
+		{
+			Name:          "Namespace never found",
+			Objects:       []runtime.Object{&pod},
+			Failures:      0,
+			ExpectedErr:   ErrClusterNamespaceNotFound,
+			ExpectedLists: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			err := corev1.AddToScheme(scheme)
+			if err != nil {
+				t.Fatalf("Failed to add corev1 to scheme: %v", err)
+			}
+			client := &namespaceFailingClient{
+				Client:   fake.NewFakeClientWithScheme(scheme, test.Objects...),
+				failures: test.Failures,
+			}
+
+			errOut := &bytes.Buffer{}
+			streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: os.Stdout, ErrOut: errOut}
+			flags := genericclioptions.ConfigFlags{}
+			cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+
+			cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+			result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+			if test.ExpectedErr == nil && err != nil {
+				t.Fatalf("Unexpected error encountered: %v", err)
+			}
+			if test.ExpectedErr != nil {
+				if !errors.Is(err, test.ExpectedErr) {
+					t.Fatalf("Expected error '%v', got '%v'", test.ExpectedErr, err)
+				}
+				if !strings.Contains(errOut.String(), "wrong hive shard") {
+					t.Errorf("Expected the wrong hive shard to be suggested, got '%s'", errOut.String())
+				}
+			}
+			if client.namespaceLists != test.ExpectedLists {
+				t.Errorf("Expected the namespace to be looked up %d times, got %d", test.ExpectedLists, client.namespaceLists)
+			}
+			if deleted := strings.Join(result.PodsDeleted, ",") == "jump1"; deleted != test.ExpectedPodDeleted {
+				t.Errorf("Expected the pod to be deleted: %t, got %v", test.ExpectedPodDeleted, result.PodsDeleted)
+			}
+		})
+	}
+}
+
+func TestCleanupAccessOptions_dropPrivateLinkAccess_Interrupted(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("uhc-staging-%s", clusterid),
+			Labels: map[string]string{"api.openshift.com/id": clusterid},
+		},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jump1",
+			Namespace: ns.Name,
+			Labels:    map[string]string{jumpPodLabelKey: clusterid},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	// The pod never terminates, so the wait only ends when the command is interrupted
+	client := &lingeringPodClient{
+		Client: fake.NewFakeClientWithScheme(scheme, &ns, &pod),
+	}
+
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: os.Stdout, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cleanupAccess.ctx = ctx
+
+	cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	result, err := cleanupAccess.dropPrivateLinkAccess(&cluster)
+	if err != ErrInterrupted {
+		t.Fatalf("Expected error %v, got %v", ErrInterrupted, err)
+	}
+	if time.Since(start) > jumpPodPollInterval {
+		t.Errorf("Expected the wait to stop as soon as the command was interrupted, it took %s", time.Since(start))
+	}
+	if !strings.Contains(err.Error(), "some operations may be incomplete") {
+		t.Errorf("Expected the interruption to be described, got '%v'", err)
+	}
+	if strings.Join(result.PodsDeleted, ",") != "jump1" {
+		t.Errorf("Expected the pod whose deletion was requested to be reported, got %v", result.PodsDeleted)
+	}
+}
+
+func TestCleanupAccessOptions_Readln_Interrupted(t *testing.T) {
+	// Nothing is ever typed, so only the interruption ends the prompt
+	in, _ := io.Pipe()
+	streams := genericclioptions.IOStreams{In: in, Out: os.Stdout, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(nil, streams, &flags)
+	ctx, cancel := context.WithCancel(context.Background())
+	cleanupAccess.ctx = ctx
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := cleanupAccess.Readln()
+	if err != ErrInterrupted {
+		t.Errorf("Expected error %v, got %v", ErrInterrupted, err)
+	}
+}
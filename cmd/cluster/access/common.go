@@ -2,10 +2,25 @@ package access
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	clustersmgmtv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/pkg/k8s"
+	osdctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	kclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -13,11 +28,111 @@ const (
 	hiveNSLabelKey = "api.openshift.com/id"
 )
 
+// ErrClusterNamespaceNotFound is returned when hive has no namespace for the cluster. Either hive hasn't created it
+// yet, or, more likely, the current kubeconfig context is not the cluster's hive shard.
+var ErrClusterNamespaceNotFound = fmt.Errorf("no namespace was found for the cluster, check that you are logged into the cluster's hive shard")
+
+// ErrMultipleClusterNamespaces is returned when several hive namespaces are labelled with the cluster's ID
+var ErrMultipleClusterNamespaces = fmt.Errorf("expected exactly 1 namespace for the cluster")
+
+// ErrHiveContextNotFound is returned when no kubeconfig context points at the server of the cluster's hive shard, e.g.
+// because hive is reached through a proxy with another URL
+var ErrHiveContextNotFound = fmt.Errorf("no kubeconfig context found for the hive shard")
+
+// ErrMultipleHiveContexts is returned when several kubeconfig contexts point at the server of the cluster's hive shard,
+// none of them being the current context, e.g. logins of several users
+var ErrMultipleHiveContexts = fmt.Errorf("several kubeconfig contexts point at the hive shard")
+
+// clusterNamespaceBackoff bounds how long getClusterNamespaceWithRetry keeps looking for the cluster's namespace
+var clusterNamespaceBackoff = wait.Backoff{Duration: time.Second, Factor: 2, Steps: 4}
+
 // isAffirmative returns true if the provided input indicates user agreement ("y" or "Y")
 func isAffirmative(input string) bool {
 	return input == "y" || input == "Y"
 }
 
+// The cloud providers break-glass access supports. Only AWS clusters can be PrivateLink and accessed through jump pods,
+// clusters on the other supported providers are accessed through their kubeconfig.
+const (
+	providerAWS = "aws"
+	providerGCP = "gcp"
+)
+
+// ErrUnsupportedProvider is returned before granting or dropping access to a cluster on a cloud provider break-glass
+// access does not handle, or whose cloud provider is unknown
+var ErrUnsupportedProvider = fmt.Errorf("break-glass access is unsupported for the cluster's cloud provider")
+
+// checkProvider returns ErrUnsupportedProvider, naming the cluster's cloud provider, unless it is a supported one
+func checkProvider(cluster *clustersmgmtv1.Cluster) error {
+	provider := cluster.CloudProvider().ID()
+	switch provider {
+	case providerAWS, providerGCP:
+		return nil
+	case "":
+		return fmt.Errorf("%w: cluster '%s' has no cloud provider", ErrUnsupportedProvider, cluster.Name())
+	default:
+		return fmt.Errorf("%w: cluster '%s' is on %s", ErrUnsupportedProvider, cluster.Name(), provider)
+	}
+}
+
+// isPrivateLink returns true if the cluster is an AWS PrivateLink cluster. Clusters on other providers, such as GCP,
+// have no AWS section and are never PrivateLink.
+func isPrivateLink(cluster *clustersmgmtv1.Cluster) bool {
+	aws, ok := cluster.GetAWS()
+	return ok && aws.PrivateLink()
+}
+
+// currentUsername returns the username of the OCM account the connection is authenticated as, falling back to $USER
+// when it can't be retrieved
+func currentUsername(conn *sdk.Connection) string {
+	username, err := osdctlutil.GetCurrentAccountUsername(conn)
+	if err != nil || username == "" {
+		return os.Getenv("USER")
+	}
+	return username
+}
+
+// addClusterIDFlag adds the --cluster-id flag, which can be used instead of the positional cluster identifier
+func addClusterIDFlag(cmd *cobra.Command, clusterID *string) {
+	cmd.Flags().StringVarP(clusterID, "cluster-id", "C", "", "Cluster identifier, instead of giving it as an argument")
+}
+
+// clusterIdentifierFromArgs returns the cluster identifier given either as the only positional argument or with
+// --cluster-id, returning an error if it is given both ways or not at all
+func clusterIdentifierFromArgs(cmd *cobra.Command, args []string, clusterID string) (string, error) {
+	if clusterID != "" {
+		if len(args) != 0 {
+			return "", outputflag.UsageErrorf(cmd, "A cluster identifier cannot be given both as an argument and with --cluster-id")
+		}
+		return clusterID, osdctlutil.IsValidClusterKey(clusterID)
+	}
+	if len(args) != 1 {
+		return "", outputflag.UsageErrorf(cmd, "Exactly one cluster identifier was expected")
+	}
+	return args[0], osdctlutil.IsValidClusterKey(args[0])
+}
+
+// selectFlag lets the operator pick between the clusters matching an ambiguous identifier, it is shared by every
+// break-glass subcommand
+const selectFlag = "select"
+
+// resolveCluster retrieves the cluster identified by key and returns it with the OCM connection used. When pick is
+// set and several clusters match the key, the operator is asked to pick one of them using the given streams.
+func resolveCluster(key string, streams genericclioptions.IOStreams, pick bool) (*clustersmgmtv1.Cluster, *sdk.Connection, error) {
+	if pick {
+		return osdctlutil.ResolveClusterInteractively(key, streams)
+	}
+	return osdctlutil.ResolveCluster(key)
+}
+
+// getCluster is like resolveCluster, using an existing OCM connection
+func getCluster(conn *sdk.Connection, key string, streams genericclioptions.IOStreams, pick bool) (*clustersmgmtv1.Cluster, error) {
+	if pick {
+		return osdctlutil.SelectCluster(conn, key, streams)
+	}
+	return osdctlutil.GetCluster(conn, key)
+}
+
 // getClusterNamespace returns the hive namespace for a cluster given it's internal ID
 func getClusterNamespace(client kclient.Client, clusterid string) (corev1.Namespace, error) {
 	nsList := corev1.NamespaceList{}
@@ -29,11 +144,132 @@ func getClusterNamespace(client kclient.Client, clusterid string) (corev1.Namesp
 
 	err = client.List(context.TODO(), &nsList, &kclient.ListOptions{LabelSelector: selector})
 	if err != nil {
-		return corev1.Namespace{}, err
+		return corev1.Namespace{}, fmt.Errorf("failed to list the namespaces on hive: %w", err)
+	}
+	if len(nsList.Items) == 0 {
+		return corev1.Namespace{}, fmt.Errorf("%w (no namespace is labelled %s=%s)", ErrClusterNamespaceNotFound, hiveNSLabelKey, clusterid)
 	}
 	if len(nsList.Items) != 1 {
-		return corev1.Namespace{}, fmt.Errorf("expected list operation to return exactly 1 namespace, got %d", len(nsList.Items))
+		return corev1.Namespace{}, fmt.Errorf("%w, got %d", ErrMultipleClusterNamespaces, len(nsList.Items))
 	}
 
 	return nsList.Items[0], nil
 }
+
+// getClusterNamespaceWithRetry is like getClusterNamespace, retrying with clusterNamespaceBackoff while the namespace
+// isn't found or listing namespaces fails, in case it is only transient. The error of the last attempt is returned.
+func getClusterNamespaceWithRetry(ctx context.Context, client kclient.Client, clusterid string) (corev1.Namespace, error) {
+	var (
+		ns      corev1.Namespace
+		lastErr error
+	)
+	err := wait.ExponentialBackoffWithContext(ctx, clusterNamespaceBackoff, func() (bool, error) {
+		ns, lastErr = getClusterNamespace(client, clusterid)
+		if lastErr == nil {
+			return true, nil
+		}
+		// Several namespaces won't go away by waiting
+		if errors.Is(lastErr, ErrMultipleClusterNamespaces) {
+			return false, lastErr
+		}
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return corev1.Namespace{}, lastErr
+	}
+	return ns, err
+}
+
+// newClient builds the clients of the access subcommands from the kubeconfig flags, including the global --context
+// flag. It is a variable so tests can check which flags the clients are built with.
+var newClient = k8s.NewClient
+
+// newHiveClient returns a client for the hive shard managing the given cluster. The shard is looked up in OCM and the
+// client is built from the kubeconfig context pointing at it, so operators don't need to switch to it beforehand.
+// If a kubeconfig context was explicitly requested, it is used as-is. If no context points at the shard, the current
+// context is used, and warn is called with a message naming the expected shard.
+func newHiveClient(conn *sdk.Connection, flags *genericclioptions.ConfigFlags, clusterID string, warn func(string)) (kclient.Client, error) {
+	if flags.Context != nil && *flags.Context != "" {
+		return newClient(flags), nil
+	}
+
+	shardResponse, err := conn.ClustersMgmt().V1().Clusters().Cluster(clusterID).ProvisionShard().Get().Send()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the provision shard for cluster '%s': %w", clusterID, err)
+	}
+	return hiveShardClient(flags, shardResponse.Body(), warn)
+}
+
+// hiveShardClient returns a client for the given provision shard's hive cluster, falling back to the current
+// kubeconfig context with a warning when no context points at it
+func hiveShardClient(flags *genericclioptions.ConfigFlags, shard *clustersmgmtv1.ProvisionShard, warn func(string)) (kclient.Client, error) {
+	hiveFlags, err := hiveShardFlags(flags, shard)
+	if errors.Is(err, ErrHiveContextNotFound) {
+		// Operators logged into hive through another URL, such as a proxy, may already be using the right context
+		warn(fmt.Sprintf("Warning: %v, using the current kubeconfig context. Pass --context if it is not the cluster's hive shard.", err))
+		return newClient(flags), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newClient(hiveFlags), nil
+}
+
+// hiveShardFlags returns a copy of the given flags pointing at the kubeconfig context of the given provision shard's
+// hive cluster. The kubeconfig and impersonation settings are kept.
+func hiveShardFlags(flags *genericclioptions.ConfigFlags, shard *clustersmgmtv1.ProvisionShard) (*genericclioptions.ConfigFlags, error) {
+	server, err := hiveShardServer(shard)
+	if err != nil {
+		return nil, err
+	}
+
+	rawConfig, err := flags.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	hiveContext, err := hiveContextForServer(rawConfig, server)
+	if err != nil {
+		return nil, err
+	}
+
+	hiveFlags := genericclioptions.NewConfigFlags(false)
+	hiveFlags.KubeConfig = flags.KubeConfig
+	hiveFlags.Impersonate = flags.Impersonate
+	hiveFlags.Context = &hiveContext
+	return hiveFlags, nil
+}
+
+// hiveShardServer returns the API server URL of the hive cluster belonging to the given provision shard
+func hiveShardServer(shard *clustersmgmtv1.ProvisionShard) (string, error) {
+	server := shard.HiveConfig().Server()
+	if server == "" {
+		return "", fmt.Errorf("provision shard '%s' does not have a hive server", shard.ID())
+	}
+	return server, nil
+}
+
+// hiveContextForServer returns the name of the kubeconfig context whose cluster is served from the given URL. When
+// several contexts are, the current context is preferred, otherwise ErrMultipleHiveContexts lists them so one can be
+// picked with --context, as some of them may hold expired credentials.
+func hiveContextForServer(config clientcmdapi.Config, server string) (string, error) {
+	matching := []string{}
+	for name, kubeContext := range config.Contexts {
+		cluster, found := config.Clusters[kubeContext.Cluster]
+		if found && strings.TrimSuffix(cluster.Server, "/") == strings.TrimSuffix(server, "/") {
+			matching = append(matching, name)
+		}
+	}
+	switch len(matching) {
+	case 0:
+		return "", fmt.Errorf("%w '%s', log into it or pass --context", ErrHiveContextNotFound, server)
+	case 1:
+		return matching[0], nil
+	}
+	for _, name := range matching {
+		if name == config.CurrentContext {
+			return name, nil
+		}
+	}
+	sort.Strings(matching)
+	return "", fmt.Errorf("%w '%s', pass one of '%s' with --context", ErrMultipleHiveContexts, server, strings.Join(matching, "', '"))
+}
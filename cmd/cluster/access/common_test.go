@@ -1,12 +1,20 @@
 package access
 
 import (
+	"errors"
 	"fmt"
+	fpath "path/filepath"
+	"strings"
 	"testing"
 
+	clustersmgmtv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -56,6 +64,106 @@ func TestIsAffirmative(t *testing.T) {
 	}
 }
 
+// TestIsPrivateLink ensures clusters without an AWS section, such as GCP clusters, are routed to the non-PrivateLink path
+func TestIsPrivateLink(t *testing.T) {
+	gcpCluster, err := clustersmgmtv1.NewCluster().
+		Name("fake-gcp-cluster").
+		ID("fake-gcp-cluster-uuid-12345").
+		CloudProvider(clustersmgmtv1.NewCloudProvider().ID("gcp")).
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build cluster: %v", err)
+	}
+	privateLinkCluster := generateClusterObjectForTesting("fake-cluster", "fake-cluster-uuid-12345", true, false)
+	publicCluster := generateClusterObjectForTesting("fake-cluster", "fake-cluster-uuid-12345", false, false)
+
+	tests := []struct {
+		Name           string
+		Cluster        *clustersmgmtv1.Cluster
+		ExpectedResult bool
+	}{
+		{
+			Name:           "GCP cluster",
+			Cluster:        gcpCluster,
+			ExpectedResult: false,
+		},
+		{
+			Name:           "AWS PrivateLink cluster",
+			Cluster:        &privateLinkCluster,
+			ExpectedResult: true,
+		},
+		{
+			Name:           "AWS non-PrivateLink cluster",
+			Cluster:        &publicCluster,
+			ExpectedResult: false,
+		},
+	}
+
+	for _, test := range tests {
+		fmt.Printf("Testing '%s'\n", test.Name)
+		result := isPrivateLink(test.Cluster)
+		if result != test.ExpectedResult {
+			t.Errorf("Failed '%s': expected %t, got %t", test.Name, test.ExpectedResult, result)
+		}
+	}
+}
+
+// TestCheckProvider ensures access is only handled for the cloud providers whose access is known, rather than
+// assuming every cluster is on AWS
+func TestCheckProvider(t *testing.T) {
+	newCluster := func(provider *clustersmgmtv1.CloudProviderBuilder) *clustersmgmtv1.Cluster {
+		builder := clustersmgmtv1.NewCluster().Name("fake-cluster").ID("fake-cluster-uuid-12345")
+		if provider != nil {
+			builder = builder.CloudProvider(provider)
+		}
+		cluster, err := builder.Build()
+		if err != nil {
+			t.Fatalf("Failed to build cluster: %v", err)
+		}
+		return cluster
+	}
+
+	tests := []struct {
+		Name          string
+		Cluster       *clustersmgmtv1.Cluster
+		ErrorExpected bool
+	}{
+		{
+			Name:    "AWS cluster",
+			Cluster: newCluster(clustersmgmtv1.NewCloudProvider().ID("aws")),
+		},
+		{
+			Name:    "GCP cluster",
+			Cluster: newCluster(clustersmgmtv1.NewCloudProvider().ID("gcp")),
+		},
+		{
+			Name:          "Azure cluster",
+			Cluster:       newCluster(clustersmgmtv1.NewCloudProvider().ID("azure")),
+			ErrorExpected: true,
+		},
+		{
+			Name:          "cluster without a cloud provider",
+			Cluster:       newCluster(nil),
+			ErrorExpected: true,
+		},
+	}
+
+	for _, test := range tests {
+		fmt.Printf("Testing '%s'\n", test.Name)
+		err := checkProvider(test.Cluster)
+		if test.ErrorExpected && !errors.Is(err, ErrUnsupportedProvider) {
+			t.Errorf("Failed '%s': expected ErrUnsupportedProvider, got %v", test.Name, err)
+		}
+		if !test.ErrorExpected && err != nil {
+			t.Errorf("Failed '%s': unexpected error: %v", test.Name, err)
+		}
+		// The non-AWS clusters have no AWS section, which must not be dereferenced
+		if isPrivateLink(test.Cluster) {
+			t.Errorf("Failed '%s': expected the cluster not to be PrivateLink", test.Name)
+		}
+	}
+}
+
 // TestGetClusterNamespaces tests that getClusterNamespaces() retrieves the expected ns from hive
 func TestGetClusterNamespaces(t *testing.T) {
 	validClusterid := "fakecluster-123456"
@@ -168,3 +276,261 @@ func TestGetClusterNamespaces(t *testing.T) {
 		}
 	}
 }
+
+// TestHiveShardServer tests that hiveShardServer() resolves the hive server from an OCM provision shard
+func TestHiveShardServer(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Server         string
+		ExpectErr      bool
+		ExpectedServer string
+	}{
+		{
+			Name:           "Shard with hive server",
+			Server:         "https://api.hive-stage-01.n1.s1.devshift.org:6443",
+			ExpectErr:      false,
+			ExpectedServer: "https://api.hive-stage-01.n1.s1.devshift.org:6443",
+		},
+		{
+			Name:      "Shard without hive server",
+			Server:    "",
+			ExpectErr: true,
+		},
+	}
+	for _, test := range tests {
+		fmt.Printf("Testing '%s'\n", test.Name)
+		shard, err := clustersmgmtv1.NewProvisionShard().
+			ID("fake-shard").
+			HiveConfig(clustersmgmtv1.NewServerConfig().Server(test.Server)).
+			Build()
+		if err != nil {
+			t.Fatalf("Failed '%s': could not build provision shard: %v", test.Name, err)
+		}
+
+		// Run test
+		server, err := hiveShardServer(shard)
+
+		// Verify results
+		if test.ExpectErr != (err != nil) {
+			t.Errorf("Failed '%s': expected error to be %t, got %v", test.Name, test.ExpectErr, err)
+		}
+		if server != test.ExpectedServer {
+			t.Errorf("Failed '%s': expected server '%s', got '%s'", test.Name, test.ExpectedServer, server)
+		}
+	}
+}
+
+// TestHiveContextForServer tests that hiveContextForServer() finds the kubeconfig context for a hive shard
+func TestHiveContextForServer(t *testing.T) {
+	config := clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"hive-a": {Server: "https://api.hive-a.devshift.org:6443"},
+			"hive-b": {Server: "https://api.hive-b.devshift.org:6443/"},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"default/hive-a/user": {Cluster: "hive-a"},
+			"default/hive-b/user": {Cluster: "hive-b"},
+		},
+	}
+	tests := []struct {
+		Name            string
+		Server          string
+		ExpectErr       bool
+		ExpectedContext string
+	}{
+		{
+			Name:            "Matching context",
+			Server:          "https://api.hive-a.devshift.org:6443",
+			ExpectedContext: "default/hive-a/user",
+		},
+		{
+			Name:            "Matching context with trailing slash",
+			Server:          "https://api.hive-b.devshift.org:6443",
+			ExpectedContext: "default/hive-b/user",
+		},
+		{
+			Name:      "No matching context",
+			Server:    "https://api.hive-c.devshift.org:6443",
+			ExpectErr: true,
+		},
+	}
+	for _, test := range tests {
+		fmt.Printf("Testing '%s'\n", test.Name)
+
+		// Run test
+		kubeContext, err := hiveContextForServer(config, test.Server)
+
+		// Verify results
+		if test.ExpectErr != (err != nil) {
+			t.Errorf("Failed '%s': expected error to be %t, got %v", test.Name, test.ExpectErr, err)
+		}
+		if kubeContext != test.ExpectedContext {
+			t.Errorf("Failed '%s': expected context '%s', got '%s'", test.Name, test.ExpectedContext, kubeContext)
+		}
+	}
+}
+
+// TestHiveContextForServer_SharedServer tests that hiveContextForServer() picks between contexts of the same hive shard
+// deterministically
+func TestHiveContextForServer_SharedServer(t *testing.T) {
+	config := clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"hive-a": {Server: "https://api.hive-a.devshift.org:6443"},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"default/hive-a/user-a": {Cluster: "hive-a", AuthInfo: "user-a"},
+			"default/hive-a/user-b": {Cluster: "hive-a", AuthInfo: "user-b"},
+		},
+	}
+
+	// The current context is preferred, however the contexts are ordered
+	for i := 0; i < 10; i++ {
+		config.CurrentContext = "default/hive-a/user-b"
+		kubeContext, err := hiveContextForServer(config, "https://api.hive-a.devshift.org:6443")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if kubeContext != "default/hive-a/user-b" {
+			t.Fatalf("Expected the current context 'default/hive-a/user-b', got '%s'", kubeContext)
+		}
+	}
+
+	// Without the current context, the candidates are listed so one can be passed with --context
+	config.CurrentContext = "default/hive-b/user-a"
+	_, err := hiveContextForServer(config, "https://api.hive-a.devshift.org:6443")
+	if !errors.Is(err, ErrMultipleHiveContexts) {
+		t.Fatalf("Expected ErrMultipleHiveContexts, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "'default/hive-a/user-a', 'default/hive-a/user-b'") {
+		t.Errorf("Expected the error to list the contexts in order, got %v", err)
+	}
+}
+
+// TestHiveShardFlags tests that hiveShardFlags() points the hive client at the context of the cluster's provision shard
+func TestHiveShardFlags(t *testing.T) {
+	config := clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"hive-a": {Server: "https://api.hive-a.devshift.org:6443"},
+			"hive-b": {Server: "https://api.hive-b.devshift.org:6443"},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"default/hive-a/user": {Cluster: "hive-a"},
+			"default/hive-b/user": {Cluster: "hive-b"},
+		},
+		// The current context is not the cluster's shard
+		CurrentContext: "default/hive-a/user",
+	}
+	kubeconfig := fpath.Join(t.TempDir(), "config")
+	err := clientcmd.WriteToFile(config, kubeconfig)
+	if err != nil {
+		t.Fatalf("Failed to write kubeconfig: %v", err)
+	}
+
+	shard, err := clustersmgmtv1.NewProvisionShard().
+		ID("fake-shard").
+		HiveConfig(clustersmgmtv1.NewServerConfig().Server("https://api.hive-b.devshift.org:6443")).
+		Build()
+	if err != nil {
+		t.Fatalf("Could not build provision shard: %v", err)
+	}
+
+	impersonate := "backplane-cluster-admin"
+	flags := genericclioptions.NewConfigFlags(false)
+	flags.KubeConfig = &kubeconfig
+	flags.Impersonate = &impersonate
+
+	// Run test
+	hiveFlags, err := hiveShardFlags(flags, shard)
+
+	// Verify results
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if hiveFlags.Context == nil || *hiveFlags.Context != "default/hive-b/user" {
+		t.Errorf("Expected the context of the cluster's shard 'default/hive-b/user', got %v", hiveFlags.Context)
+	}
+	if hiveFlags.KubeConfig == nil || *hiveFlags.KubeConfig != kubeconfig {
+		t.Errorf("Expected the kubeconfig '%s' to be kept, got %v", kubeconfig, hiveFlags.KubeConfig)
+	}
+	if hiveFlags.Impersonate == nil || *hiveFlags.Impersonate != impersonate {
+		t.Errorf("Expected impersonating '%s' to be kept, got %v", impersonate, hiveFlags.Impersonate)
+	}
+	restConfig, err := hiveFlags.ToRESTConfig()
+	if err != nil {
+		t.Fatalf("Failed to build the hive client config: %v", err)
+	}
+	if restConfig.Host != "https://api.hive-b.devshift.org:6443" {
+		t.Errorf("Expected the hive client to target 'https://api.hive-b.devshift.org:6443', got '%s'", restConfig.Host)
+	}
+}
+
+// TestHiveShardClient_NoMatchingContext tests that the current context is used, with a warning naming the shard and
+// --context, when no kubeconfig context points at the shard's server, e.g. when hive is reached through a proxy
+func TestHiveShardClient_NoMatchingContext(t *testing.T) {
+	defer func(f func(*genericclioptions.ConfigFlags) kclient.Client) { newClient = f }(newClient)
+	var builtWith *genericclioptions.ConfigFlags
+	newClient = func(flags *genericclioptions.ConfigFlags) kclient.Client {
+		builtWith = flags
+		return fake.NewFakeClient()
+	}
+
+	config := clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"proxy": {Server: "https://backplane.devshift.org/hive-b"},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"default/proxy/user": {Cluster: "proxy"},
+		},
+		CurrentContext: "default/proxy/user",
+	}
+	kubeconfig := fpath.Join(t.TempDir(), "config")
+	err := clientcmd.WriteToFile(config, kubeconfig)
+	if err != nil {
+		t.Fatalf("Failed to write kubeconfig: %v", err)
+	}
+	shard, err := clustersmgmtv1.NewProvisionShard().
+		ID("fake-shard").
+		HiveConfig(clustersmgmtv1.NewServerConfig().Server("https://api.hive-b.devshift.org:6443")).
+		Build()
+	if err != nil {
+		t.Fatalf("Could not build provision shard: %v", err)
+	}
+	flags := genericclioptions.NewConfigFlags(false)
+	flags.KubeConfig = &kubeconfig
+
+	warnings := []string{}
+	_, err = hiveShardClient(flags, shard, func(msg string) { warnings = append(warnings, msg) })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if builtWith != flags {
+		t.Errorf("Expected the client to be built with the current context, got %v", builtWith)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "https://api.hive-b.devshift.org:6443") || !strings.Contains(warnings[0], "--context") {
+		t.Errorf("Expected a warning naming the shard and --context, got %v", warnings)
+	}
+}
+
+// TestNewHiveClient_ContextOverride tests that a kubeconfig context given with --context is passed to the client
+// factory as-is, without looking up the cluster's shard
+func TestNewHiveClient_ContextOverride(t *testing.T) {
+	defer func(f func(*genericclioptions.ConfigFlags) kclient.Client) { newClient = f }(newClient)
+	var builtWith *genericclioptions.ConfigFlags
+	newClient = func(flags *genericclioptions.ConfigFlags) kclient.Client {
+		builtWith = flags
+		return fake.NewClientBuilder().Build()
+	}
+
+	kubeContext := "default/hive-b/user"
+	flags := genericclioptions.NewConfigFlags(false)
+	flags.Context = &kubeContext
+
+	// A nil OCM connection would panic if the shard were looked up
+	_, err := newHiveClient(nil, flags, "fake-cluster-uuid-12345", func(string) {})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if builtWith == nil || builtWith.Context == nil || *builtWith.Context != kubeContext {
+		t.Errorf("Expected the client to be built with context '%s', got %v", kubeContext, builtWith)
+	}
+}
@@ -0,0 +1,160 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	clustersmgmtv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	osdctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrNoJumpPods is returned when there are no jump pods to act on
+var ErrNoJumpPods = fmt.Errorf("no jump pods were found for the cluster")
+
+// ErrNotPrivateLink is returned when a jump pod operation is requested for a cluster which isn't PrivateLink
+var ErrNotPrivateLink = fmt.Errorf("the cluster is not PrivateLink, so it has no jump pods")
+
+func newCmdExtend(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	var (
+		duration  time.Duration
+		clusterID string
+	)
+	extendCmd := &cobra.Command{
+		Use:               "extend <cluster identifier>",
+		Short:             "Extend emergency access to a PrivateLink cluster",
+		Long:              "Push back the expiry of the jump pods running against the given PrivateLink cluster, instead of dropping\nand recreating them. The cluster's hive shard is looked up in OCM and the kubeconfig context pointing to\nit is used, or the current context if none does, unless one is given with --context. If several contexts\npoint to it, the current context is preferred, otherwise the command fails and lists them. The jump pods then exit once the given --duration has elapsed from now. Jump pods\ncreated without an expires-at annotation cannot be extended and are skipped.\nThe cluster identifier can also be given with --cluster-id.",
+		Args:              cobra.MaximumNArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			clusterIdentifier, err := extendCmdComplete(cmd, args, clusterID, duration)
+			outputflag.CheckErr(globalOpts.Output, err)
+			outputflag.CheckErr(globalOpts.Output, verifyPermissions(streams, flags))
+			// The hive client is built once the cluster's shard is known
+			extendAccess := newExtendAccessOptions(nil, streams, flags)
+			extendAccess.duration = duration
+			extendAccess.selectCluster = cmdutil.GetFlagBool(cmd, selectFlag)
+			outputflag.CheckErr(globalOpts.Output, extendAccess.Run(cmd, []string{clusterIdentifier}))
+		},
+	}
+	addClusterIDFlag(extendCmd, &clusterID)
+	extendCmd.Flags().DurationVar(&duration, "duration", jumpPodLifespan*time.Second, "How long from now the jump pods should keep running")
+	return extendCmd
+}
+
+// extendCmdComplete verifies the command's invocation, returning the cluster identifier or an error if the usage is invalid
+func extendCmdComplete(cmd *cobra.Command, args []string, clusterID string, duration time.Duration) (string, error) {
+	if duration <= 0 {
+		return "", outputflag.UsageErrorf(cmd, "--duration must be positive")
+	}
+	return clusterIdentifierFromArgs(cmd, args, clusterID)
+}
+
+// extendAccessOptions contains the objects and information required to extend access to a cluster
+type extendAccessOptions struct {
+	*genericclioptions.ConfigFlags
+	genericclioptions.IOStreams
+	kclient.Client
+
+	duration      time.Duration
+	selectCluster bool
+}
+
+// newExtendAccessOptions creates an extendAccessOptions object
+func newExtendAccessOptions(client kclient.Client, streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags) extendAccessOptions {
+	e := extendAccessOptions{
+		IOStreams:   streams,
+		ConfigFlags: flags,
+		Client:      client,
+	}
+	return e
+}
+
+// Println appends a newline then prints the given msg using the extendAccessOptions' IOStreams
+func (e *extendAccessOptions) Println(msg string) {
+	osdctlutil.StreamPrintln(e.IOStreams, msg)
+}
+
+// Errorln appends a newline then prints the given error msg using the extendAccessOptions' IOStreams
+func (e *extendAccessOptions) Errorln(msg string) {
+	osdctlutil.StreamErrorln(e.IOStreams, msg)
+}
+
+// Run executes the 'extend' access subcommand
+func (e *extendAccessOptions) Run(cmd *cobra.Command, args []string) error {
+	cluster, conn, err := resolveCluster(args[0], e.IOStreams, e.selectCluster)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cmdutil.CheckErr(conn.Close())
+	}()
+
+	err = checkProvider(cluster)
+	if err != nil {
+		return err
+	}
+	if !isPrivateLink(cluster) {
+		return ErrNotPrivateLink
+	}
+	if e.Client == nil {
+		e.Client, err = newHiveClient(conn, e.ConfigFlags, cluster.ID(), e.Errorln)
+		if err != nil {
+			return err
+		}
+	}
+	return e.extendJumpPods(cluster, time.Now().Add(e.duration))
+}
+
+// extendJumpPods sets the expiry of the cluster's jump pods to the given time
+func (e *extendAccessOptions) extendJumpPods(cluster *clustersmgmtv1.Cluster, expiresAt time.Time) error {
+	ns, err := getClusterNamespace(e.Client, cluster.ID())
+	if err != nil {
+		e.Errorln("Failed to retrieve cluster namespace")
+		return err
+	}
+	listOpts, err := jumpPodListOptions(ns.Name, cluster.ID())
+	if err != nil {
+		return err
+	}
+	pods := corev1.PodList{}
+	err = e.Client.List(context.TODO(), &pods, listOpts)
+	if err != nil {
+		e.Errorln(fmt.Sprintf("Failed to list pods in cluster namespace '%s'", ns.Name))
+		return err
+	}
+	if len(pods.Items) == 0 {
+		return ErrNoJumpPods
+	}
+
+	extended := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		// Older jump pods sleep for a fixed time and don't read the annotation
+		if _, ok := pod.Annotations[jumpPodExpiresAtAnnotation]; !ok {
+			e.Errorln(fmt.Sprintf("Skipping pod '%s': it has no '%s' annotation, so it cannot be extended", pod.Name, jumpPodExpiresAtAnnotation))
+			continue
+		}
+		patch := kclient.MergeFrom(pod.DeepCopy())
+		pod.Annotations[jumpPodExpiresAtAnnotation] = jumpPodExpiresAt(expiresAt)
+		err = e.Client.Patch(context.TODO(), pod, patch)
+		if err != nil {
+			e.Errorln(fmt.Sprintf("Failed to extend pod '%s'", pod.Name))
+			return err
+		}
+		extended++
+	}
+	if extended == 0 {
+		return fmt.Errorf("none of the %d jump pods could be extended", len(pods.Items))
+	}
+	e.Println(fmt.Sprintf("Extended %d pod(s) until %s.", extended, expiresAt.Format(time.RFC3339)))
+	return nil
+}
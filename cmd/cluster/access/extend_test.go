@@ -0,0 +1,108 @@
+package access
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExtendAccessOptions_extendJumpPods(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+	)
+	oldExpiry := map[string]string{jumpPodExpiresAtAnnotation: "1600000000"}
+
+	tests := []struct {
+		Name             string
+		Pods             []metav1.ObjectMeta
+		ExpectedExtended []string
+		ExpectedErr      error
+	}{
+		{
+			Name: "Multiple jump pods",
+			Pods: []metav1.ObjectMeta{
+				{Name: "jump1", Labels: map[string]string{jumpPodLabelKey: clusterid}, Annotations: oldExpiry},
+				{Name: "jump2", Labels: map[string]string{jumpPodLabelKey: clusterid}, Annotations: oldExpiry},
+				{Name: "provision", Labels: map[string]string{"a-provisioning-pod-label": "testing"}, Annotations: oldExpiry},
+			},
+			ExpectedExtended: []string{"jump1", "jump2"},
+		},
+		{
+			Name: "Jump pod without an expiry",
+			Pods: []metav1.ObjectMeta{
+				{Name: "jump1", Labels: map[string]string{jumpPodLabelKey: clusterid}, Annotations: oldExpiry},
+				{Name: "legacy", Labels: map[string]string{jumpPodLabelKey: clusterid}},
+			},
+			ExpectedExtended: []string{"jump1"},
+		},
+		{
+			Name:        "No jump pods",
+			Pods:        []metav1.ObjectMeta{},
+			ExpectedErr: ErrNoJumpPods,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			objs := []runtime.Object{}
+			ns := corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "uhc-staging-" + clusterid,
+					Labels: map[string]string{hiveNSLabelKey: clusterid},
+				},
+			}
+			objs = append(objs, &ns)
+			for _, objMeta := range test.Pods {
+				pod := corev1.Pod{ObjectMeta: *objMeta.DeepCopy()}
+				pod.Namespace = ns.Name
+				objs = append(objs, &pod)
+			}
+
+			scheme := runtime.NewScheme()
+			err := corev1.AddToScheme(scheme)
+			if err != nil {
+				t.Fatalf("Failed to add corev1 to scheme: %v", err)
+			}
+			client := fake.NewFakeClientWithScheme(scheme, objs...)
+
+			streams := genericclioptions.IOStreams{In: strings.NewReader(""), Out: os.Stdout, ErrOut: os.Stderr}
+			flags := genericclioptions.ConfigFlags{}
+			extendAccess := newExtendAccessOptions(client, streams, &flags)
+
+			cluster := generateClusterObjectForTesting("fake-cluster", clusterid, true, false)
+			expiresAt := time.Now().Add(2 * time.Hour)
+
+			err = extendAccess.extendJumpPods(&cluster, expiresAt)
+			if err != test.ExpectedErr {
+				t.Fatalf("Expected error %v, got %v", test.ExpectedErr, err)
+			}
+
+			pods := corev1.PodList{}
+			err = client.List(context.TODO(), &pods)
+			if err != nil {
+				t.Fatalf("Failed to list pods: %v", err)
+			}
+			for _, pod := range pods.Items {
+				extended := false
+				for _, name := range test.ExpectedExtended {
+					extended = extended || name == pod.Name
+				}
+				actual, ok := pod.Annotations[jumpPodExpiresAtAnnotation]
+				switch {
+				case extended && actual != jumpPodExpiresAt(expiresAt):
+					t.Errorf("Expected pod '%s' to expire at %s, got '%s'", pod.Name, jumpPodExpiresAt(expiresAt), actual)
+				case !extended && ok && actual != oldExpiry[jumpPodExpiresAtAnnotation]:
+					t.Errorf("Expected pod '%s' not to be extended, got '%s'", pod.Name, actual)
+				}
+			}
+		})
+	}
+}
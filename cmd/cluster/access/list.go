@@ -0,0 +1,411 @@
+package access
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/printer"
+	osdctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newCmdList(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	var (
+		watchPods bool
+		since     time.Duration
+		phase     string
+		clusterID string
+	)
+	listCmd := &cobra.Command{
+		Use:               "list <cluster identifier>",
+		Short:             "List the jump pods running against a cluster",
+		Long:              "List the jump pods running against the given PrivateLink cluster in the cluster's namespace on hive. You must be logged into the cluster's hive shard.\nWith --watch, jump pods being added or deleted are printed until interrupted. When combined with '-o json', each event is emitted as a single line of JSON.\nWith --since, only jump pods created longer ago than the given duration are listed, to help find forgotten access.\nWith --phase, only jump pods in the given phase are listed, or those not in it when it starts with '!'. A pod whose container is waiting, e.g. in CrashLoopBackOff, is in the phase named by the reason it is waiting.\nThe cluster identifier can also be given with --cluster-id. Use 'all' as the identifier to list the jump pods of every cluster on the hive shard, grouped by cluster.",
+		Args:              cobra.MaximumNArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			clusterIdentifier, err := listCmdComplete(cmd, args, clusterID)
+			outputflag.CheckErr(globalOpts.Output, err)
+			if since != 0 && watchPods {
+				outputflag.CheckErr(globalOpts.Output, outputflag.UsageErrorf(cmd, "--since cannot be used with --watch"))
+			}
+			if since < 0 {
+				outputflag.CheckErr(globalOpts.Output, outputflag.UsageErrorf(cmd, "--since cannot be negative"))
+			}
+			if phase != "" && watchPods {
+				outputflag.CheckErr(globalOpts.Output, outputflag.UsageErrorf(cmd, "--phase cannot be used with --watch"))
+			}
+			if clusterIdentifier == allClusters && watchPods {
+				outputflag.CheckErr(globalOpts.Output, outputflag.UsageErrorf(cmd, "--watch cannot be used when listing all clusters"))
+			}
+			outputflag.CheckErr(globalOpts.Output, verifyPermissions(streams, flags))
+			client := k8s.NewWatchClient(flags)
+			listAccess := newListAccessOptions(client, streams, flags)
+			listAccess.watch = watchPods
+			listAccess.since = since
+			listAccess.phase = phase
+			listAccess.output = globalOpts.Output
+			listAccess.selectCluster = cmdutil.GetFlagBool(cmd, selectFlag)
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			outputflag.CheckErr(globalOpts.Output, listAccess.Run(ctx, []string{clusterIdentifier}))
+		},
+	}
+	addClusterIDFlag(listCmd, &clusterID)
+	listCmd.Flags().BoolVarP(&watchPods, "watch", "w", false, "Watch for jump pods being added or deleted")
+	listCmd.Flags().DurationVar(&since, "since", 0, "Only list jump pods older than the given duration, e.g. 24h")
+	listCmd.Flags().StringVar(&phase, "phase", "", "Only list jump pods in the given phase, e.g. Running, or not in it with a leading '!', e.g. '!Running'")
+	return listCmd
+}
+
+// listCmdComplete verifies the command's invocation, returning the cluster identifier or an error if the usage is invalid
+func listCmdComplete(cmd *cobra.Command, args []string, clusterID string) (string, error) {
+	return clusterIdentifierFromArgs(cmd, args, clusterID)
+}
+
+// listAccessOptions contains the objects and information required to list the jump pods running against a cluster
+type listAccessOptions struct {
+	*genericclioptions.ConfigFlags
+	genericclioptions.IOStreams
+	kclient.WithWatch
+
+	watch bool
+	since time.Duration
+	// phase filters the jump pods listed by jumpPodPhase, a leading '!' excludes the phase instead
+	phase         string
+	output        string
+	selectCluster bool
+}
+
+// allClusters is the cluster identifier listing the jump pods of every cluster on the hive shard
+const allClusters = "all"
+
+// jumpPod is the printed representation of a jump pod
+type jumpPod struct {
+	Name              string      `json:"name"`
+	Namespace         string      `json:"namespace"`
+	Phase             string      `json:"phase"`
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+}
+
+// clusterJumpPods is the printed representation of the jump pods running against one cluster
+type clusterJumpPods struct {
+	ClusterID string    `json:"clusterID"`
+	Pods      []jumpPod `json:"pods"`
+}
+
+// jumpPodEvent is the printed representation of a change to a jump pod while watching
+type jumpPodEvent struct {
+	Type watch.EventType `json:"type"`
+	Pod  jumpPod         `json:"pod"`
+}
+
+// newListAccessOptions creates a listAccessOptions object
+func newListAccessOptions(client kclient.WithWatch, streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags) listAccessOptions {
+	l := listAccessOptions{
+		IOStreams:   streams,
+		ConfigFlags: flags,
+		WithWatch:   client,
+	}
+	return l
+}
+
+// Println appends a newline then prints the given msg using the listAccessOptions' IOStreams
+func (l *listAccessOptions) Println(msg string) {
+	osdctlutil.StreamPrintln(l.IOStreams, msg)
+}
+
+// Errorln appends a newline then prints the given error msg using the listAccessOptions' IOStreams
+func (l *listAccessOptions) Errorln(msg string) {
+	osdctlutil.StreamErrorln(l.IOStreams, msg)
+}
+
+// Run executes the 'list' access subcommand
+func (l *listAccessOptions) Run(ctx context.Context, args []string) error {
+	clusterIdentifier := args[0]
+	if clusterIdentifier == allClusters {
+		return l.runAllClusters(ctx)
+	}
+
+	conn, err := osdctlutil.NewOCMConnection()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cmdutil.CheckErr(conn.Close())
+	}()
+
+	cluster, err := getCluster(conn, clusterIdentifier, l.IOStreams, l.selectCluster)
+	if err != nil {
+		return err
+	}
+
+	ns, err := getClusterNamespace(l.WithWatch, cluster.ID())
+	if err != nil {
+		l.Errorln("Failed to retrieve cluster namespace")
+		return err
+	}
+
+	listOpts, err := jumpPodListOptions(ns.Name, cluster.ID())
+	if err != nil {
+		return err
+	}
+
+	if l.watch {
+		w, err := l.WithWatch.Watch(ctx, &corev1.PodList{}, listOpts)
+		if err != nil {
+			l.Errorln(fmt.Sprintf("Failed to watch pods in cluster namespace '%s'", ns.Name))
+			return err
+		}
+		defer w.Stop()
+		return l.printJumpPodEvents(ctx, w)
+	}
+
+	pods := corev1.PodList{}
+	err = l.WithWatch.List(ctx, &pods, listOpts)
+	if err != nil {
+		l.Errorln(fmt.Sprintf("Failed to list pods in cluster namespace '%s'", ns.Name))
+		return err
+	}
+	if l.since > 0 {
+		pods.Items = jumpPodsOlderThan(pods.Items, time.Now().Add(-l.since))
+	}
+	if l.phase != "" {
+		pods.Items = jumpPodsInPhase(pods.Items, l.phase)
+	}
+	return l.printJumpPods(pods.Items)
+}
+
+// runAllClusters lists the jump pods of every cluster on the hive shard the client points to. The clusters are found
+// from the jump pods' labels rather than looked up in OCM.
+func (l *listAccessOptions) runAllClusters(ctx context.Context) error {
+	listOpts, err := allJumpPodsListOptions()
+	if err != nil {
+		return err
+	}
+	pods := corev1.PodList{}
+	err = l.WithWatch.List(ctx, &pods, listOpts)
+	if err != nil {
+		l.Errorln("Failed to list jump pods")
+		return err
+	}
+	if l.since > 0 {
+		pods.Items = jumpPodsOlderThan(pods.Items, time.Now().Add(-l.since))
+	}
+	if l.phase != "" {
+		pods.Items = jumpPodsInPhase(pods.Items, l.phase)
+	}
+	return l.printClusterJumpPods(groupJumpPodsByCluster(pods.Items))
+}
+
+// groupJumpPodsByCluster groups the given pods by the cluster in their jump pod label, sorted by cluster ID then pod
+// name. Pods with an empty label value can't be attributed to a cluster and are left out.
+func groupJumpPodsByCluster(pods []corev1.Pod) []clusterJumpPods {
+	byCluster := map[string][]jumpPod{}
+	for _, pod := range pods {
+		clusterID := pod.Labels[jumpPodLabelKey]
+		if clusterID == "" {
+			continue
+		}
+		byCluster[clusterID] = append(byCluster[clusterID], newJumpPod(pod))
+	}
+
+	clusters := []clusterJumpPods{}
+	for clusterID, clusterPods := range byCluster {
+		sort.Slice(clusterPods, func(i, j int) bool {
+			return clusterPods[i].Name < clusterPods[j].Name
+		})
+		clusters = append(clusters, clusterJumpPods{ClusterID: clusterID, Pods: clusterPods})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].ClusterID < clusters[j].ClusterID
+	})
+	return clusters
+}
+
+// jumpPodsOlderThan returns the pods created before the given time
+func jumpPodsOlderThan(pods []corev1.Pod, cutoff time.Time) []corev1.Pod {
+	old := []corev1.Pod{}
+	for _, pod := range pods {
+		if pod.CreationTimestamp.Time.Before(cutoff) {
+			old = append(old, pod)
+		}
+	}
+	return old
+}
+
+// jumpPodsInPhase returns the pods whose phase, as returned by jumpPodPhase, is the given one ignoring case. When the
+// phase starts with '!', the pods in any other phase are returned instead.
+func jumpPodsInPhase(pods []corev1.Pod, phase string) []corev1.Pod {
+	exclude := strings.HasPrefix(phase, "!")
+	phase = strings.TrimPrefix(phase, "!")
+
+	matched := []corev1.Pod{}
+	for _, pod := range pods {
+		if strings.EqualFold(jumpPodPhase(pod), phase) != exclude {
+			matched = append(matched, pod)
+		}
+	}
+	return matched
+}
+
+// jumpPodPhase returns the phase of the pod. If one of its containers is waiting, e.g. in CrashLoopBackOff, the reason
+// it is waiting is returned instead, as a crash looping pod is otherwise reported as Running.
+func jumpPodPhase(pod corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+			return status.State.Waiting.Reason
+		}
+	}
+	if pod.Status.Phase == "" {
+		return string(corev1.PodUnknown)
+	}
+	return string(pod.Status.Phase)
+}
+
+// allJumpPodsListOptions returns the options needed to list the jump pods of every cluster, in all namespaces
+func allJumpPodsListOptions() (*kclient.ListOptions, error) {
+	labelSelector := metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+		{Key: jumpPodLabelKey, Operator: metav1.LabelSelectorOpExists},
+	}}
+	selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	return &kclient.ListOptions{LabelSelector: selector}, nil
+}
+
+// jumpPodListOptions returns the options needed to list the jump pods for the given cluster in the given namespace
+func jumpPodListOptions(namespace string, clusterID string) (*kclient.ListOptions, error) {
+	if clusterID == "" {
+		return nil, ErrEmptyClusterID
+	}
+	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{jumpPodLabelKey: clusterID}}
+	selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	return &kclient.ListOptions{Namespace: namespace, LabelSelector: selector}, nil
+}
+
+func newJumpPod(pod corev1.Pod) jumpPod {
+	return jumpPod{
+		Name:              pod.Name,
+		Namespace:         pod.Namespace,
+		Phase:             jumpPodPhase(pod),
+		CreationTimestamp: pod.CreationTimestamp,
+	}
+}
+
+// printJumpPods prints the given pods as a table with their age, or as a JSON list with their creation timestamp when
+// '-o json' is set
+func (l *listAccessOptions) printJumpPods(pods []corev1.Pod) error {
+	jumpPods := []jumpPod{}
+	for _, pod := range pods {
+		jumpPods = append(jumpPods, newJumpPod(pod))
+	}
+
+	if l.output == "json" {
+		raw, err := json.MarshalIndent(jumpPods, "", "    ")
+		if err != nil {
+			return err
+		}
+		l.Println(string(raw))
+		return nil
+	}
+
+	if len(jumpPods) == 0 {
+		l.Println("No jump pods found")
+		return nil
+	}
+	l.Println(strings.TrimSuffix(jumpPodTable(jumpPods, time.Now()), "\n"))
+	return nil
+}
+
+// printClusterJumpPods prints a table of jump pods for each of the given clusters, or them as a JSON list when
+// '-o json' is set
+func (l *listAccessOptions) printClusterJumpPods(clusters []clusterJumpPods) error {
+	if l.output == "json" {
+		raw, err := json.MarshalIndent(clusters, "", "    ")
+		if err != nil {
+			return err
+		}
+		l.Println(string(raw))
+		return nil
+	}
+
+	if len(clusters) == 0 {
+		l.Println("No jump pods found")
+		return nil
+	}
+	now := time.Now()
+	var sb strings.Builder
+	for i, cluster := range clusters {
+		if i != 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("Cluster %s (%d jump pod(s)):\n", cluster.ClusterID, len(cluster.Pods)))
+		sb.WriteString(jumpPodTable(cluster.Pods, now))
+	}
+	l.Println(strings.TrimSuffix(sb.String(), "\n"))
+	return nil
+}
+
+// jumpPodTable formats the given pods as a table with a header row, showing the phase of each and how long ago it was
+// created at the given time
+func jumpPodTable(pods []jumpPod, now time.Time) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-40s %-50s %-18s %s\n", "NAME", "NAMESPACE", "PHASE", "AGE"))
+	for _, pod := range pods {
+		sb.WriteString(fmt.Sprintf("%-40s %-50s %-18s %s\n", pod.Name, pod.Namespace, pod.Phase, printer.HumanDuration(now.Sub(pod.CreationTimestamp.Time))))
+	}
+	return sb.String()
+}
+
+// printJumpPodEvents prints jump pods being added or deleted until the watch ends or the context is cancelled.
+// When '-o json' is set, each event is printed as a single line of JSON.
+func (l *listAccessOptions) printJumpPodEvents(ctx context.Context, w watch.Interface) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			if event.Type != watch.Added && event.Type != watch.Deleted {
+				continue
+			}
+			pod, ok := event.Object.(*corev1.Pod)
+			if !ok {
+				continue
+			}
+
+			if l.output == "json" {
+				raw, err := json.Marshal(jumpPodEvent{Type: event.Type, Pod: newJumpPod(*pod)})
+				if err != nil {
+					return err
+				}
+				l.Println(string(raw))
+			} else {
+				l.Println(fmt.Sprintf("%-8s %s/%s", event.Type, pod.Namespace, pod.Name))
+			}
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package access
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestListAccessOptions_printJumpPodEvents(t *testing.T) {
+	const (
+		clusterid = "fake-cluster-uuid-12345"
+		namespace = "uhc-staging-fake-cluster-uuid-12345"
+	)
+
+	tests := []struct {
+		Name   string
+		Output string
+	}{
+		{
+			Name:   "Text output",
+			Output: "",
+		},
+		{
+			Name:   "JSON output",
+			Output: "json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			err := corev1.AddToScheme(scheme)
+			if err != nil {
+				t.Fatalf("Failed to add corev1 to scheme: %v", err)
+			}
+			client := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+			out := &bytes.Buffer{}
+			streams := genericclioptions.IOStreams{In: os.Stdin, Out: out, ErrOut: os.Stderr}
+			flags := genericclioptions.ConfigFlags{}
+			listAccess := newListAccessOptions(client, streams, &flags)
+			listAccess.output = test.Output
+
+			listOpts, err := jumpPodListOptions(namespace, clusterid)
+			if err != nil {
+				t.Fatalf("Failed to build list options: %v", err)
+			}
+			w, err := client.Watch(context.TODO(), &corev1.PodList{}, listOpts)
+			if err != nil {
+				t.Fatalf("Failed to watch pods: %v", err)
+			}
+
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "jumphost",
+					Namespace: namespace,
+					Labels:    map[string]string{jumpPodLabelKey: clusterid},
+				},
+			}
+			err = client.Create(context.TODO(), &pod)
+			if err != nil {
+				t.Fatalf("Failed to create pod: %v", err)
+			}
+			err = client.Delete(context.TODO(), &pod)
+			if err != nil {
+				t.Fatalf("Failed to delete pod: %v", err)
+			}
+			// Stopping the watch lets the already-queued events drain before the loop exits
+			w.Stop()
+
+			err = listAccess.printJumpPodEvents(context.TODO(), w)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("Expected 2 events to be printed, got %d:\n%s", len(lines), out.String())
+			}
+			for i, expectedType := range []watch.EventType{watch.Added, watch.Deleted} {
+				if test.Output == "json" {
+					event := jumpPodEvent{}
+					err = json.Unmarshal([]byte(lines[i]), &event)
+					if err != nil {
+						t.Fatalf("Expected line %d to be a JSON event, got '%s': %v", i, lines[i], err)
+					}
+					if event.Type != expectedType || event.Pod.Name != pod.Name {
+						t.Errorf("Expected %s event for pod %s, got %v", expectedType, pod.Name, event)
+					}
+				} else if !strings.HasPrefix(lines[i], string(expectedType)) || !strings.Contains(lines[i], pod.Name) {
+					t.Errorf("Expected %s event for pod %s, got '%s'", expectedType, pod.Name, lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestJumpPodsOlderThan(t *testing.T) {
+	now := time.Now()
+	ages := map[string]time.Duration{
+		"fresh":   10 * time.Minute,
+		"recent":  23 * time.Hour,
+		"stale":   25 * time.Hour,
+		"ancient": 30 * 24 * time.Hour,
+	}
+
+	pods := []corev1.Pod{}
+	for name, age := range ages {
+		pods = append(pods, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+		})
+	}
+
+	old := jumpPodsOlderThan(pods, now.Add(-24*time.Hour))
+
+	names := map[string]bool{}
+	for _, pod := range old {
+		names[pod.Name] = true
+	}
+	if len(names) != 2 || !names["stale"] || !names["ancient"] {
+		t.Errorf("Expected only the stale and ancient pods to be listed, got %v", names)
+	}
+}
+
+func TestJumpPodListOptions_LabelKeyOverride(t *testing.T) {
+	defer func(key string) { jumpPodLabelKey = key }(jumpPodLabelKey)
+	jumpPodLabelKey = "example.com/break-glass-cluster"
+
+	listOpts, err := jumpPodListOptions("uhc-staging-fake-cluster-uuid-12345", "fake-cluster-uuid-12345")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if listOpts.LabelSelector.String() != "example.com/break-glass-cluster=fake-cluster-uuid-12345" {
+		t.Errorf("Expected the selector to use the overridden label key, got '%s'", listOpts.LabelSelector.String())
+	}
+}
+
+func TestListAccessOptions_Run_AllClusters(t *testing.T) {
+	pod := func(name string, clusterid string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "uhc-staging-" + clusterid,
+				Labels:    map[string]string{jumpPodLabelKey: clusterid},
+			},
+		}
+	}
+	objs := []kclient.Object{
+		pod("jump2", "cluster-b"),
+		pod("jump1", "cluster-b"),
+		pod("jump1", "cluster-a"),
+		pod("unlabelled", ""),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "uhc-staging-cluster-a"}},
+	}
+
+	tests := []struct {
+		Name   string
+		Output string
+	}{
+		{
+			Name:   "Text output",
+			Output: "",
+		},
+		{
+			Name:   "JSON output",
+			Output: "json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			err := corev1.AddToScheme(scheme)
+			if err != nil {
+				t.Fatalf("Failed to add corev1 to scheme: %v", err)
+			}
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+			out := &bytes.Buffer{}
+			streams := genericclioptions.IOStreams{In: os.Stdin, Out: out, ErrOut: os.Stderr}
+			flags := genericclioptions.ConfigFlags{}
+			listAccess := newListAccessOptions(client, streams, &flags)
+			listAccess.output = test.Output
+
+			err = listAccess.Run(context.TODO(), []string{allClusters})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if test.Output == "json" {
+				clusters := []clusterJumpPods{}
+				err = json.Unmarshal(out.Bytes(), &clusters)
+				if err != nil {
+					t.Fatalf("Expected a JSON list of clusters, got '%s': %v", out.String(), err)
+				}
+				if len(clusters) != 2 || clusters[0].ClusterID != "cluster-a" || clusters[1].ClusterID != "cluster-b" {
+					t.Fatalf("Expected the jump pods of cluster-a and cluster-b, got %v", clusters)
+				}
+				if len(clusters[1].Pods) != 2 || clusters[1].Pods[0].Name != "jump1" || clusters[1].Pods[1].Name != "jump2" {
+					t.Errorf("Expected cluster-b's jump pods sorted by name, got %v", clusters[1].Pods)
+				}
+				return
+			}
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			expectedPrefixes := []string{
+				"Cluster cluster-a (1 jump pod(s)):",
+				"NAME",
+				"jump1",
+				"",
+				"Cluster cluster-b (2 jump pod(s)):",
+				"NAME",
+				"jump1",
+				"jump2",
+			}
+			if len(lines) != len(expectedPrefixes) {
+				t.Fatalf("Expected %d lines, got %d:\n%s", len(expectedPrefixes), len(lines), out.String())
+			}
+			for i, prefix := range expectedPrefixes {
+				if !strings.HasPrefix(lines[i], prefix) || (prefix == "" && lines[i] != "") {
+					t.Errorf("Expected line %d to start with '%s', got '%s'", i, prefix, lines[i])
+				}
+			}
+			if !strings.Contains(lines[6], "uhc-staging-cluster-b") {
+				t.Errorf("Expected cluster-b's jump pod to be listed in its namespace, got '%s'", lines[6])
+			}
+		})
+	}
+}
+
+func TestJumpPodTable(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	pods := []jumpPod{
+		{Name: "jump1", Namespace: "uhc-staging-fake-cluster-uuid-12345", CreationTimestamp: metav1.NewTime(now.Add(-(3*time.Hour + 12*time.Minute)))},
+	}
+
+	lines := strings.Split(strings.TrimSpace(jumpPodTable(pods, now)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected a header and a row, got:\n%s", strings.Join(lines, "\n"))
+	}
+	if !strings.HasSuffix(lines[0], "AGE") || !strings.HasSuffix(lines[1], " 3h12m") {
+		t.Errorf("Expected the pod's age to be shown, got:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestListAccessOptions_Run_Phase(t *testing.T) {
+	pod := func(name string, phase corev1.PodPhase, waitingReason string) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "uhc-staging-cluster-a",
+				Labels:    map[string]string{jumpPodLabelKey: "cluster-a"},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+		if waitingReason != "" {
+			p.Status.ContainerStatuses = []corev1.ContainerStatus{
+				{Name: jumpContainerName, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waitingReason}}},
+			}
+		}
+		return p
+	}
+	objs := []kclient.Object{
+		pod("jump-running", corev1.PodRunning, ""),
+		pod("jump-pending", corev1.PodPending, ""),
+		pod("jump-crashing", corev1.PodRunning, "CrashLoopBackOff"),
+	}
+
+	tests := []struct {
+		Name     string
+		Phase    string
+		Expected map[string]string
+	}{
+		{
+			Name:     "No filter",
+			Phase:    "",
+			Expected: map[string]string{"jump-running": "Running", "jump-pending": "Pending", "jump-crashing": "CrashLoopBackOff"},
+		},
+		{
+			Name:     "Only running",
+			Phase:    "Running",
+			Expected: map[string]string{"jump-running": "Running"},
+		},
+		{
+			Name:     "Not running",
+			Phase:    "!Running",
+			Expected: map[string]string{"jump-pending": "Pending", "jump-crashing": "CrashLoopBackOff"},
+		},
+		{
+			Name:     "Waiting reason, ignoring case",
+			Phase:    "crashloopbackoff",
+			Expected: map[string]string{"jump-crashing": "CrashLoopBackOff"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			err := corev1.AddToScheme(scheme)
+			if err != nil {
+				t.Fatalf("Failed to add corev1 to scheme: %v", err)
+			}
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+			out := &bytes.Buffer{}
+			streams := genericclioptions.IOStreams{In: os.Stdin, Out: out, ErrOut: os.Stderr}
+			flags := genericclioptions.ConfigFlags{}
+			listAccess := newListAccessOptions(client, streams, &flags)
+			listAccess.output = "json"
+			listAccess.phase = test.Phase
+
+			err = listAccess.Run(context.TODO(), []string{allClusters})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			clusters := []clusterJumpPods{}
+			err = json.Unmarshal(out.Bytes(), &clusters)
+			if err != nil {
+				t.Fatalf("Expected a JSON list of clusters, got '%s': %v", out.String(), err)
+			}
+			phases := map[string]string{}
+			for _, cluster := range clusters {
+				for _, p := range cluster.Pods {
+					phases[p.Name] = p.Phase
+				}
+			}
+			if !reflect.DeepEqual(phases, test.Expected) {
+				t.Errorf("Expected the jump pods and phases %v, got %v", test.Expected, phases)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package access
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// defaultStaleAge is how old a jump pod must be for 'cleanup --all-stale' to consider its access forgotten
+const defaultStaleAge = 24 * time.Hour
+
+// RunAllStale drops the jump pods older than the given age from every cluster on the hive shard the client points to.
+// The clusters are discovered from the jump pods' labels rather than looked up in OCM, and only their stale jump
+// pods are deleted. A failure to drop access to one cluster does not stop the others from being cleaned up.
+func (c *cleanupAccessOptions) RunAllStale(age time.Duration) ([]CleanupResult, error) {
+	listOpts, err := allJumpPodsListOptions()
+	if err != nil {
+		c.Errorln("Failed to convert labelSelector to selector")
+		return nil, err
+	}
+
+	pods := corev1.PodList{}
+	err = c.Client.List(c.ctx, &pods, listOpts)
+	if err != nil {
+		c.Errorln("Failed to list jump pods")
+		return nil, err
+	}
+
+	// Pods with an empty label value can't be attributed to a cluster, they are left alone
+	byCluster := map[string][]corev1.Pod{}
+	for _, pod := range jumpPodsOlderThan(pods.Items, time.Now().Add(-age)) {
+		clusterID := pod.Labels[jumpPodLabelKey]
+		if clusterID == "" {
+			continue
+		}
+		byCluster[clusterID] = append(byCluster[clusterID], pod)
+	}
+	if len(byCluster) == 0 {
+		c.Println(fmt.Sprintf("No jump pods older than %s found.", age))
+		return []CleanupResult{}, nil
+	}
+
+	clusterIDs := []string{}
+	numPods := 0
+	for clusterID, clusterPods := range byCluster {
+		clusterIDs = append(clusterIDs, clusterID)
+		numPods += len(clusterPods)
+	}
+	sort.Strings(clusterIDs)
+
+	results := []CleanupResult{}
+	c.Println(fmt.Sprintf("Found %d jump pod(s) older than %s against %d cluster(s):", numPods, age, len(clusterIDs)))
+	for _, clusterID := range clusterIDs {
+		c.Println(fmt.Sprintf("- %s: %s", clusterID, strings.Join(podNames(byCluster[clusterID]), ", ")))
+		results = append(results, CleanupResult{
+			ClusterID:   clusterID,
+			PrivateLink: true,
+			DryRun:      c.dryRun,
+			AccessFound: true,
+			PodsKept:    podNames(byCluster[clusterID]),
+		})
+	}
+	c.Println("")
+	if c.dryRun {
+		c.Println("Access has not been dropped.")
+		return results, nil
+	}
+
+	c.Print("Continue? [y/N] ")
+	input, err := c.Readln()
+	if err != nil {
+		c.Errorln("Failed to read user input")
+		return results, err
+	}
+	confirmed := isAffirmative(input)
+	if confirmed && numPods > c.confirmCount {
+		c.Print(fmt.Sprintf("%d pods is more than the --confirm-count of %d. Type the number of pods to continue: ", numPods, c.confirmCount))
+		input, err = c.Readln()
+		if err != nil {
+			c.Errorln("Failed to read user input")
+			return results, err
+		}
+		confirmed = input == strconv.Itoa(numPods)
+		if !confirmed {
+			c.Println("The number of pods did not match.")
+		}
+	}
+	if !confirmed {
+		c.Println("Access has not been dropped.")
+		return results, nil
+	}
+
+	failures := []string{}
+	for i, clusterID := range clusterIDs {
+		if c.ctx.Err() != nil {
+			return results, ErrInterrupted
+		}
+		deleted, err := c.deleteJumpPods(byCluster[clusterID])
+		results[i].PodsDeleted = podNames(deleted)
+		results[i].PodsKept = []string{}
+		if err == nil && c.waitForDelete {
+			err = c.waitForPodsDeleted(deleted)
+		}
+		if err != nil {
+			c.Errorln(fmt.Sprintf("Failed to drop access to cluster '%s': %v", clusterID, err))
+			failures = append(failures, fmt.Sprintf("cluster '%s': %v", clusterID, err))
+		}
+	}
+
+	c.Println(fmt.Sprintf("Dropped access to %d of %d cluster(s).", len(clusterIDs)-len(failures), len(clusterIDs)))
+	if len(failures) > 0 {
+		return results, fmt.Errorf("failed to drop access to %d of %d clusters:\n%s", len(failures), len(clusterIDs), strings.Join(failures, "\n"))
+	}
+	return results, nil
+}
+
+// deleteJumpPods deletes each of the given pods, returning those whose deletion was requested. Pods which have
+// already been removed are skipped.
+func (c *cleanupAccessOptions) deleteJumpPods(pods []corev1.Pod) ([]corev1.Pod, error) {
+	deleted := []corev1.Pod{}
+	for i := range pods {
+		pod := pods[i]
+		err := c.Client.Delete(c.ctx, &pod)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return deleted, err
+		}
+		deleted = append(deleted, pod)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,140 @@
+package access
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCleanupAccessOptions_RunAllStale(t *testing.T) {
+	now := time.Now()
+	newPod := func(name string, namespace string, labels map[string]string, age time.Duration) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				Labels:            labels,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+		}
+	}
+
+	objs := []runtime.Object{
+		newPod("jump-a", "uhc-staging-cluster-a", map[string]string{jumpPodLabelKey: "cluster-a"}, 48*time.Hour),
+		newPod("jump-b1", "uhc-staging-cluster-b", map[string]string{jumpPodLabelKey: "cluster-b"}, 30*time.Hour),
+		newPod("jump-b2", "uhc-staging-cluster-b", map[string]string{jumpPodLabelKey: "cluster-b"}, 26*time.Hour),
+		// Access created recently is still in use
+		newPod("jump-fresh", "uhc-staging-cluster-a", map[string]string{jumpPodLabelKey: "cluster-a"}, time.Hour),
+		newPod("provision", "uhc-staging-cluster-b", map[string]string{"a-provisioning-pod-label": "testing"}, 72*time.Hour),
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := fake.NewFakeClientWithScheme(scheme, objs...)
+
+	out := &bytes.Buffer{}
+	streams := genericclioptions.IOStreams{In: strings.NewReader("y\n"), Out: out, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	cleanupAccess := newCleanupAccessOptions(client, streams, &flags)
+
+	results, err := cleanupAccess.RunAllStale(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error encountered: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected both clusters to be cleaned up, got %+v", results)
+	}
+	expected := map[string]string{"cluster-a": "jump-a", "cluster-b": "jump-b1,jump-b2"}
+	for _, result := range results {
+		if !result.AccessFound || strings.Join(result.PodsDeleted, ",") != expected[result.ClusterID] {
+			t.Errorf("Expected pods '%s' to be deleted from cluster '%s', got %+v", expected[result.ClusterID], result.ClusterID, result)
+		}
+	}
+	if !strings.Contains(out.String(), "Dropped access to 2 of 2 cluster(s).") {
+		t.Errorf("Expected a summary of the clusters cleaned up, got output:\n%s", out.String())
+	}
+
+	podsAfter := corev1.PodList{}
+	err = client.List(context.TODO(), &podsAfter)
+	if err != nil {
+		t.Fatalf("Error while listing pods after testing: %v", err)
+	}
+	remaining := []string{}
+	for _, pod := range podsAfter.Items {
+		remaining = append(remaining, pod.Name)
+	}
+	if strings.Join(remaining, ",") != "jump-fresh,provision" {
+		t.Errorf("Expected only the fresh jump pod and the unrelated pod to remain, got %v", remaining)
+	}
+}
+
+func TestCleanupAllStaleCmdComplete(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Args        []string
+		ClusterID   string
+		Batch       bool
+		Interactive bool
+		StaleAge    time.Duration
+		ExpectErr   bool
+	}{
+		{
+			Name:     "No cluster identifier",
+			StaleAge: defaultStaleAge,
+		},
+		{
+			Name:      "Cluster identifier given",
+			Args:      []string{"cluster-a"},
+			StaleAge:  defaultStaleAge,
+			ExpectErr: true,
+		},
+		{
+			Name:      "Cluster ID flag given",
+			ClusterID: "cluster-a",
+			StaleAge:  defaultStaleAge,
+			ExpectErr: true,
+		},
+		{
+			Name:      "Stdin given",
+			Batch:     true,
+			StaleAge:  defaultStaleAge,
+			ExpectErr: true,
+		},
+		{
+			Name:        "Interactive",
+			Interactive: true,
+			StaleAge:    defaultStaleAge,
+			ExpectErr:   true,
+		},
+		{
+			Name:      "No age",
+			ExpectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := cleanupAllStaleCmdComplete(&cobra.Command{}, test.Args, test.ClusterID, test.Batch, test.Interactive, test.StaleAge)
+			if test.ExpectErr && err == nil {
+				t.Errorf("Expected an error")
+			}
+			if !test.ExpectErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
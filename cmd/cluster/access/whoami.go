@@ -0,0 +1,136 @@
+package access
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/printer"
+	osdctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newCmdWhoamiAccess(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	whoamiCmd := &cobra.Command{
+		Use:               "whoami-access",
+		Short:             "List the clusters you still have jump pods running against",
+		Long:              "List the clusters on the hive shard that jump pods you created are still running against, along with the age of each pod. You must be logged into the hive shard.\nJump pods are attributed to the OCM account that created them, falling back to $USER. Pods created by older versions of osdctl record no owner and are never listed.",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			outputflag.CheckErr(globalOpts.Output, verifyPermissions(streams, flags))
+			client := newClient(flags)
+			whoamiAccess := newWhoamiAccessOptions(client, streams, flags)
+			whoamiAccess.output = globalOpts.Output
+			outputflag.CheckErr(globalOpts.Output, whoamiAccess.Run(context.TODO()))
+		},
+	}
+	return whoamiCmd
+}
+
+// whoamiAccessOptions contains the objects and information required to list the clusters the current user has jump
+// pods running against
+type whoamiAccessOptions struct {
+	*genericclioptions.ConfigFlags
+	genericclioptions.IOStreams
+	kclient.Client
+
+	// owner is the username the jump pods are filtered by, it is looked up in OCM when empty
+	owner  string
+	output string
+}
+
+// newWhoamiAccessOptions creates a whoamiAccessOptions object
+func newWhoamiAccessOptions(client kclient.Client, streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags) whoamiAccessOptions {
+	w := whoamiAccessOptions{
+		IOStreams:   streams,
+		ConfigFlags: flags,
+		Client:      client,
+	}
+	return w
+}
+
+// Println appends a newline then prints the given msg using the whoamiAccessOptions' IOStreams
+func (w *whoamiAccessOptions) Println(msg string) {
+	osdctlutil.StreamPrintln(w.IOStreams, msg)
+}
+
+// Errorln appends a newline then prints the given error msg using the whoamiAccessOptions' IOStreams
+func (w *whoamiAccessOptions) Errorln(msg string) {
+	osdctlutil.StreamErrorln(w.IOStreams, msg)
+}
+
+// Run executes the 'whoami-access' access subcommand
+func (w *whoamiAccessOptions) Run(ctx context.Context) error {
+	if w.owner == "" {
+		conn, err := osdctlutil.NewOCMConnection()
+		if err != nil {
+			return err
+		}
+		w.owner = currentUsername(conn)
+		cmdutil.CheckErr(conn.Close())
+		if w.owner == "" {
+			return fmt.Errorf("could not determine the current user from OCM or $USER")
+		}
+	}
+
+	listOpts, err := allJumpPodsListOptions()
+	if err != nil {
+		return err
+	}
+	pods := corev1.PodList{}
+	err = w.Client.List(ctx, &pods, listOpts)
+	if err != nil {
+		w.Errorln("Failed to list jump pods")
+		return err
+	}
+	return w.printClusters(groupJumpPodsByCluster(jumpPodsOwnedBy(pods.Items, w.owner)))
+}
+
+// jumpPodsOwnedBy returns the pods whose owner annotation is the given username
+func jumpPodsOwnedBy(pods []corev1.Pod, owner string) []corev1.Pod {
+	owned := []corev1.Pod{}
+	for _, pod := range pods {
+		if pod.Annotations[jumpPodOwnerAnnotation] == owner {
+			owned = append(owned, pod)
+		}
+	}
+	return owned
+}
+
+// printClusters prints one line per jump pod with its cluster and age, or the clusters as a JSON list when '-o json'
+// is set
+func (w *whoamiAccessOptions) printClusters(clusters []clusterJumpPods) error {
+	if w.output == "json" {
+		raw, err := json.MarshalIndent(clusters, "", "    ")
+		if err != nil {
+			return err
+		}
+		w.Println(string(raw))
+		return nil
+	}
+
+	if len(clusters) == 0 {
+		w.Println(fmt.Sprintf("No jump pods owned by '%s' found", w.owner))
+		return nil
+	}
+	now := time.Now()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-34s %-40s %s\n", "CLUSTER", "POD", "AGE"))
+	for _, cluster := range clusters {
+		for _, pod := range cluster.Pods {
+			sb.WriteString(fmt.Sprintf("%-34s %-40s %s\n", cluster.ClusterID, pod.Name, printer.HumanDuration(now.Sub(pod.CreationTimestamp.Time))))
+		}
+	}
+	w.Println(strings.TrimSuffix(sb.String(), "\n"))
+	return nil
+}
@@ -0,0 +1,75 @@
+package access
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWhoamiAccessOptions_Run(t *testing.T) {
+	pod := func(name string, clusterid string, owner string) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "uhc-staging-" + clusterid,
+				Labels:    map[string]string{jumpPodLabelKey: clusterid},
+			},
+		}
+		if owner != "" {
+			p.Annotations = map[string]string{jumpPodOwnerAnnotation: owner}
+		}
+		return p
+	}
+	objs := []kclient.Object{
+		pod("jump1", "cluster-b", "tuser"),
+		pod("jump2", "cluster-b", "other"),
+		pod("jump1", "cluster-a", "tuser"),
+		pod("jump1", "cluster-c", "other"),
+		pod("jump2", "cluster-c", ""),
+	}
+
+	scheme := runtime.NewScheme()
+	err := corev1.AddToScheme(scheme)
+	if err != nil {
+		t.Fatalf("Failed to add corev1 to scheme: %v", err)
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	out := &bytes.Buffer{}
+	streams := genericclioptions.IOStreams{In: os.Stdin, Out: out, ErrOut: os.Stderr}
+	flags := genericclioptions.ConfigFlags{}
+	whoamiAccess := newWhoamiAccessOptions(client, streams, &flags)
+	whoamiAccess.owner = "tuser"
+
+	err = whoamiAccess.Run(context.TODO())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	expectedPrefixes := []string{
+		"CLUSTER",
+		"cluster-a",
+		"cluster-b",
+	}
+	if len(lines) != len(expectedPrefixes) {
+		t.Fatalf("Expected %d lines, got %d:\n%s", len(expectedPrefixes), len(lines), out.String())
+	}
+	for i, prefix := range expectedPrefixes {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("Expected line %d to start with '%s', got '%s'", i, prefix, lines[i])
+		}
+	}
+	if !strings.Contains(lines[2], "jump1") {
+		t.Errorf("Expected tuser's jump pod on cluster-b to be listed, got '%s'", lines[2])
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/openshift/osdctl/cmd/env"
 	"github.com/openshift/osdctl/cmd/federatedrole"
 	"github.com/openshift/osdctl/cmd/network"
+	"github.com/openshift/osdctl/cmd/preflight"
 	"github.com/openshift/osdctl/cmd/servicelog"
 	"github.com/openshift/osdctl/cmd/sts"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
@@ -81,6 +82,9 @@ func NewCmdRoot(streams genericclioptions.IOStreams) *cobra.Command {
 
 	rootCmd.AddCommand(capability.NewCmdCapability())
 
+	// Add preflight command to check AWS and OCM connectivity
+	rootCmd.AddCommand(preflight.NewCmdPreflight(streams, globalOpts))
+
 	return rootCmd
 }
 
@@ -145,7 +145,7 @@ func getAccounts(OU *organizations.OrganizationalUnit, awsClient awsprovider.Cli
 			NextToken: nextToken,
 		})
 		if err != nil {
-			return nil, err
+			return nil, awsprovider.WithOrganizationsHint(err)
 		}
 
 		for i := 0; i < len(accounts.Accounts); i++ {
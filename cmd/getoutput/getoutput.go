@@ -1,16 +1,25 @@
 package getoutput
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 
+	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 type CmdResponse interface {
 	String() string
 }
 
+// PrintResponse writes resp to stdout in the given output format. Formats the response doesn't support, including
+// '-o csv' for responses which aren't a CSVResponse, fall back to plain text, so a command which already did its work
+// still reports it.
 func PrintResponse(output string, resp CmdResponse) error {
 	if output == "json" {
 
@@ -30,8 +39,185 @@ func PrintResponse(output string, resp CmdResponse) error {
 
 		fmt.Println(string(accountIdToYaml))
 
+	} else if output == CSVOutput && SupportsCSV(resp) {
+		return PrintCSV(os.Stdout, resp)
 	} else {
 		fmt.Println(resp)
 	}
 	return nil
 }
+
+// NDJSONOutput is the output format writing one compact JSON response per line, for commands which stream their
+// results as they are found instead of printing them all at the end
+const NDJSONOutput = "ndjson"
+
+// PrintNDJSON writes the response to w as a single line of JSON
+func PrintNDJSON(w io.Writer, resp CmdResponse) error {
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(raw))
+	return err
+}
+
+// CSVOutput is the output format writing a header row then one row per item, for responses implementing CSVResponse
+const CSVOutput = "csv"
+
+// CSVResponse is a response which can be written as CSV. The header and the fields of each row must always be in the
+// same order, so spreadsheets importing the output keep working.
+type CSVResponse interface {
+	CSVHeader() []string
+	CSVRows() [][]string
+}
+
+var ErrCSVNotSupported = fmt.Errorf("'-o csv' is not supported by this command")
+
+// SupportsCSV returns whether the response, or the data of an Envelope, can be written with PrintCSV
+func SupportsCSV(resp CmdResponse) bool {
+	if envelope, ok := resp.(Envelope); ok {
+		resp = envelope.Data
+	}
+	_, ok := resp.(CSVResponse)
+	return ok
+}
+
+// PrintCSV writes the response to w as a header row followed by its rows. Fields containing commas, quotes or
+// newlines are quoted.
+func PrintCSV(w io.Writer, resp CmdResponse) error {
+	if !SupportsCSV(resp) {
+		return ErrCSVNotSupported
+	}
+	if envelope, ok := resp.(Envelope); ok {
+		resp = envelope.Data
+	}
+	csvResp := resp.(CSVResponse)
+
+	cw := csv.NewWriter(w)
+	err := cw.Write(csvResp.CSVHeader())
+	if err != nil {
+		return err
+	}
+	// WriteAll flushes the writer
+	return cw.WriteAll(csvResp.CSVRows())
+}
+
+// OutputAPIVersion is the version of the structured (json/yaml) command output. It must be bumped whenever
+// the shape of an Envelope or of the data wrapped by it changes incompatibly.
+const OutputAPIVersion = "osdctl.openshift.io/v1alpha1"
+
+// Envelope wraps a CmdResponse with versioning information so scripts consuming structured output can
+// detect changes to it
+type Envelope struct {
+	APIVersion string      `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string      `json:"kind" yaml:"kind"`
+	Data       CmdResponse `json:"data" yaml:"data"`
+}
+
+// NewEnvelope wraps the given response in an Envelope of the given kind
+func NewEnvelope(kind string, data CmdResponse) Envelope {
+	return Envelope{
+		APIVersion: OutputAPIVersion,
+		Kind:       kind,
+		Data:       data,
+	}
+}
+
+// String returns the plain text representation of the wrapped response
+func (e Envelope) String() string {
+	return e.Data.String()
+}
+
+// ErrorResponse is a failed command's error, written to stderr instead of the plain text error with '-o json' so
+// scripts consuming the output can parse it
+type ErrorResponse struct {
+	Code    string `json:"code" yaml:"code"`
+	Message string `json:"message" yaml:"message"`
+}
+
+func (e ErrorResponse) String() string {
+	return e.Message
+}
+
+const (
+	// DefaultErrorCode is the code of errors no code was registered for
+	DefaultErrorCode = "Error"
+	// UsageErrorCode is the code of the errors returned by UsageErrorf
+	UsageErrorCode = "UsageError"
+)
+
+// ErrUsage is matched with errors.Is by the errors returned by UsageErrorf
+var ErrUsage = fmt.Errorf("invalid usage")
+
+// usageError is an error returned by cmdutil.UsageErrorf, marked so it matches ErrUsage
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+func (e usageError) Is(target error) bool {
+	return target == ErrUsage
+}
+
+// UsageErrorf returns the error of cmdutil.UsageErrorf, which ErrorCode reports with UsageErrorCode
+func UsageErrorf(cmd *cobra.Command, format string, args ...interface{}) error {
+	return usageError{err: cmdutil.UsageErrorf(cmd, format, args...)}
+}
+
+// errorCodes are the codes registered for the sentinel errors, in the order they were registered
+var errorCodes []registeredErrorCode
+
+type registeredErrorCode struct {
+	err  error
+	code string
+}
+
+// RegisterErrorCode sets the code reported for errors matching err with errors.Is. It is meant to be called from
+// init functions, before any command runs.
+func RegisterErrorCode(code string, err error) {
+	errorCodes = append(errorCodes, registeredErrorCode{err: err, code: code})
+}
+
+// ErrorCode returns the code reported for err, the code of the first registered error it matches, UsageErrorCode
+// for usage errors and DefaultErrorCode otherwise
+func ErrorCode(err error) string {
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	if errors.Is(err, ErrUsage) {
+		return UsageErrorCode
+	}
+	return DefaultErrorCode
+}
+
+// PrintError writes err to w as an ErrorResponse in JSON
+func PrintError(w io.Writer, err error) error {
+	raw, marshalErr := json.MarshalIndent(ErrorResponse{Code: ErrorCode(err), Message: err.Error()}, "", "    ")
+	if marshalErr != nil {
+		return marshalErr
+	}
+	_, marshalErr = fmt.Fprintln(w, string(raw))
+	return marshalErr
+}
+
+// CheckErr exits like cmdutil.CheckErr when err is not nil, but with '-o json' the error is written to stderr as
+// an ErrorResponse instead of as plain text
+func CheckErr(output string, err error) {
+	if err == nil {
+		return
+	}
+	if output != "json" {
+		cmdutil.CheckErr(err)
+		return
+	}
+	if printErr := PrintError(os.Stderr, err); printErr != nil {
+		cmdutil.CheckErr(err)
+		return
+	}
+	os.Exit(cmdutil.DefaultErrorExitCode)
+}
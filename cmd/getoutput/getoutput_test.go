@@ -0,0 +1,137 @@
+package getoutput
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type testResponse struct {
+	Id string `json:"id" yaml:"id"`
+}
+
+func (t testResponse) String() string {
+	return t.Id
+}
+
+func TestEnvelope(t *testing.T) {
+	envelope := NewEnvelope("TestKind", testResponse{Id: "111111111111"})
+
+	raw, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("failed to marshal envelope: %v", err)
+	}
+
+	var got map[string]interface{}
+	err = json.Unmarshal(raw, &got)
+	if err != nil {
+		t.Fatalf("failed to unmarshal envelope: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"apiVersion": OutputAPIVersion,
+		"kind":       "TestKind",
+		"data": map[string]interface{}{
+			"id": "111111111111",
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if envelope.String() != "111111111111" {
+		t.Errorf("expected text output to be the wrapped response, got %s", envelope.String())
+	}
+}
+
+type testCSVResponse struct {
+	rows [][]string
+}
+
+func (t testCSVResponse) String() string {
+	return ""
+}
+
+func (t testCSVResponse) CSVHeader() []string {
+	return []string{"id", "tags"}
+}
+
+func (t testCSVResponse) CSVRows() [][]string {
+	return t.rows
+}
+
+func TestPrintCSV(t *testing.T) {
+	resp := testCSVResponse{rows: [][]string{
+		{"111111111111", "owner=tuser"},
+		{"222222222222", "owner=tuser,cost-center=1234"},
+	}}
+
+	var out bytes.Buffer
+	err := PrintCSV(&out, NewEnvelope("TestKind", resp))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "id,tags\n111111111111,owner=tuser\n222222222222,\"owner=tuser,cost-center=1234\"\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestPrintCSVNotSupported(t *testing.T) {
+	var out bytes.Buffer
+	err := PrintCSV(&out, NewEnvelope("TestKind", testResponse{Id: "111111111111"}))
+	if err != ErrCSVNotSupported {
+		t.Errorf("expected %v, got %v", ErrCSVNotSupported, err)
+	}
+}
+
+func TestSupportsCSV(t *testing.T) {
+	if !SupportsCSV(NewEnvelope("TestKind", testCSVResponse{})) {
+		t.Errorf("expected an envelope of a CSVResponse to support CSV")
+	}
+	if SupportsCSV(NewEnvelope("TestKind", testResponse{Id: "111111111111"})) {
+		t.Errorf("expected an envelope of a plain response not to support CSV")
+	}
+}
+
+func TestPrintError(t *testing.T) {
+	errRegistered := fmt.Errorf("registered error")
+	RegisterErrorCode("Registered", errRegistered)
+
+	testData := []struct {
+		name         string
+		err          error
+		expectedCode string
+	}{
+		{name: "registered error", err: errRegistered, expectedCode: "Registered"},
+		{name: "wrapped registered error", err: fmt.Errorf("assigning: %w", errRegistered), expectedCode: "Registered"},
+		{name: "usage error", err: UsageErrorf(&cobra.Command{Use: "assign"}, "--count cannot be negative"), expectedCode: UsageErrorCode},
+		{name: "wrapped usage error", err: fmt.Errorf("reading flags: %w", UsageErrorf(&cobra.Command{Use: "assign"}, "--count cannot be negative")), expectedCode: UsageErrorCode},
+		{name: "error worded like a usage error", err: fmt.Errorf("--count cannot be negative\nSee 'osdctl account mgmt assign -h' for help and examples"), expectedCode: DefaultErrorCode},
+		{name: "other error", err: fmt.Errorf("something failed"), expectedCode: DefaultErrorCode},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := PrintError(&out, test.err)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got ErrorResponse
+			err = json.Unmarshal(out.Bytes(), &got)
+			if err != nil {
+				t.Fatalf("expected a JSON error object, got %q: %v", out.String(), err)
+			}
+			expected := ErrorResponse{Code: test.expectedCode, Message: test.err.Error()}
+			if got != expected {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+		})
+	}
+}
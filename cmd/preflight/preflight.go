@@ -0,0 +1,192 @@
+package preflight
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/cmd/common"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// The checks run by 'preflight', in the order they are reported
+const (
+	checkAWSCredentials   = "aws-credentials"
+	checkAWSOrganizations = "aws-organizations"
+	checkOCMConnection    = "ocm-connection"
+	checkOCMWhoami        = "ocm-whoami"
+)
+
+type preflightOptions struct {
+	profile    string
+	region     string
+	configFile string
+	output     string
+
+	// newAWSClient builds the AWS client from the profile, region and config file
+	newAWSClient func(profile, region, configFile string) (awsprovider.Client, error)
+	// connectOCM opens the OCM connection, and ocmUsername returns the username it is authenticated as
+	connectOCM  func() (*sdk.Connection, error)
+	ocmUsername func(*sdk.Connection) (string, error)
+
+	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// preflightCheck is the outcome of one of the connectivity checks
+type preflightCheck struct {
+	Name   string `json:"name" yaml:"name"`
+	Passed bool   `json:"passed" yaml:"passed"`
+	Detail string `json:"detail" yaml:"detail"`
+}
+
+type preflightResponse struct {
+	Passed bool             `json:"passed" yaml:"passed"`
+	Checks []preflightCheck `json:"checks" yaml:"checks"`
+}
+
+func (f preflightResponse) String() string {
+	var sb strings.Builder
+	for _, c := range f.Checks {
+		result := "PASS"
+		if !c.Passed {
+			result = "FAIL"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s: %s\n", result, c.Name, c.Detail))
+	}
+	return sb.String()
+}
+
+func newPreflightOptions(streams genericclioptions.IOStreams, globalOpts *globalflags.GlobalOptions) *preflightOptions {
+	return &preflightOptions{
+		newAWSClient:  awsprovider.NewAwsClient,
+		connectOCM:    utils.NewOCMConnection,
+		ocmUsername:   utils.GetCurrentAccountUsername,
+		IOStreams:     streams,
+		GlobalOptions: globalOpts,
+	}
+}
+
+// NewCmdPreflight checks that the AWS credentials and the OCM connection work before running other commands
+func NewCmdPreflight(streams genericclioptions.IOStreams, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := newPreflightOptions(streams, globalOpts)
+	preflightCmd := &cobra.Command{
+		Use:               "preflight",
+		Short:             "Check AWS and OCM connectivity",
+		Long:              "Check that the AWS credentials are valid and can query the organization, and that an OCM connection can be created and is authenticated. Each check is reported as passed or failed, and the command fails if any check failed.",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(ops.complete(cmd, args))
+			cmdutil.CheckErr(ops.run())
+		},
+	}
+	preflightCmd.Flags().StringVarP(&ops.profile, "aws-profile", "p", "", "specify AWS profile")
+	preflightCmd.Flags().StringVarP(&ops.configFile, "aws-config", "c", "", "specify AWS config file path")
+	preflightCmd.Flags().StringVarP(&ops.region, "aws-region", "g", common.DefaultRegion, "specify AWS region")
+
+	return preflightCmd
+}
+
+func (o *preflightOptions) complete(_ *cobra.Command, _ []string) error {
+	o.output = o.GlobalOptions.Output
+	return nil
+}
+
+var ErrPreflightFailed = fmt.Errorf("some preflight checks failed")
+
+func (o *preflightOptions) run() error {
+	resp := o.preflight()
+
+	err := outputflag.PrintResponse(o.output, outputflag.NewEnvelope("Preflight", resp))
+	if err != nil {
+		return err
+	}
+	if !resp.Passed {
+		return ErrPreflightFailed
+	}
+	return nil
+}
+
+// preflight runs every check. A check that depends on a failed one is reported as failed without being run.
+func (o *preflightOptions) preflight() preflightResponse {
+	resp := preflightResponse{}
+	resp.Checks = append(resp.Checks, o.checkAWS()...)
+	resp.Checks = append(resp.Checks, o.checkOCM()...)
+
+	resp.Passed = true
+	for _, c := range resp.Checks {
+		resp.Passed = resp.Passed && c.Passed
+	}
+	return resp
+}
+
+// checkAWS checks that an AWS client can be built, which validates the credentials, and that it can list the roots
+// of the organization
+func (o *preflightOptions) checkAWS() []preflightCheck {
+	credentials := preflightCheck{Name: checkAWSCredentials}
+	organization := preflightCheck{Name: checkAWSOrganizations}
+
+	awsClient, err := o.newAWSClient(o.profile, o.region, o.configFile)
+	if err != nil {
+		credentials.Detail = err.Error()
+		organization.Detail = fmt.Sprintf("skipped, %s failed", checkAWSCredentials)
+		return []preflightCheck{credentials, organization}
+	}
+	identity, err := awsClient.GetCallerIdentity(nil)
+	if err != nil {
+		credentials.Detail = awsprovider.WithRequestID(err).Error()
+		organization.Detail = fmt.Sprintf("skipped, %s failed", checkAWSCredentials)
+		return []preflightCheck{credentials, organization}
+	}
+	credentials.Passed = true
+	credentials.Detail = fmt.Sprintf("authenticated as %s", aws.StringValue(identity.Arn))
+
+	roots, err := awsClient.ListRoots(&organizations.ListRootsInput{MaxResults: aws.Int64(1)})
+	if err != nil {
+		organization.Detail = awsprovider.WithOrganizationsHint(awsprovider.WithRequestID(err)).Error()
+		return []preflightCheck{credentials, organization}
+	}
+	if len(roots.Roots) == 0 {
+		organization.Detail = "no organization root found"
+		return []preflightCheck{credentials, organization}
+	}
+	organization.Passed = true
+	organization.Detail = fmt.Sprintf("root is %s", aws.StringValue(roots.Roots[0].Id))
+	return []preflightCheck{credentials, organization}
+}
+
+// checkOCM checks that an OCM connection can be created and that the current account can be retrieved with it
+func (o *preflightOptions) checkOCM() []preflightCheck {
+	connection := preflightCheck{Name: checkOCMConnection}
+	whoami := preflightCheck{Name: checkOCMWhoami}
+
+	conn, err := o.connectOCM()
+	if err != nil {
+		connection.Detail = err.Error()
+		whoami.Detail = fmt.Sprintf("skipped, %s failed", checkOCMConnection)
+		return []preflightCheck{connection, whoami}
+	}
+	if conn != nil {
+		defer conn.Close()
+	}
+	connection.Passed = true
+	connection.Detail = "connection created"
+
+	username, err := o.ocmUsername(conn)
+	if err != nil {
+		whoami.Detail = err.Error()
+		return []preflightCheck{connection, whoami}
+	}
+	whoami.Passed = true
+	whoami.Detail = fmt.Sprintf("authenticated as %s", username)
+	return []preflightCheck{connection, whoami}
+}
@@ -0,0 +1,107 @@
+package preflight
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/golang/mock/gomock"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func TestPreflight(t *testing.T) {
+	testData := []struct {
+		name           string
+		awsClientErr   error
+		identityErr    error
+		listRootsErr   error
+		ocmConnErr     error
+		ocmUsernameErr error
+		// failedChecks are the checks expected to fail, all other checks must pass
+		failedChecks []string
+	}{
+		{
+			name: "all checks pass",
+		},
+		{
+			name:         "AWS client cannot be built",
+			awsClientErr: fmt.Errorf("could not create AWS session"),
+			failedChecks: []string{checkAWSCredentials, checkAWSOrganizations},
+		},
+		{
+			name:         "AWS credentials are invalid",
+			identityErr:  fmt.Errorf("InvalidClientTokenId"),
+			failedChecks: []string{checkAWSCredentials, checkAWSOrganizations},
+		},
+		{
+			name:         "organization cannot be queried",
+			listRootsErr: fmt.Errorf("AccessDeniedException"),
+			failedChecks: []string{checkAWSOrganizations},
+		},
+		{
+			name:         "OCM connection cannot be created",
+			ocmConnErr:   fmt.Errorf("Not logged in"),
+			failedChecks: []string{checkOCMConnection, checkOCMWhoami},
+		},
+		{
+			name:           "OCM account cannot be retrieved",
+			ocmUsernameErr: fmt.Errorf("can't retrieve the current OCM account"),
+			failedChecks:   []string{checkOCMWhoami},
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			mockAWSClient := mock.NewMockClient(mockCtrl)
+
+			if test.awsClientErr == nil {
+				mockAWSClient.EXPECT().GetCallerIdentity(gomock.Any()).Return(&sts.GetCallerIdentityOutput{
+					Arn: aws.String("arn:aws:iam::111111111111:user/tuser"),
+				}, test.identityErr)
+				if test.identityErr == nil {
+					mockAWSClient.EXPECT().ListRoots(gomock.Any()).Return(&organizations.ListRootsOutput{
+						Roots: []*organizations.Root{{Id: aws.String("r-abcd")}},
+					}, test.listRootsErr)
+				}
+			}
+
+			o := &preflightOptions{
+				newAWSClient: func(profile, region, configFile string) (awsprovider.Client, error) {
+					if test.awsClientErr != nil {
+						return nil, test.awsClientErr
+					}
+					return mockAWSClient, nil
+				},
+				connectOCM: func() (*sdk.Connection, error) {
+					return nil, test.ocmConnErr
+				},
+				ocmUsername: func(*sdk.Connection) (string, error) {
+					return "tuser", test.ocmUsernameErr
+				},
+			}
+
+			resp := o.preflight()
+
+			failed := map[string]bool{}
+			for _, name := range test.failedChecks {
+				failed[name] = true
+			}
+			if len(resp.Checks) != 4 {
+				t.Fatalf("expected 4 checks, got %d: %v", len(resp.Checks), resp.Checks)
+			}
+			for _, c := range resp.Checks {
+				if c.Passed == failed[c.Name] {
+					t.Errorf("expected check %s to pass: %v, got %v (%s)", c.Name, !failed[c.Name], c.Passed, c.Detail)
+				}
+			}
+			if resp.Passed != (len(test.failedChecks) == 0) {
+				t.Errorf("expected passed to be %v, got %v", len(test.failedChecks) == 0, resp.Passed)
+			}
+		})
+	}
+}
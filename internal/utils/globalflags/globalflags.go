@@ -11,12 +11,14 @@ import (
 // GlobalOptions defines all available commands
 type GlobalOptions struct {
 	Output string
+	Quiet  bool
 }
 
 // AddGlobalFlags adds the Global Flags to the root command
 func AddGlobalFlags(cmd *cobra.Command, opts *GlobalOptions) {
 	cmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
-	cmd.PersistentFlags().StringVarP(&opts.Output, "output", "o", "", "Valid formats are ['', 'json', 'yaml', 'env']")
+	cmd.PersistentFlags().StringVarP(&opts.Output, "output", "o", "", "Valid formats are ['', 'json', 'yaml', 'env']. Some commands also support 'ndjson' or 'csv', see their help")
+	cmd.PersistentFlags().BoolVar(&opts.Quiet, "quiet", false, "Suppress informational output. Errors, prompts and '-o' payloads are still printed")
 }
 
 // GetFlags adds the kubeFlags we care about and adds the flags from the provided command
@@ -5,13 +5,14 @@ import (
 	"fmt"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type LazyClient struct {
-	client client.Client
+	client client.WithWatch
 	flags  *genericclioptions.ConfigFlags
 }
 
@@ -55,6 +56,10 @@ func (s *LazyClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ..
 	return s.getClient().DeleteAllOf(ctx, obj, opts...)
 }
 
+func (s *LazyClient) Watch(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	return s.getClient().Watch(ctx, list, opts...)
+}
+
 func (s *LazyClient) Status() client.StatusWriter {
 	return s.getClient().Status()
 }
@@ -63,7 +68,12 @@ func NewClient(flags *genericclioptions.ConfigFlags) client.Client {
 	return &LazyClient{nil, flags}
 }
 
-func (s *LazyClient) getClient() client.Client {
+// NewWatchClient returns a client which, in addition to the regular client operations, is able to watch resources
+func NewWatchClient(flags *genericclioptions.ConfigFlags) client.WithWatch {
+	return &LazyClient{nil, flags}
+}
+
+func (s *LazyClient) getClient() client.WithWatch {
 	if s.client == nil {
 		s.initialize()
 	}
@@ -79,7 +89,7 @@ func (s *LazyClient) initialize() {
 		panic(s.err())
 	}
 
-	s.client, err = client.New(cfg, client.Options{})
+	s.client, err = client.NewWithWatch(cfg, client.Options{})
 	if err != nil {
 		panic(s.err())
 	}
@@ -0,0 +1,39 @@
+package printer
+
+import (
+	"fmt"
+	"time"
+)
+
+// HumanDuration formats the duration compactly for tables, using its two most significant units, e.g. 45s, 5m30s,
+// 3h12m or 2d4h. Durations are truncated to the second, and negative durations are printed as 0s.
+func HumanDuration(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	if seconds <= 0 {
+		return "0s"
+	}
+
+	units := []struct {
+		suffix  string
+		seconds int64
+	}{
+		{"d", 24 * 60 * 60},
+		{"h", 60 * 60},
+		{"m", 60},
+		{"s", 1},
+	}
+	for i, unit := range units {
+		if seconds < unit.seconds {
+			continue
+		}
+		s := fmt.Sprintf("%d%s", seconds/unit.seconds, unit.suffix)
+		if i+1 < len(units) {
+			next := units[i+1]
+			if rest := seconds % unit.seconds / next.seconds; rest != 0 {
+				s += fmt.Sprintf("%d%s", rest, next.suffix)
+			}
+		}
+		return s
+	}
+	return "0s"
+}
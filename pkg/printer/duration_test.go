@@ -0,0 +1,70 @@
+package printer
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestHumanDuration(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	testCases := []struct {
+		title    string
+		duration time.Duration
+		output   string
+	}{
+		{
+			title:    "negative",
+			duration: -5 * time.Second,
+			output:   "0s",
+		},
+		{
+			title:    "less than a second",
+			duration: 500 * time.Millisecond,
+			output:   "0s",
+		},
+		{
+			title:    "seconds",
+			duration: 45 * time.Second,
+			output:   "45s",
+		},
+		{
+			title:    "minutes and seconds",
+			duration: 5*time.Minute + 30*time.Second,
+			output:   "5m30s",
+		},
+		{
+			title:    "whole minutes",
+			duration: 5 * time.Minute,
+			output:   "5m",
+		},
+		{
+			title:    "hours and minutes, dropping the seconds",
+			duration: 3*time.Hour + 12*time.Minute + 59*time.Second,
+			output:   "3h12m",
+		},
+		{
+			title:    "hours and seconds, dropping the seconds",
+			duration: 3*time.Hour + 59*time.Second,
+			output:   "3h",
+		},
+		{
+			title:    "days and hours",
+			duration: 50*time.Hour + 30*time.Minute,
+			output:   "2d2h",
+		},
+		{
+			title:    "many days",
+			duration: 400 * 24 * time.Hour,
+			output:   "400d",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			g.Expect(HumanDuration(tc.duration)).To(Equal(tc.output))
+		})
+	}
+}
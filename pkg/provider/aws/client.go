@@ -93,6 +93,7 @@ type Client interface {
 
 	// Organizations
 	CreateAccount(input *organizations.CreateAccountInput) (*organizations.CreateAccountOutput, error)
+	CreateGovCloudAccount(input *organizations.CreateGovCloudAccountInput) (*organizations.CreateGovCloudAccountOutput, error)
 	DescribeCreateAccountStatus(input *organizations.DescribeCreateAccountStatusInput) (*organizations.DescribeCreateAccountStatusOutput, error)
 	ListAccounts(input *organizations.ListAccountsInput) (*organizations.ListAccountsOutput, error)
 	ListParents(input *organizations.ListParentsInput) (*organizations.ListParentsOutput, error)
@@ -376,6 +377,10 @@ func (c *AwsClient) CreateAccount(input *organizations.CreateAccountInput) (*org
 	return c.orgClient.CreateAccount(input)
 }
 
+func (c *AwsClient) CreateGovCloudAccount(input *organizations.CreateGovCloudAccountInput) (*organizations.CreateGovCloudAccountOutput, error) {
+	return c.orgClient.CreateGovCloudAccount(input)
+}
+
 func (c *AwsClient) DescribeCreateAccountStatus(input *organizations.DescribeCreateAccountStatusInput) (*organizations.DescribeCreateAccountStatusOutput, error) {
 	return c.orgClient.DescribeCreateAccountStatus(input)
 }
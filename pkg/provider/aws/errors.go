@@ -0,0 +1,65 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+// RequestIDError wraps an error returned by a failed AWS request along with the request's ID,
+// which AWS support needs in order to troubleshoot the failure
+type RequestIDError struct {
+	RequestID string
+	Err       error
+}
+
+func (e *RequestIDError) Error() string {
+	return fmt.Sprintf("%v (AWS request ID: %s)", e.Err, e.RequestID)
+}
+
+func (e *RequestIDError) Unwrap() error {
+	return e.Err
+}
+
+// WithRequestID wraps the given error in a RequestIDError if it is an AWS request failure that carries
+// a request ID. Any other error is returned unchanged.
+func WithRequestID(err error) error {
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) && reqErr.RequestID() != "" {
+		return &RequestIDError{
+			RequestID: reqErr.RequestID(),
+			Err:       err,
+		}
+	}
+	return err
+}
+
+// OrganizationsNotAvailableError wraps an error returned by AWS Organizations because the caller's account isn't the
+// organization's management account, explaining what to do about it
+type OrganizationsNotAvailableError struct {
+	Err error
+}
+
+func (e *OrganizationsNotAvailableError) Error() string {
+	return fmt.Sprintf("%v\nAWS Organizations can only be managed from the organization's management account, run the command with that account's credentials (the payer account profile)", e.Err)
+}
+
+func (e *OrganizationsNotAvailableError) Unwrap() error {
+	return e.Err
+}
+
+// WithOrganizationsHint wraps the given error in an OrganizationsNotAvailableError if AWS Organizations rejected the
+// request because it isn't in use by, or access to it was denied to, the caller's account. Any other error is returned
+// unchanged.
+func WithOrganizationsHint(err error) error {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		switch aerr.Code() {
+		case organizations.ErrCodeAWSOrganizationsNotInUseException, organizations.ErrCodeAccessDeniedException:
+			return &OrganizationsNotAvailableError{Err: err}
+		}
+	}
+	return err
+}
@@ -132,6 +132,21 @@ func (mr *MockClientMockRecorder) CreateCostCategoryDefinition(input interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCostCategoryDefinition", reflect.TypeOf((*MockClient)(nil).CreateCostCategoryDefinition), input)
 }
 
+// CreateGovCloudAccount mocks base method.
+func (m *MockClient) CreateGovCloudAccount(input *organizations.CreateGovCloudAccountInput) (*organizations.CreateGovCloudAccountOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGovCloudAccount", input)
+	ret0, _ := ret[0].(*organizations.CreateGovCloudAccountOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGovCloudAccount indicates an expected call of CreateGovCloudAccount.
+func (mr *MockClientMockRecorder) CreateGovCloudAccount(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGovCloudAccount", reflect.TypeOf((*MockClient)(nil).CreateGovCloudAccount), input)
+}
+
 // CreatePolicy mocks base method.
 func (m *MockClient) CreatePolicy(arg0 *iam.CreatePolicyInput) (*iam.CreatePolicyOutput, error) {
 	m.ctrl.T.Helper()
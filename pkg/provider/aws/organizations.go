@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ErrStopIteration can be returned by the callback given to ForEachAccount to stop iterating without an error
+var ErrStopIteration = errors.New("stop iteration")
+
+// throttleBackoff is how Organizations requests that were throttled are retried
+var throttleBackoff = wait.Backoff{Duration: time.Second, Factor: 2, Steps: 5}
+
+// OrgAccount is an account of an AWS organization. Its tags are only retrieved once Tags is first called.
+type OrgAccount struct {
+	*organizations.Account
+
+	client Client
+	tags   map[string]string
+}
+
+// Tags returns the account's tags, following every page of them
+func (a *OrgAccount) Tags() (map[string]string, error) {
+	if a.tags != nil {
+		return a.tags, nil
+	}
+
+	tags := map[string]string{}
+	input := &organizations.ListTagsForResourceInput{ResourceId: a.Id}
+	for {
+		var output *organizations.ListTagsForResourceOutput
+		err := retryThrottled(func() (err error) {
+			output, err = a.client.ListTagsForResource(input)
+			return err
+		})
+		if err != nil {
+			return nil, WithRequestID(err)
+		}
+		for _, tag := range output.Tags {
+			if tag.Key != nil && tag.Value != nil {
+				tags[*tag.Key] = *tag.Value
+			}
+		}
+		if output.NextToken == nil || *output.NextToken == "" {
+			break
+		}
+		input = &organizations.ListTagsForResourceInput{ResourceId: a.Id, NextToken: output.NextToken}
+	}
+
+	a.tags = tags
+	return tags, nil
+}
+
+// ForEachAccount calls fn with every account directly under the given parent, or with every account of the
+// organization if parentID is empty, across all pages. Throttled requests are retried. Iterating stops at the
+// first error, which is returned unless it is ErrStopIteration.
+func ForEachAccount(client Client, parentID string, fn func(account *OrgAccount) error) error {
+	var nextToken *string
+	for {
+		var (
+			accounts []*organizations.Account
+			err      error
+		)
+		if parentID == "" {
+			accounts, nextToken, err = listAccountsPage(client, nextToken)
+		} else {
+			accounts, nextToken, err = listAccountsForParentPage(client, parentID, nextToken)
+		}
+		if err != nil {
+			return WithRequestID(err)
+		}
+
+		for _, account := range accounts {
+			err = fn(&OrgAccount{Account: account, client: client})
+			if err == ErrStopIteration {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if nextToken == nil || *nextToken == "" {
+			return nil
+		}
+	}
+}
+
+func listAccountsPage(client Client, nextToken *string) ([]*organizations.Account, *string, error) {
+	var output *organizations.ListAccountsOutput
+	err := retryThrottled(func() (err error) {
+		output, err = client.ListAccounts(&organizations.ListAccountsInput{NextToken: nextToken})
+		return err
+	})
+	if err != nil {
+		return nil, nil, WithOrganizationsHint(err)
+	}
+	return output.Accounts, output.NextToken, nil
+}
+
+func listAccountsForParentPage(client Client, parentID string, nextToken *string) ([]*organizations.Account, *string, error) {
+	var output *organizations.ListAccountsForParentOutput
+	err := retryThrottled(func() (err error) {
+		output, err = client.ListAccountsForParent(&organizations.ListAccountsForParentInput{ParentId: &parentID, NextToken: nextToken})
+		return err
+	})
+	if err != nil {
+		return nil, nil, WithOrganizationsHint(err)
+	}
+	return output.Accounts, output.NextToken, nil
+}
+
+// retryThrottled calls fn until it succeeds, fails with an error other than throttling, or throttleBackoff runs out
+func retryThrottled(fn func() error) error {
+	var lastErr error
+	err := wait.ExponentialBackoff(throttleBackoff, func() (bool, error) {
+		lastErr = fn()
+		if lastErr == nil {
+			return true, nil
+		}
+		var aerr awserr.Error
+		if errors.As(lastErr, &aerr) && aerr.Code() == organizations.ErrCodeTooManyRequestsException {
+			return false, nil
+		}
+		return false, lastErr
+	})
+	if err == wait.ErrWaitTimeout {
+		return lastErr
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/golang/mock/gomock"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestForEachAccount(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mocks := setupDefaultMocks(t)
+	defer mocks.mockCtrl.Finish()
+
+	r := mocks.mockAWSClient.EXPECT()
+	gomock.InOrder(
+		r.ListAccountsForParent(&organizations.ListAccountsForParentInput{ParentId: aws.String("ou-abcd")}).Return(
+			&organizations.ListAccountsForParentOutput{
+				Accounts:  []*organizations.Account{{Id: aws.String("111111111111")}, {Id: aws.String("222222222222")}},
+				NextToken: aws.String("page-2"),
+			}, nil).Times(1),
+		r.ListAccountsForParent(&organizations.ListAccountsForParentInput{ParentId: aws.String("ou-abcd"), NextToken: aws.String("page-2")}).Return(
+			&organizations.ListAccountsForParentOutput{
+				Accounts: []*organizations.Account{{Id: aws.String("333333333333")}},
+			}, nil).Times(1),
+	)
+	// Tags are only listed for the accounts which ask for them, once
+	r.ListTagsForResource(&organizations.ListTagsForResourceInput{ResourceId: aws.String("222222222222")}).Return(
+		&organizations.ListTagsForResourceOutput{
+			Tags: []*organizations.Tag{{Key: aws.String("owner"), Value: aws.String("tuser")}},
+		}, nil).Times(1)
+
+	ids := []string{}
+	err := ForEachAccount(mocks.mockAWSClient, "ou-abcd", func(account *OrgAccount) error {
+		ids = append(ids, *account.Id)
+		if *account.Id == "222222222222" {
+			tags, err := account.Tags()
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(tags).Should(Equal(map[string]string{"owner": "tuser"}))
+			tags, err = account.Tags()
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(tags).Should(HaveKey("owner"))
+		}
+		return nil
+	})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ids).Should(Equal([]string{"111111111111", "222222222222", "333333333333"}))
+}
+
+func TestForEachAccountStop(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mocks := setupDefaultMocks(t)
+	defer mocks.mockCtrl.Finish()
+
+	mocks.mockAWSClient.EXPECT().ListAccounts(&organizations.ListAccountsInput{}).Return(
+		&organizations.ListAccountsOutput{
+			Accounts:  []*organizations.Account{{Id: aws.String("111111111111")}, {Id: aws.String("222222222222")}},
+			NextToken: aws.String("page-2"),
+		}, nil).Times(1)
+
+	ids := []string{}
+	err := ForEachAccount(mocks.mockAWSClient, "", func(account *OrgAccount) error {
+		ids = append(ids, *account.Id)
+		return ErrStopIteration
+	})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ids).Should(Equal([]string{"111111111111"}))
+}
+
+func TestForEachAccountThrottled(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mocks := setupDefaultMocks(t)
+	defer mocks.mockCtrl.Finish()
+
+	defer func(backoff wait.Backoff) { throttleBackoff = backoff }(throttleBackoff)
+	throttleBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 2}
+
+	throttled := awserr.New(organizations.ErrCodeTooManyRequestsException, "", errors.New("FakeError"))
+	r := mocks.mockAWSClient.EXPECT()
+	gomock.InOrder(
+		r.ListAccounts(gomock.Any()).Return(nil, throttled).Times(1),
+		r.ListAccounts(gomock.Any()).Return(
+			&organizations.ListAccountsOutput{Accounts: []*organizations.Account{{Id: aws.String("111111111111")}}}, nil).Times(1),
+		// Once the backoff runs out, the throttling error is returned
+		r.ListAccounts(gomock.Any()).Return(nil, throttled).Times(2),
+	)
+
+	count := 0
+	err := ForEachAccount(mocks.mockAWSClient, "", func(account *OrgAccount) error {
+		count++
+		return nil
+	})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(count).Should(Equal(1))
+
+	err = ForEachAccount(mocks.mockAWSClient, "", func(account *OrgAccount) error {
+		return nil
+	})
+	g.Expect(err).Should(Equal(throttled))
+}
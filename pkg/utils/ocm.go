@@ -62,17 +62,42 @@ func GenerateQuery(clusterIdentifier string) string {
 	return strings.TrimSpace(fmt.Sprintf("(id like '%[1]s' or external_id like '%[1]s' or display_name like '%[1]s')", clusterIdentifier))
 }
 
+// CreateConnection returns a connection to OCM, exiting with guidance on how to fix the failure if one can't be created
 func CreateConnection() *sdk.Connection {
-	connection, err := ocm.NewConnection().Build()
+	connection, err := NewOCMConnection()
 	if err != nil {
-		if strings.Contains(err.Error(), "Not logged in, run the") {
-			log.Fatalf("Failed to create OCM connection: Authentication error, run the 'ocm login' command first.")
-		}
-		log.Fatalf("Failed to create OCM connection: %v", err)
+		log.Fatal(err)
 	}
 	return connection
 }
 
+// NewOCMConnection returns a connection to OCM. Errors are wrapped with ConnectionError.
+func NewOCMConnection() (*sdk.Connection, error) {
+	connection, err := ocm.NewConnection().Build()
+	if err != nil {
+		return nil, ConnectionError(err)
+	}
+	return connection, nil
+}
+
+// GetCurrentAccountUsername returns the username of the OCM account the connection is authenticated as
+func GetCurrentAccountUsername(connection *sdk.Connection) (string, error) {
+	response, err := connection.AccountsMgmt().V1().CurrentAccount().Get().Send()
+	if err != nil {
+		return "", fmt.Errorf("can't retrieve the current OCM account: %v", err)
+	}
+	return response.Body().Username(), nil
+}
+
+// ConnectionError wraps an error encountered while connecting to OCM with guidance on how to resolve it.
+// The original error remains available to errors.Is and errors.As.
+func ConnectionError(err error) error {
+	if strings.Contains(err.Error(), "Not logged in, run the") {
+		return fmt.Errorf("failed to create OCM connection: %w\nAuthentication error, run the 'ocm login' command first", err)
+	}
+	return fmt.Errorf("failed to create OCM connection: %w\nYour OCM token may have expired: run the 'ocm login' command, or check that $OCM_CONFIG points to a valid configuration file", err)
+}
+
 func GetSupportRoleArnForCluster(ocmClient *sdk.Connection, clusterID string) (string, error) {
 	liveResponse, err := ocmClient.ClustersMgmt().V1().Clusters().Cluster(clusterID).Resources().Live().Get().Send()
 	if err != nil {
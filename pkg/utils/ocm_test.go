@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConnectionError(t *testing.T) {
+	tests := []struct {
+		name             string
+		err              error
+		expectedGuidance string
+	}{
+		{
+			name:             "not logged in",
+			err:              fmt.Errorf("Not logged in, run the 'login' command"),
+			expectedGuidance: "run the 'ocm login' command first",
+		},
+		{
+			name:             "other connection failure",
+			err:              fmt.Errorf("can't read config file"),
+			expectedGuidance: "check that $OCM_CONFIG points to a valid configuration file",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ConnectionError(test.err)
+			if !strings.Contains(err.Error(), test.expectedGuidance) {
+				t.Errorf("expected error to contain guidance %q, got %q", test.expectedGuidance, err.Error())
+			}
+			if !errors.Is(err, test.err) {
+				t.Errorf("expected the original error to be unwrappable from %q", err.Error())
+			}
+		})
+	}
+}
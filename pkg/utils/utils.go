@@ -3,7 +3,9 @@ package utils
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"regexp"
+	"strconv"
 	"strings"
 
 	sdk "github.com/openshift-online/ocm-sdk-go"
@@ -50,6 +52,29 @@ func GetCurrentOCMEnv(connection *sdk.Connection) string {
 	return currentEnv
 }
 
+// maxClusterMatches is how many of the clusters matching an ambiguous key are retrieved to pick from
+const maxClusterMatches = 20
+
+// ClusterMatch is one of the clusters matching an ambiguous cluster key
+type ClusterMatch struct {
+	ID   string
+	Name string
+}
+
+// AmbiguousClusterError is returned by GetCluster when several clusters match the given key. Matches holds at most
+// maxClusterMatches of the Total clusters matching it.
+type AmbiguousClusterError struct {
+	Key     string
+	Total   int
+	Matches []ClusterMatch
+
+	msg string
+}
+
+func (e *AmbiguousClusterError) Error() string {
+	return e.msg
+}
+
 // GetCluster Function allows to get a single cluster with any identifier (displayname, ID, or external ID)
 func GetCluster(connection *sdk.Connection, key string) (cluster *cmv1.Cluster, err error) {
 	// Prepare the resources that we will be using:
@@ -64,7 +89,7 @@ func GetCluster(connection *sdk.Connection, key string) (cluster *cmv1.Cluster,
 	)
 	subsListResponse, err := subsResource.List().
 		Search(subsSearch).
-		Size(1).
+		Size(maxClusterMatches).
 		Send()
 	if err != nil {
 		err = fmt.Errorf("Can't retrieve subscription for key '%s': %v", key, err)
@@ -94,10 +119,17 @@ func GetCluster(connection *sdk.Connection, key string) (cluster *cmv1.Cluster,
 	// If there are multiple subscriptions that match the cluster then we should report it as
 	// an error:
 	if subsTotal > 1 {
-		err = fmt.Errorf(
-			"There are %d subscriptions with cluster identifier or name '%s'",
-			subsTotal, key,
-		)
+		ambiguous := &AmbiguousClusterError{
+			Key:   key,
+			Total: subsTotal,
+			msg:   fmt.Sprintf("There are %d subscriptions with cluster identifier or name '%s'", subsTotal, key),
+		}
+		for _, sub := range subsListResponse.Items().Slice() {
+			if id, ok := sub.GetClusterID(); ok {
+				ambiguous.Matches = append(ambiguous.Matches, ClusterMatch{ID: id, Name: sub.DisplayName()})
+			}
+		}
+		err = ambiguous
 		return
 	}
 
@@ -111,7 +143,7 @@ func GetCluster(connection *sdk.Connection, key string) (cluster *cmv1.Cluster,
 	)
 	clustersListResponse, err := clustersResource.List().
 		Search(clustersSearch).
-		Size(1).
+		Size(maxClusterMatches).
 		Send()
 	if err != nil {
 		err = fmt.Errorf("Can't retrieve clusters for key '%s': %v", key, err)
@@ -127,10 +159,15 @@ func GetCluster(connection *sdk.Connection, key string) (cluster *cmv1.Cluster,
 
 	// If there are multiple matching clusters then we should report it as an error:
 	if clustersTotal > 1 {
-		err = fmt.Errorf(
-			"There are %d clusters with identifier or name '%s'",
-			clustersTotal, key,
-		)
+		ambiguous := &AmbiguousClusterError{
+			Key:   key,
+			Total: clustersTotal,
+			msg:   fmt.Sprintf("There are %d clusters with identifier or name '%s'", clustersTotal, key),
+		}
+		for _, c := range clustersListResponse.Items().Slice() {
+			ambiguous.Matches = append(ambiguous.Matches, ClusterMatch{ID: c.ID(), Name: c.Name()})
+		}
+		err = ambiguous
 		return
 	}
 
@@ -142,6 +179,106 @@ func GetCluster(connection *sdk.Connection, key string) (cluster *cmv1.Cluster,
 	return
 }
 
+// ResolveCluster validates the given cluster identifier, opens a connection to OCM and retrieves the cluster it
+// identifies. On success the caller is responsible for closing the returned connection; on failure it has
+// already been closed.
+func ResolveCluster(clusterKey string) (*cmv1.Cluster, *sdk.Connection, error) {
+	return resolveCluster(clusterKey, NewOCMConnection, GetCluster)
+}
+
+// ResolveClusterInteractively is like ResolveCluster, but lets the operator pick one of the clusters matching an
+// ambiguous identifier using the given streams
+func ResolveClusterInteractively(clusterKey string, streams genericclioptions.IOStreams) (*cmv1.Cluster, *sdk.Connection, error) {
+	return resolveCluster(clusterKey, NewOCMConnection, func(connection *sdk.Connection, key string) (*cmv1.Cluster, error) {
+		return SelectCluster(connection, key, streams)
+	})
+}
+
+func resolveCluster(clusterKey string, connect func() (*sdk.Connection, error), get func(*sdk.Connection, string) (*cmv1.Cluster, error)) (*cmv1.Cluster, *sdk.Connection, error) {
+	err := IsValidClusterKey(clusterKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	connection, err := connect()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cluster, err := get(connection, clusterKey)
+	if err != nil {
+		_ = connection.Close()
+		return nil, nil, err
+	}
+
+	return cluster, connection, nil
+}
+
+// SelectCluster returns the cluster identified by the given key, like GetCluster. If several clusters match the key,
+// they are printed as a numbered list on the given streams and the operator is asked to pick one.
+func SelectCluster(connection *sdk.Connection, key string, streams genericclioptions.IOStreams) (*cmv1.Cluster, error) {
+	cluster, err := GetCluster(connection, key)
+	ambiguous, ok := err.(*AmbiguousClusterError)
+	if !ok || len(ambiguous.Matches) == 0 {
+		return cluster, err
+	}
+
+	match, err := PickCluster(streams, ambiguous)
+	if err != nil {
+		return nil, err
+	}
+	return GetCluster(connection, match.ID)
+}
+
+// PickCluster prints the clusters matching an ambiguous key as a numbered list and returns the one the operator
+// picks by number. When streams.In is a *bufio.Reader, the selection is read through it, so a caller reading its
+// own prompts from the same buffer doesn't lose input to the picker.
+func PickCluster(streams genericclioptions.IOStreams, ambiguous *AmbiguousClusterError) (ClusterMatch, error) {
+	StreamPrintln(streams, fmt.Sprintf("%s:", ambiguous.Error()))
+	for i, match := range ambiguous.Matches {
+		StreamPrintln(streams, fmt.Sprintf("  %d) %s (%s)", i+1, match.Name, match.ID))
+	}
+	if ambiguous.Total > len(ambiguous.Matches) {
+		StreamPrintln(streams, fmt.Sprintf("Only the first %d are listed, use the cluster ID to select another one.", len(ambiguous.Matches)))
+	}
+	StreamPrint(streams, fmt.Sprintf("Select a cluster [1-%d]: ", len(ambiguous.Matches)))
+
+	input, err := readLine(streams.In)
+	if err != nil && input == "" {
+		return ClusterMatch{}, fmt.Errorf("failed to read the selected cluster: %w", err)
+	}
+	selected, err := strconv.Atoi(input)
+	if err != nil || selected < 1 || selected > len(ambiguous.Matches) {
+		return ClusterMatch{}, fmt.Errorf("invalid selection '%s', expected a number between 1 and %d", input, len(ambiguous.Matches))
+	}
+	return ambiguous.Matches[selected-1], nil
+}
+
+// readLine reads a single line from r, without buffering past it so later reads from r still see the
+// following lines. A *bufio.Reader is read through its buffer instead. The returned line has its surrounding
+// whitespace trimmed.
+func readLine(r io.Reader) (string, error) {
+	if br, ok := r.(*bufio.Reader); ok {
+		line, err := br.ReadString('\n')
+		return strings.TrimSpace(line), err
+	}
+	var sb strings.Builder
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				break
+			}
+			sb.WriteByte(b[0])
+		}
+		if err != nil {
+			return strings.TrimSpace(sb.String()), err
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
 func GetClusterLimitedSupportReasons(connection *sdk.Connection, clusterID string) ([]*LimitedSupportReasonItem, error) {
 
 	limitedSupportReasons, err := connection.ClustersMgmt().V1().
@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// fakeToken returns an unsigned access token the OCM connection will accept, it's never verified client side
+func fakeToken(t *testing.T) string {
+	header, err := json.Marshal(map[string]string{"alg": "none", "typ": "JWT"})
+	if err != nil {
+		t.Fatalf("failed to marshal token header: %v", err)
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"typ": "Bearer",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal token claims: %v", err)
+	}
+	return fmt.Sprintf("%s.%s.", base64.RawURLEncoding.EncodeToString(header), base64.RawURLEncoding.EncodeToString(claims))
+}
+
+// newFakeConnection returns a function connecting to a fake OCM that knows about a cluster named 'fake-cluster', and
+// two clusters both named 'dup-cluster'
+func newFakeConnection(t *testing.T) func() (*sdk.Connection, error) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/accounts_mgmt/v1/subscriptions":
+			if strings.Contains(r.URL.Query().Get("search"), "'fake-cluster'") {
+				fmt.Fprint(w, `{"kind": "SubscriptionList", "page": 1, "size": 1, "total": 1, "items": [{"kind": "Subscription", "cluster_id": "fake-cluster-id"}]}`)
+				return
+			}
+			if strings.Contains(r.URL.Query().Get("search"), "'dup-cluster'") {
+				fmt.Fprint(w, `{"kind": "SubscriptionList", "page": 1, "size": 2, "total": 2, "items": [`+
+					`{"kind": "Subscription", "cluster_id": "dup-cluster-id-1", "display_name": "dup-cluster"},`+
+					`{"kind": "Subscription", "cluster_id": "dup-cluster-id-2", "display_name": "dup-cluster"}]}`)
+				return
+			}
+			if strings.Contains(r.URL.Query().Get("search"), "'dup-cluster-id-2'") {
+				fmt.Fprint(w, `{"kind": "SubscriptionList", "page": 1, "size": 1, "total": 1, "items": [{"kind": "Subscription", "cluster_id": "dup-cluster-id-2"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"kind": "SubscriptionList", "page": 1, "size": 0, "total": 0, "items": []}`)
+		case "/api/clusters_mgmt/v1/clusters/fake-cluster-id":
+			fmt.Fprint(w, `{"kind": "Cluster", "id": "fake-cluster-id", "name": "fake-cluster"}`)
+		case "/api/clusters_mgmt/v1/clusters/dup-cluster-id-2":
+			fmt.Fprint(w, `{"kind": "Cluster", "id": "dup-cluster-id-2", "name": "dup-cluster"}`)
+		case "/api/clusters_mgmt/v1/clusters":
+			fmt.Fprint(w, `{"kind": "ClusterList", "page": 1, "size": 0, "total": 0, "items": []}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return func() (*sdk.Connection, error) {
+		return sdk.NewConnectionBuilder().URL(server.URL).Tokens(fakeToken(t)).Build()
+	}
+}
+
+func TestResolveCluster(t *testing.T) {
+	tests := []struct {
+		name        string
+		clusterKey  string
+		connectErr  error
+		expectedID  string
+		expectedErr string
+	}{
+		{
+			name:       "resolves cluster",
+			clusterKey: "fake-cluster",
+			expectedID: "fake-cluster-id",
+		},
+		{
+			name:        "invalid cluster key",
+			clusterKey:  "fake cluster",
+			expectedErr: "isn't valid",
+		},
+		{
+			name:        "connection failure",
+			clusterKey:  "fake-cluster",
+			connectErr:  fmt.Errorf("can't read config file"),
+			expectedErr: "failed to create OCM connection",
+		},
+		{
+			name:        "unknown cluster",
+			clusterKey:  "unknown-cluster",
+			expectedErr: "There are no subscriptions or clusters with identifier or name 'unknown-cluster'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			connect := newFakeConnection(t)
+			if test.connectErr != nil {
+				connect = func() (*sdk.Connection, error) {
+					return nil, ConnectionError(test.connectErr)
+				}
+			}
+
+			cluster, connection, err := resolveCluster(test.clusterKey, connect, GetCluster)
+			if test.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedErr, err)
+				}
+				if cluster != nil || connection != nil {
+					t.Errorf("expected no cluster or connection to be returned on error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer connection.Close()
+			if cluster.ID() != test.expectedID {
+				t.Errorf("expected cluster ID %s, got %s", test.expectedID, cluster.ID())
+			}
+		})
+	}
+}
+
+func TestSelectCluster(t *testing.T) {
+	tests := []struct {
+		name        string
+		clusterKey  string
+		input       string
+		expectedID  string
+		expectedErr string
+	}{
+		{
+			name:       "unambiguous cluster",
+			clusterKey: "fake-cluster",
+			expectedID: "fake-cluster-id",
+		},
+		{
+			name:       "picks the selected cluster",
+			clusterKey: "dup-cluster",
+			input:      "2\n",
+			expectedID: "dup-cluster-id-2",
+		},
+		{
+			name:        "out of range selection",
+			clusterKey:  "dup-cluster",
+			input:       "3\n",
+			expectedErr: "invalid selection '3'",
+		},
+		{
+			name:        "no selection",
+			clusterKey:  "dup-cluster",
+			expectedErr: "failed to read the selected cluster",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			connection, err := newFakeConnection(t)()
+			if err != nil {
+				t.Fatalf("failed to connect to fake OCM: %v", err)
+			}
+			defer connection.Close()
+
+			out := &bytes.Buffer{}
+			streams := genericclioptions.IOStreams{In: strings.NewReader(test.input), Out: out, ErrOut: out}
+
+			cluster, err := SelectCluster(connection, test.clusterKey, streams)
+			if test.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cluster.ID() != test.expectedID {
+				t.Errorf("expected cluster ID %s, got %s", test.expectedID, cluster.ID())
+			}
+		})
+	}
+}
+
+func TestPickClusterBufferedReader(t *testing.T) {
+	ambiguous := &AmbiguousClusterError{
+		Key:     "dup-cluster",
+		Total:   2,
+		Matches: []ClusterMatch{{ID: "dup-cluster-id-1", Name: "dup-cluster"}, {ID: "dup-cluster-id-2", Name: "dup-cluster"}},
+		msg:     "several clusters match 'dup-cluster'",
+	}
+	// The caller has already buffered the input following the selection, e.g. the next prompt's answer
+	in := bufio.NewReader(strings.NewReader("2\ny\n"))
+	_, err := in.Peek(1)
+	if err != nil {
+		t.Fatalf("failed to fill the buffer: %v", err)
+	}
+	out := &bytes.Buffer{}
+	streams := genericclioptions.IOStreams{In: in, Out: out, ErrOut: out}
+
+	match, err := PickCluster(streams, ambiguous)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if match.ID != "dup-cluster-id-2" {
+		t.Errorf("expected cluster ID dup-cluster-id-2, got %s", match.ID)
+	}
+	next, err := in.ReadString('\n')
+	if err != nil || next != "y\n" {
+		t.Errorf("expected the next line to be left in the buffer, got %q (%v)", next, err)
+	}
+}
+
+func TestGetClusterAmbiguous(t *testing.T) {
+	connection, err := newFakeConnection(t)()
+	if err != nil {
+		t.Fatalf("failed to connect to fake OCM: %v", err)
+	}
+	defer connection.Close()
+
+	_, err = GetCluster(connection, "dup-cluster")
+	ambiguous, ok := err.(*AmbiguousClusterError)
+	if !ok {
+		t.Fatalf("expected an AmbiguousClusterError, got %v", err)
+	}
+	if ambiguous.Error() != "There are 2 subscriptions with cluster identifier or name 'dup-cluster'" {
+		t.Errorf("unexpected error message: %s", ambiguous.Error())
+	}
+	if len(ambiguous.Matches) != 2 || ambiguous.Matches[1].ID != "dup-cluster-id-2" {
+		t.Errorf("unexpected matches: %v", ambiguous.Matches)
+	}
+}